@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"roastgit/internal/cache"
+	"roastgit/internal/git"
+	"roastgit/internal/util"
+)
+
+// runCache inspects or removes the per-commit cache and incremental state
+// of a repository.
+func runCache(args []string) int {
+	const usage = "usage: roastgit cache stats|clear [--path dir] [--json]"
+	if len(args) == 0 || (args[0] != "stats" && args[0] != "clear") {
+		exitWith(exitUsage, usage, true)
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("roastgit cache "+action, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("path", "", "path to repo (default: auto-detect from cwd)")
+	backend := fs.String("backend", git.BackendExec, "git backend: exec or native")
+	jsonOut := fs.Bool("json", false, "output JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usageText())
+			return exitOK
+		}
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if fs.NArg() > 0 {
+		exitWith(exitUsage, usage, true)
+	}
+	if *backend != git.BackendExec && *backend != git.BackendNative {
+		exitWith(exitUsage, "--backend must be 'exec' or 'native'", true)
+	}
+	if *backend == git.BackendExec {
+		if err := git.EnsureGit(); err != nil {
+			exitWith(exitGitError, "git executable not found in PATH (try --backend native)", false)
+		}
+	}
+	repoPath, err := resolveRepo(*path)
+	if err != nil {
+		if errors.Is(err, git.ErrNotRepo) {
+			exitWith(exitNotRepo, "not a git repository", false)
+		}
+		exitWith(exitGitError, err.Error(), false)
+	}
+	repo, err := git.Open(*backend, repoPath)
+	if err != nil {
+		handleGitError(err)
+	}
+	defer repo.Close()
+	gitDir, err := repo.GitDir(context.Background())
+	if err != nil {
+		handleGitError(err)
+	}
+	dir := cache.Dir(gitDir)
+
+	if action == "clear" {
+		if err := cache.Clear(dir); err != nil {
+			exitWith(exitGitError, err.Error(), false)
+		}
+		fmt.Fprintf(os.Stdout, "Cleared %s\n", dir)
+		return exitOK
+	}
+	stats, err := cache.ReadStats(dir)
+	if err != nil {
+		exitWith(exitGitError, err.Error(), false)
+	}
+	if *jsonOut {
+		data, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			exitWith(exitGitError, err.Error(), false)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return exitOK
+	}
+	fmt.Fprintf(os.Stdout, "Cache: %s\n", stats.Path)
+	if stats.Version != cache.FormatVersion {
+		fmt.Fprintf(os.Stdout, "Format version %d is outdated (current %d); the next run rebuilds it.\n", stats.Version, cache.FormatVersion)
+		return exitOK
+	}
+	fmt.Fprintf(os.Stdout, "Format version: %d\n", stats.Version)
+	fmt.Fprintf(os.Stdout, "Commits: %d (sizes %d, file stats %d, message checks %d)\n", stats.Commits, stats.Sizes, stats.Files, stats.Messages)
+	fmt.Fprintf(os.Stdout, "Size on disk: %s\n", formatBytes(stats.Bytes))
+	if stats.StateHead != "" {
+		fmt.Fprintf(os.Stdout, "Incremental state: %d commits at %s (%s)\n", stats.StateCommits, util.ShortSHA(stats.StateHead), formatBytes(stats.StateBytes))
+	} else {
+		fmt.Fprintln(os.Stdout, "Incremental state: none")
+	}
+	return exitOK
+}
+
+// formatBytes renders n with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
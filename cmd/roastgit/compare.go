@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"roastgit/internal/compare"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/render"
+)
+
+// runCompare diffs two JSON reports written by --json.
+func runCompare(args []string) int {
+	fs := flag.NewFlagSet("roastgit compare", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	jsonOut := fs.Bool("json", false, "output JSON")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usageText())
+			return exitOK
+		}
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if fs.NArg() != 2 {
+		exitWith(exitUsage, "compare takes two report files: roastgit compare <a.json> <b.json>", true)
+	}
+	reports := make([]model.Report, 2)
+	for i, path := range fs.Args() {
+		report, err := readReport(path)
+		if err != nil {
+			exitWith(exitUsage, err.Error(), false)
+		}
+		reports[i] = report
+	}
+	cmp := compare.Reports(fs.Arg(0), reports[0], fs.Arg(1), reports[1])
+	writeComparison(cmp, *jsonOut, *noColor)
+	return exitOK
+}
+
+// runCompareRange analyzes two revision ranges of the same repo and diffs them.
+func runCompareRange(ctx context.Context, repoPath string, cfg model.Config) int {
+	repo, err := git.Open(cfg.Backend, repoPath)
+	if err != nil {
+		handleGitError(err)
+	}
+	defer repo.Close()
+	reports := make([]model.Report, 2)
+	tips := make([]string, 2)
+	for i, r := range cfg.CompareRange {
+		rangeCfg := cfg
+		rangeCfg.Range = r
+		report, err := buildReport(ctx, repoPath, rangeCfg)
+		if err != nil {
+			handleRunError(ctx, err)
+		}
+		if report.Partial != nil {
+			// Comparing a cut-short range would report made-up changes.
+			exitWith(partialExitCode(report.Partial), fmt.Sprintf("%s: the run was cut short; nothing compared", r), false)
+		}
+		reports[i] = report
+		if tips[i], err = repo.ResolveCommit(ctx, rangeTip(r)); err != nil {
+			handleRunError(ctx, err)
+		}
+	}
+	cmp := compare.Reports(cfg.CompareRange[0], reports[0], cfg.CompareRange[1], reports[1])
+	// The reports name HEAD; each side of a range ends at its own tip.
+	cmp.Base.Head, cmp.Head.Head = tips[0], tips[1]
+	writeComparison(cmp, cfg.JSON, cfg.NoColor)
+	return exitOK
+}
+
+// rangeTip returns the revision a range ends at: B of A..B or A...B, HEAD
+// when B is left out, or the range itself when it names a single revision.
+func rangeTip(r string) string {
+	i := strings.LastIndex(r, "..")
+	if i < 0 {
+		return r
+	}
+	if tip := r[i+2:]; tip != "" {
+		return tip
+	}
+	return "HEAD"
+}
+
+func readReport(path string) (model.Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return model.Report{}, err
+	}
+	var report model.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return model.Report{}, fmt.Errorf("%s: not a roastgit JSON report: %w", path, err)
+	}
+	return report, nil
+}
+
+func writeComparison(cmp model.Comparison, jsonOut, noColor bool) {
+	if jsonOut {
+		out, err := render.CompareJSON(cmp)
+		if err != nil {
+			exitWith(exitGitError, err.Error(), false)
+		}
+		fmt.Fprintln(os.Stdout, out)
+		return
+	}
+	fmt.Fprintln(os.Stdout, render.CompareText(cmp, render.TextConfig{NoColor: noColor}))
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/config"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/render"
+	"roastgit/internal/roast"
+)
+
+// hookMarker identifies hook scripts written by roastgit so reinstalling can
+// overwrite them without --force.
+const hookMarker = "# roastgit-managed hook"
+
+// hookCommands maps each installable git hook to the roastgit arguments it
+// runs; "$@" forwards the hook's own arguments.
+var hookCommands = map[string]string{
+	"commit-msg": `check-msg "$@"`,
+	"pre-push":   `pre-push "$@"`,
+}
+
+// runCheckMsg roasts a pending commit message, as the commit-msg hook does.
+func runCheckMsg(args []string) int {
+	fs := flag.NewFlagSet("roastgit check-msg", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("path", "", "path to repo (default: auto-detect from cwd)")
+	configFile := fs.String("config", "", "config file")
+	intensity := fs.Int("intensity", 3, "roast intensity 0-5")
+	wholesome := fs.Bool("wholesome", false, "wholesome mode")
+	censor := fs.Bool("censor", false, "censor profanity")
+	minScore := fs.Int("min-score", 0, "reject messages scoring below this (0-100)")
+	jsonOut := fs.Bool("json", false, "output JSON")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usageText())
+			return exitOK
+		}
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if fs.NArg() != 1 {
+		exitWith(exitUsage, "usage: roastgit check-msg [flags] <file>", true)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	raw, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		exitWith(exitUsage, err.Error(), false)
+	}
+	// Outside a repository the message is still checked, just without
+	// config or the staged diff size.
+	ctx := context.Background()
+	haveGit := git.EnsureGit() == nil
+	repoPath := ""
+	if haveGit {
+		if p, err := resolveRepo(*path); err == nil {
+			repoPath = p
+		}
+	}
+	subject, body := analyze.SplitMessage(string(raw), messageCleanup(ctx, repoPath, haveGit))
+	if subject == "" {
+		// git aborts empty messages on its own.
+		return exitOK
+	}
+
+	fileCfg := config.File{}
+	var size *model.CommitSize
+	if repoPath != "" {
+		fileCfg, err = config.LoadRepo(repoPath, *configFile)
+		if err != nil {
+			exitWith(exitUsage, err.Error(), false)
+		}
+		if staged, err := git.StagedSize(ctx, repoPath); err == nil {
+			size = &staged
+		}
+	}
+	if !set["intensity"] && fileCfg.Hooks.Intensity != nil {
+		*intensity = *fileCfg.Hooks.Intensity
+	}
+	if !set["min-score"] {
+		*minScore = fileCfg.Hooks.MinMessageScore
+	}
+	*wholesome = *wholesome || fileCfg.Hooks.Wholesome
+	if *intensity < 0 || *intensity > 5 {
+		exitWith(exitUsage, "--intensity must be between 0 and 5", true)
+	}
+	if *minScore < 0 || *minScore > 100 {
+		exitWith(exitUsage, "--min-score must be between 0 and 100", true)
+	}
+
+	check := analyze.CheckMessage(subject, body, size)
+	line, tips := roast.CommitRoast(check.Reasons, *intensity, *wholesome, *censor, subject)
+	report := model.MessageReport{
+		Subject:  subject,
+		Score:    check.Info.Score,
+		Reasons:  check.Reasons,
+		Exempted: check.Exempted,
+		Roast:    line,
+		Tips:     tips,
+		MinScore: *minScore,
+		Rejected: *minScore > 0 && check.Info.Score < *minScore,
+	}
+	if *jsonOut {
+		out, err := render.MessageJSON(report)
+		if err != nil {
+			exitWith(exitGitError, err.Error(), false)
+		}
+		fmt.Fprintln(os.Stdout, out)
+	} else {
+		fmt.Fprintln(os.Stdout, render.MessageText(report, render.TextConfig{NoColor: *noColor}))
+	}
+	if report.Rejected {
+		fmt.Fprintf(os.Stderr, "commit rejected: message score %d is below %d\n", report.Score, report.MinScore)
+		return exitGateFailed
+	}
+	return exitOK
+}
+
+// messageCleanup reads how git cleans up the message being checked, from
+// the repository's config or, outside one, the global config. Without git
+// it assumes the defaults.
+func messageCleanup(ctx context.Context, repoPath string, haveGit bool) analyze.MessageCleanup {
+	if !haveGit {
+		return analyze.MessageCleanup{}
+	}
+	if repoPath == "" {
+		repoPath = "."
+	}
+	// Config git cannot read leaves the defaults.
+	char, _ := git.ConfigValue(ctx, repoPath, "core.commentChar")
+	mode, _ := git.ConfigValue(ctx, repoPath, "commit.cleanup")
+	return analyze.MessageCleanup{CommentChar: char, Mode: mode}
+}
+
+// runPrePush roasts the commits a push would send, reading the ref updates
+// git writes to the pre-push hook's stdin. It exits 5 when the configured
+// gate matches an outgoing commit.
+func runPrePush(args []string) int {
+	fs := flag.NewFlagSet("roastgit pre-push", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("path", "", "path to repo (default: auto-detect from cwd)")
+	configFile := fs.String("config", "", "config file")
+	failOn := fs.String("fail-on", "", "block the push if outgoing commits match these checks")
+	intensity := fs.Int("intensity", 3, "roast intensity 0-5")
+	wholesome := fs.Bool("wholesome", false, "wholesome mode")
+	censor := fs.Bool("censor", false, "censor profanity")
+	jsonOut := fs.Bool("json", false, "output JSON")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usageText())
+			return exitOK
+		}
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if fs.NArg() > 2 {
+		exitWith(exitUsage, "usage: roastgit pre-push [flags] [<remote> [<url>]]", true)
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if err := git.EnsureGit(); err != nil {
+		exitWith(exitGitError, "git executable not found in PATH", false)
+	}
+	repoPath, err := resolveRepo(*path)
+	if err != nil {
+		if errors.Is(err, git.ErrNotRepo) {
+			exitWith(exitNotRepo, "not a git repository", false)
+		}
+		exitWith(exitGitError, err.Error(), false)
+	}
+	cfg := model.Config{
+		ConfigFile: *configFile,
+		Intensity:  *intensity,
+		Wholesome:  *wholesome,
+		Censor:     *censor,
+		JSON:       *jsonOut,
+		NoColor:    *noColor,
+		JSONCompat: model.SchemaVersion,
+		TZ:         "local",
+		Backend:    git.BackendExec,
+		Deep:       true,
+		Top:        5,
+	}
+	fileCfg, err := config.LoadRepo(repoPath, cfg.ConfigFile)
+	if err != nil {
+		exitWith(exitUsage, err.Error(), false)
+	}
+	if !set["intensity"] && fileCfg.Hooks.Intensity != nil {
+		cfg.Intensity = *fileCfg.Hooks.Intensity
+	}
+	cfg.Wholesome = cfg.Wholesome || fileCfg.Hooks.Wholesome
+	cfg.FailOn = fileCfg.Gates.PrePush
+	if set["fail-on"] {
+		cfg.FailOn = splitList(*failOn)
+	}
+	if err := validateConfig(cfg); err != nil {
+		exitWith(exitUsage, err.Error(), true)
+	}
+
+	updates, err := git.ParsePushUpdates(os.Stdin)
+	if err != nil {
+		exitWith(exitUsage, err.Error(), false)
+	}
+	ctx := context.Background()
+	cfg.Revisions = git.PushRevisions(updates, fs.Arg(0), func(sha string) bool {
+		return git.CommitExists(ctx, repoPath, sha)
+	})
+	if len(cfg.Revisions) == 0 {
+		// Only deletions, or nothing to push.
+		return exitOK
+	}
+	report, err := buildReport(ctx, repoPath, cfg)
+	if err != nil {
+		handleGitError(err)
+	}
+	if report.Repo.CommitCount == 0 {
+		return exitOK
+	}
+	if cfg.JSON {
+		out, err := render.JSON(report)
+		if err != nil {
+			exitWith(exitGitError, err.Error(), false)
+		}
+		fmt.Fprintln(os.Stdout, out)
+	} else {
+		fmt.Fprintln(os.Stdout, render.Text(report, render.TextConfig{NoColor: cfg.NoColor}))
+	}
+	if report.Gate != nil && report.Gate.Failed {
+		fmt.Fprintf(os.Stderr, "push rejected: %d outgoing offenders match %s\n", report.Gate.Violations, strings.Join(report.Gate.FailOn, ", "))
+		return exitGateFailed
+	}
+	return exitOK
+}
+
+// runHook handles "roastgit hook install".
+func runHook(args []string) int {
+	if len(args) == 0 || args[0] != "install" {
+		exitWith(exitUsage, "usage: roastgit hook install [--hooks-path dir] [--force]", true)
+	}
+	fs := flag.NewFlagSet("roastgit hook install", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("path", "", "path to repo (default: auto-detect from cwd)")
+	hooksPath := fs.String("hooks-path", "", "install into this directory and set core.hooksPath")
+	force := fs.Bool("force", false, "overwrite hooks not written by roastgit")
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usageText())
+			return exitOK
+		}
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if err := git.EnsureGit(); err != nil {
+		exitWith(exitGitError, "git executable not found in PATH", false)
+	}
+	repoPath, err := resolveRepo(*path)
+	if err != nil {
+		if errors.Is(err, git.ErrNotRepo) {
+			exitWith(exitNotRepo, "not a git repository", false)
+		}
+		exitWith(exitGitError, err.Error(), false)
+	}
+	ctx := context.Background()
+
+	dir := *hooksPath
+	if dir == "" {
+		dir, err = git.HooksDir(ctx, repoPath)
+		if err != nil {
+			handleGitError(err)
+		}
+	} else if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repoPath, dir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		exitWith(exitGitError, err.Error(), false)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "roastgit"
+	}
+	names := make([]string, 0, len(hookCommands))
+	for name := range hookCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		target := filepath.Join(dir, name)
+		if existing, err := os.ReadFile(target); err == nil && !strings.Contains(string(existing), hookMarker) && !*force {
+			exitWith(exitUsage, fmt.Sprintf("%s already exists and was not written by roastgit (use --force to overwrite)", target), false)
+		}
+		script := fmt.Sprintf("#!/bin/sh\n%s. Reinstall with: roastgit hook install\nexec %s %s\n", hookMarker, shellQuote(exe), hookCommands[name])
+		if err := os.WriteFile(target, []byte(script), 0o755); err != nil {
+			exitWith(exitGitError, err.Error(), false)
+		}
+		fmt.Fprintf(os.Stdout, "Installed %s hook in %s\n", name, dir)
+	}
+	if *hooksPath != "" {
+		if err := git.SetConfig(ctx, repoPath, "core.hooksPath", *hooksPath); err != nil {
+			handleGitError(err)
+		}
+		fmt.Fprintf(os.Stdout, "Set core.hooksPath to %s\n", *hooksPath)
+	}
+	return exitOK
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -7,16 +7,17 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"roastgit/internal/analyze"
+	"roastgit/internal/baseline"
 	"roastgit/internal/git"
 	"roastgit/internal/model"
 	"roastgit/internal/render"
-	"roastgit/internal/roast"
-	"roastgit/internal/util"
+	"roastgit/pkg/roastgit"
 )
 
 const (
@@ -24,13 +25,124 @@ const (
 	exitUsage    = 2
 	exitNotRepo  = 3
 	exitGitError = 4
+	// exitGateFailed reports that a --fail-on gate found new violations.
+	exitGateFailed = 5
+	// Git failures with a known cause; see handleGitError.
+	exitBadRevision = 6
+	exitShallow     = 7
+	exitUnbornHead  = 8
+	exitUnsafeRepo  = 9
+	// exitTimeout and exitInterrupted follow timeout(1) and the shell's
+	// 128+SIGINT; a partial report is printed first when there is one.
+	exitTimeout     = 124
+	exitInterrupted = 130
 )
 
+// subcommands maps a leading positional argument to its handler. Each handler
+// returns the process exit code.
+var subcommands = map[string]func(args []string) int{
+	"schema":    runSchema,
+	"compare":   runCompare,
+	"baseline":  runBaseline,
+	"check-msg": runCheckMsg,
+	"hook":      runHook,
+	"pre-push":  runPrePush,
+	"show":      runShow,
+	"cache":     runCache,
+	"serve":     runServe,
+	"tui":       runTUI,
+	"watch":     runWatch,
+	"rules":     runRules,
+}
+
 func main() {
-	cfg, err := parseFlags(os.Args[1:])
+	args := os.Args[1:]
+	if len(args) > 0 {
+		if cmd, ok := subcommands[args[0]]; ok {
+			os.Exit(cmd(args[1:]))
+		}
+	}
+
+	cfg, repoPath, ok := setup(args)
+	if !ok {
+		return
+	}
+
+	ctx, stop := rootContext(cfg)
+	defer stop()
+	if isMulti(cfg) {
+		os.Exit(runMulti(ctx, cfg))
+	}
+	if len(cfg.CompareRange) == 2 {
+		os.Exit(runCompareRange(ctx, repoPath, cfg))
+	}
+
+	report, err := buildReport(ctx, repoPath, cfg)
+	if err != nil {
+		handleRunError(ctx, err)
+	}
+
+	renderer := roastgit.TextRenderer(roastgit.TextOptions{NoColor: cfg.NoColor, Explain: cfg.Explain})
+	if cfg.JSON {
+		renderer = roastgit.JSONRenderer(cfg.JSONCompat)
+	}
+	if err := renderer.Render(os.Stdout, report); err != nil {
+		exitWith(exitGitError, err.Error(), false)
+	}
+	if report.Partial != nil {
+		os.Exit(partialExitCode(report.Partial))
+	}
+	if refuseGate(report, cfg) {
+		exitWith(exitShallow, "--fail-on not evaluated: a shallow clone cut off the analyzed history.\n"+
+			"Fetch the full history with:\n  git fetch --unshallow", false)
+	}
+	if report.Gate != nil && report.Gate.Failed {
+		os.Exit(exitGateFailed)
+	}
+}
+
+// rootContext returns the context of a run: cancelled by SIGINT or SIGTERM,
+// and by the deadline when --timeout is set, with short git commands bounded
+// by --git-timeout.
+func rootContext(cfg model.Config) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	ctx = git.WithCommandTimeout(ctx, cfg.GitTimeout)
+	if cfg.Timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+func partialExitCode(p *model.PartialInfo) int {
+	if p.Reason == "timeout" {
+		return exitTimeout
+	}
+	return exitInterrupted
+}
+
+// handleRunError exits for a failed run, telling an interrupt or --timeout
+// that stopped it before anything was read apart from a git failure.
+func handleRunError(ctx context.Context, err error) {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		exitWith(exitTimeout, "timed out before any commits were analyzed", false)
+	case ctx.Err() != nil:
+		exitWith(exitInterrupted, "interrupted before any commits were analyzed", false)
+	}
+	handleGitError(err)
+}
+
+// setup parses and validates flags and locates the repository. It exits on
+// errors and returns ok=false when only help was requested.
+func setup(args []string) (model.Config, string, bool) {
+	cfg, err := parseFlags(args)
 	if err != nil {
 		if errors.Is(err, flag.ErrHelp) {
-			return
+			return cfg, "", false
 		}
 		exitWith(exitUsage, err.Error(), true)
 	}
@@ -39,10 +151,16 @@ func main() {
 		exitWith(exitUsage, err.Error(), true)
 	}
 
-	if err := git.EnsureGit(); err != nil {
-		exitWith(exitGitError, "git executable not found in PATH", false)
+	if cfg.Backend == git.BackendExec {
+		if err := git.EnsureGit(); err != nil {
+			exitWith(exitGitError, "git executable not found in PATH (try --backend native)", false)
+		}
 	}
 
+	if isMulti(cfg) {
+		// Each repository is resolved by runMulti.
+		return cfg, "", true
+	}
 	repoPath, err := resolveRepo(cfg.Path)
 	if err != nil {
 		if errors.Is(err, git.ErrNotRepo) {
@@ -50,77 +168,65 @@ func main() {
 		}
 		exitWith(exitGitError, err.Error(), false)
 	}
+	return cfg, repoPath, true
+}
 
-	ctx := context.Background()
-	commits, head, repoName, err := loadRepo(ctx, repoPath, cfg)
-	if err != nil {
-		handleGitError(err)
-	}
-
-	branches := []string{}
-	if bs, err := git.Branches(ctx, repoPath); err == nil {
-		branches = bs
-	}
+// buildReport runs the full analysis pipeline for one repository.
+func buildReport(ctx context.Context, repoPath string, cfg model.Config) (model.Report, error) {
+	return roastgit.Analyze(ctx, analyzeOptions(repoPath, cfg))
+}
 
-	var spinner *util.Spinner
-	if !cfg.JSON && len(commits) > 2000 {
-		spinner = util.NewSpinner(os.Stderr, "Analyzing commits")
-		spinner.Start()
-	}
-	sizes, sampled, err := loadSizes(ctx, repoPath, commits, cfg)
-	if spinner != nil {
-		spinner.Stop("Analysis complete")
+// analyzeOptions maps the flags of a run onto the library options.
+func analyzeOptions(repoPath string, cfg model.Config) roastgit.Options {
+	opts := roastgit.Options{
+		Path:              repoPath,
+		Backend:           cfg.Backend,
+		ConfigFile:        cfg.ConfigFile,
+		Since:             cfg.Since,
+		Until:             cfg.Until,
+		Author:            cfg.Author,
+		Range:             cfg.Range,
+		Revisions:         cfg.Revisions,
+		MaxCommits:        cfg.MaxCommits,
+		ExcludeBots:       cfg.ExcludeBots,
+		Deep:              cfg.Deep,
+		TZ:                cfg.TZ,
+		Intensity:         cfg.Intensity,
+		Wholesome:         cfg.Wholesome,
+		Censor:            cfg.Censor,
+		Top:               cfg.Top,
+		Trend:             cfg.Trend,
+		Baseline:          cfg.Baseline,
+		NoBaseline:        cfg.NoBaseline,
+		FailOn:            cfg.FailOn,
+		RefuseShallow:     cfg.RefuseShallow,
+		Jobs:              cfg.Jobs,
+		NoCache:           cfg.NoCache,
+		Incremental:       cfg.Incremental,
+		RecurseSubmodules: cfg.RecurseSubmodules,
 	}
-	if err != nil {
-		handleGitError(err)
-	}
-
-	metrics, offenders := analyze.Analyze(commits, sizes, branches, analyze.AnalyzeConfig{TZ: cfg.TZ})
-	metrics.Size.Sampled = sampled
-
-	score := analyze.Score(metrics)
-	seed := head + fmt.Sprintf("-%d-%t", len(commits), cfg.Wholesome)
-	roasts := roast.GenerateRoasts(metrics, score, cfg.Intensity, cfg.Wholesome, cfg.Censor, seed)
-
-	report := model.Report{
-		Repo: model.RepoInfo{
-			Path:        repoPath,
-			Name:        repoName,
-			Head:        head,
-			CommitCount: len(commits),
-		},
-		Filters: model.Filters{
-			Since:      cfg.Since,
-			Until:      cfg.Until,
-			Author:     cfg.Author,
-			MaxCommits: cfg.MaxCommits,
-			TZ:         cfg.TZ,
-			Deep:       cfg.Deep,
-		},
-		Score:     score,
-		Metrics:   metrics,
-		Offenders: offenders,
-		Roasts:    roasts,
-	}
-
-	if cfg.JSON {
-		out, err := render.JSON(report)
-		if err != nil {
-			exitWith(exitGitError, err.Error(), false)
-		}
-		fmt.Fprintln(os.Stdout, out)
-		return
+	if !cfg.JSON && !cfg.Quiet {
+		opts.Progress = os.Stderr
 	}
+	return opts
+}
 
-	output := render.Text(report, render.TextConfig{NoColor: cfg.NoColor, Explain: cfg.Explain})
-	fmt.Fprintln(os.Stdout, output)
+// refuseGate reports whether --refuse-shallow keeps the gate from judging a
+// history that a shallow clone cut off.
+func refuseGate(report model.Report, cfg model.Config) bool {
+	return cfg.RefuseShallow && report.Shallow != nil && len(cfg.FailOn) > 0
 }
 
 func parseFlags(args []string) (model.Config, error) {
 	cfg := model.Config{}
 	fs := flag.NewFlagSet("roastgit", flag.ContinueOnError)
 	fs.SetOutput(io.Discard)
-	fs.StringVar(&cfg.Path, "path", "", "path to repo (default: auto-detect from cwd)")
+	paths := stringList{}
+	fs.Var(&paths, "path", "path to repo (default: auto-detect from cwd); repeat for a combined report")
+	fs.StringVar(&cfg.ReposFile, "repos-file", "", "file listing repository paths, one per line")
+	fs.StringVar(&cfg.Scan, "scan", "", "analyze every repository found under this directory")
+	fs.BoolVar(&cfg.RecurseSubmodules, "recurse-submodules", false, "nest a report of each checked-out submodule")
+	fs.StringVar(&cfg.ConfigFile, "config", "", "config file (default: .roastgit.json in the repo root)")
 	fs.StringVar(&cfg.Since, "since", "", "since date YYYY-MM-DD")
 	fs.StringVar(&cfg.Until, "until", "", "until date YYYY-MM-DD")
 	fs.StringVar(&cfg.Author, "author", "", "author filter")
@@ -133,15 +239,59 @@ func parseFlags(args []string) (model.Config, error) {
 	fs.IntVar(&cfg.MaxCommits, "max-commits", 0, "limit commits analyzed")
 	fs.StringVar(&cfg.TZ, "tz", "local", "time zone: local or commit")
 	fs.BoolVar(&cfg.Explain, "explain", false, "include scoring explanation")
+	fs.StringVar(&cfg.Range, "range", "", "revision range to analyze, e.g. v1.0..HEAD")
+	compareRange := fs.String("compare-range", "", "compare two revision ranges: --compare-range A B")
+	fs.StringVar(&cfg.Trend, "trend", "", "score trend per week, month or quarter")
+	fs.BoolVar(&cfg.ExcludeBots, "exclude-bots", false, "leave bot commits out of the analysis")
+	fs.IntVar(&cfg.Top, "top", 5, "number of offenders listed (0 = all)")
+	fs.StringVar(&cfg.Baseline, "baseline", "", "baseline file (default: .roastgit-baseline.json in the repo root)")
+	fs.BoolVar(&cfg.NoBaseline, "no-baseline", false, "ignore the baseline file")
+	failOn := fs.String("fail-on", "", "exit 5 if new offenders match these checks (comma-separated, or 'any')")
+	fs.IntVar(&cfg.JSONCompat, "json-compat", 0, "emit JSON in an older schema version")
+	fs.StringVar(&cfg.Backend, "backend", git.BackendExec, "git backend: exec or native")
+	fs.IntVar(&cfg.Jobs, "jobs", 0, "concurrent numstat workers (0 = number of CPUs)")
+	fs.BoolVar(&cfg.NoCache, "no-cache", false, "neither read nor write the commit cache")
+	fs.BoolVar(&cfg.Incremental, "incremental", false, "only read commits added since the last incremental run")
+	fs.BoolVar(&cfg.RefuseShallow, "refuse-shallow", false, "with --fail-on, exit 7 instead of gating a history cut off by a shallow clone")
+	fs.DurationVar(&cfg.Timeout, "timeout", 0, "stop and print a partial report after this long (0 = no limit)")
+	fs.DurationVar(&cfg.GitTimeout, "git-timeout", git.DefaultCommandTimeout, "limit of each short git command such as rev-parse (0 = no limit)")
 	fs.Usage = func() {
 		fmt.Fprintln(os.Stderr, usageText())
 	}
-	if err := fs.Parse(args); err != nil {
-		if errors.Is(err, flag.ErrHelp) {
-			fs.Usage()
-			return cfg, flag.ErrHelp
+	var secondRange string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				fs.Usage()
+				return cfg, flag.ErrHelp
+			}
+			return cfg, fmt.Errorf("%w", err)
+		}
+		// Parsing stops at the second --compare-range range; flags may
+		// follow it.
+		if *compareRange == "" || secondRange != "" || fs.NArg() == 0 {
+			break
+		}
+		secondRange, args = fs.Arg(0), fs.Args()[1:]
+	}
+	cfg.FailOn = splitList(*failOn)
+	cfg.Paths = paths
+	if len(paths) > 0 {
+		cfg.Path = paths[0]
+	}
+	if *compareRange != "" {
+		if secondRange == "" {
+			return cfg, fmt.Errorf("--compare-range takes two ranges: --compare-range A B")
 		}
-		return cfg, fmt.Errorf("%w", err)
+		cfg.CompareRange = []string{*compareRange, secondRange}
+	}
+	if fs.NArg() > 0 {
+		return cfg, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if cfg.JSONCompat != 0 {
+		cfg.JSON = true
+	} else {
+		cfg.JSONCompat = model.SchemaVersion
 	}
 	return cfg, nil
 }
@@ -150,9 +300,56 @@ func validateConfig(cfg model.Config) error {
 	if cfg.Intensity < 0 || cfg.Intensity > 5 {
 		return fmt.Errorf("--intensity must be between 0 and 5")
 	}
+	if cfg.JSONCompat < 1 || cfg.JSONCompat > model.SchemaVersion {
+		return fmt.Errorf("--json-compat must be between 1 and %d", model.SchemaVersion)
+	}
 	if cfg.TZ != "local" && cfg.TZ != "commit" {
 		return fmt.Errorf("--tz must be 'local' or 'commit'")
 	}
+	for _, r := range append([]string{cfg.Range}, cfg.CompareRange...) {
+		if strings.HasPrefix(r, "-") {
+			return fmt.Errorf("invalid revision range %q", r)
+		}
+	}
+	switch cfg.Backend {
+	case git.BackendExec, git.BackendNative:
+	default:
+		return fmt.Errorf("--backend must be 'exec' or 'native'")
+	}
+	if cfg.Top < 0 {
+		return fmt.Errorf("--top must be 0 or more")
+	}
+	if cfg.Jobs < 0 {
+		return fmt.Errorf("--jobs must be 0 or more")
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("--timeout must be 0 or more")
+	}
+	if cfg.GitTimeout < 0 {
+		return fmt.Errorf("--git-timeout must be 0 or more")
+	}
+	if isMulti(cfg) {
+		switch {
+		case len(cfg.CompareRange) > 0:
+			return fmt.Errorf("--compare-range analyzes a single repository")
+		case cfg.Baseline != "":
+			return fmt.Errorf("--baseline names one file and cannot be used with several repositories")
+		case cfg.JSONCompat != model.SchemaVersion:
+			return fmt.Errorf("--json-compat is not supported with several repositories")
+		}
+	}
+	if cfg.Incremental && (cfg.Since != "" || cfg.Until != "" || cfg.Author != "" || cfg.MaxCommits != 0 ||
+		cfg.Range != "" || len(cfg.CompareRange) > 0 || len(cfg.Revisions) > 0) {
+		return fmt.Errorf("--incremental analyzes the whole history of HEAD and cannot be combined with --since, --until, --author, --max-commits, --range or --compare-range")
+	}
+	if cfg.Incremental && cfg.Trend != "" {
+		return fmt.Errorf("--incremental keeps totals, not the commits --trend is built from")
+	}
+	switch cfg.Trend {
+	case "", analyze.PeriodWeek, analyze.PeriodMonth, analyze.PeriodQuarter:
+	default:
+		return fmt.Errorf("--trend must be 'week', 'month' or 'quarter'")
+	}
 	if cfg.Since != "" {
 		if _, err := time.Parse("2006-01-02", cfg.Since); err != nil {
 			return fmt.Errorf("--since must be YYYY-MM-DD")
@@ -166,6 +363,48 @@ func validateConfig(cfg model.Config) error {
 	return nil
 }
 
+func runSchema(args []string) int {
+	if len(args) > 0 {
+		exitWith(exitUsage, "schema takes no arguments", true)
+	}
+	fmt.Fprintln(os.Stdout, render.Schema())
+	return exitOK
+}
+
+// runBaseline handles "roastgit baseline write", which records the current
+// offenders so later runs and gates only report new ones.
+func runBaseline(args []string) int {
+	if len(args) == 0 || args[0] != "write" {
+		exitWith(exitUsage, "usage: roastgit baseline write [flags]", true)
+	}
+	cfg, repoPath, ok := setup(args[1:])
+	if !ok {
+		return exitOK
+	}
+	if isMulti(cfg) {
+		exitWith(exitUsage, "baseline write takes a single repository", true)
+	}
+	cfg.NoBaseline = true
+	cfg.FailOn = nil
+	cfg.Top = 0
+	ctx, stop := rootContext(cfg)
+	defer stop()
+	report, err := buildReport(ctx, repoPath, cfg)
+	if err != nil {
+		handleRunError(ctx, err)
+	}
+	if report.Partial != nil {
+		// A baseline of a partial history would hide nothing it missed.
+		exitWith(partialExitCode(report.Partial), "baseline not written: the run was cut short", false)
+	}
+	path := baseline.Path(repoPath, cfg.Baseline)
+	if err := baseline.Write(path, baseline.New(report.Repo.Head, report.Offenders, time.Now())); err != nil {
+		exitWith(exitGitError, err.Error(), false)
+	}
+	fmt.Fprintf(os.Stdout, "Recorded %d offenders in %s\n", len(report.Offenders), path)
+	return exitOK
+}
+
 func resolveRepo(path string) (string, error) {
 	if path == "" {
 		cwd, err := os.Getwd()
@@ -177,60 +416,53 @@ func resolveRepo(path string) (string, error) {
 	return git.FindRepoRoot(path)
 }
 
-func loadRepo(ctx context.Context, repoPath string, cfg model.Config) ([]model.Commit, string, string, error) {
-	if !git.IsGitRepo(ctx, repoPath) {
-		return nil, "", "", git.ErrNotRepo
+// handleGitError exits for a failed git operation, with a specific code and
+// a hint for the failures git's stderr identifies.
+func handleGitError(err error) {
+	if errors.As(err, new(roastgit.ConfigError)) {
+		exitWith(exitUsage, err.Error(), false)
 	}
-	head, err := git.HeadSHA(ctx, repoPath)
-	if err != nil {
-		return nil, "", "", err
-	}
-	repoName := filepath.Base(repoPath)
-	commits, err := git.LogCommits(ctx, repoPath, git.LogOptions{
-		Since:      cfg.Since,
-		Until:      cfg.Until,
-		Author:     cfg.Author,
-		MaxCommits: cfg.MaxCommits,
-	})
-	if err != nil {
-		return nil, "", "", err
+	var gitErr *git.GitError
+	dir := "<repo>"
+	if errors.As(err, &gitErr) {
+		dir = gitErr.Dir
+	}
+	switch {
+	case errors.Is(err, git.ErrNotRepo):
+		exitWith(exitNotRepo, "not a git repository", false)
+	case errors.Is(err, git.ErrUnsafeRepo):
+		exitWith(exitUnsafeRepo, fmt.Sprintf("git refuses to read %s because another user owns it.\n"+
+			"If you trust this repository, allow it with:\n  git config --global --add safe.directory %s", dir, dir), false)
+	case errors.Is(err, git.ErrUnbornHead):
+		exitWith(exitUnbornHead, "HEAD has no commits yet; make a first commit, then run roastgit again", false)
+	case errors.Is(err, git.ErrShallow):
+		exitWith(exitShallow, git.Summary(err)+"\nThis is a shallow clone and the commits needed were not fetched.\n"+
+			"Fetch the full history with:\n  git fetch --unshallow", false)
+	case errors.Is(err, git.ErrBadRevision):
+		exitWith(exitBadRevision, git.Summary(err), false)
 	}
-	return commits, head, repoName, nil
+	exitWith(exitGitError, err.Error(), false)
 }
 
-func loadSizes(ctx context.Context, repoPath string, commits []model.Commit, cfg model.Config) (map[string]model.CommitSize, bool, error) {
-	if len(commits) == 0 {
-		return map[string]model.CommitSize{}, false, nil
-	}
-	if cfg.Deep {
-		sizes, err := git.NumstatForLog(ctx, repoPath, git.LogOptions{
-			Since:      cfg.Since,
-			Until:      cfg.Until,
-			Author:     cfg.Author,
-			MaxCommits: cfg.MaxCommits,
-		})
-		return sizes, false, err
-	}
-	count := len(commits)
-	sampleSize := count
-	if count > 500 {
-		sampleSize = 500
-	}
-	indices := util.SampleIndices(count, sampleSize)
-	shas := make([]string, 0, len(indices))
-	for _, idx := range indices {
-		shas = append(shas, commits[idx].SHA)
-	}
-	sizes, err := git.NumstatForCommits(ctx, repoPath, shas)
-	return sizes, sampleSize < count, err
+// stringList is a flag that may be given several times.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ", ") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
 }
 
-func handleGitError(err error) {
-	msg := err.Error()
-	if strings.Contains(strings.ToLower(msg), "not a git repository") {
-		exitWith(exitNotRepo, "not a git repository", false)
+func splitList(s string) []string {
+	out := []string{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
 	}
-	exitWith(exitGitError, msg, false)
+	return out
 }
 
 func exitWith(code int, message string, showUsage bool) {
@@ -245,9 +477,39 @@ func exitWith(code int, message string, showUsage bool) {
 
 func usageText() string {
 	return `Usage: roastgit [flags]
+       roastgit show [flags] [<rev>]
+       roastgit baseline write [flags]
+       roastgit check-msg [--min-score n] [--intensity n] <file>
+       roastgit pre-push [--fail-on list] [<remote> [<url>]]
+       roastgit hook install [--hooks-path dir] [--force]
+       roastgit cache stats|clear [--path dir] [--json]
+       roastgit serve [--addr host:port] [--path dir]...
+       roastgit tui [flags]
+       roastgit watch [--interval d] [--path dir] [flags]
+       roastgit rules [--path dir] [--config file] [--json]
+       roastgit compare [--json] [--no-color] <a.json> <b.json>
+       roastgit schema
+
+Commands:
+  show                 roast a single commit in depth (default HEAD)
+  baseline write       record current offenders in the baseline file
+  check-msg            roast a commit message file (used by the commit-msg hook)
+  pre-push             roast the commits being pushed (used by the pre-push hook)
+  hook install         install roastgit git hooks
+  cache stats|clear    show or delete the per-commit cache and incremental state
+  serve                serve a JSON API and dashboard for one or more repositories
+  tui                  browse the full report in a full-screen terminal UI
+  watch                roast each new commit as it lands, with the score change
+  rules                list the offender rules: built-in, config and scripts
+  compare              diff two JSON reports
+  schema               print the JSON Schema for --json output
 
 Flags:
-  --path string         path to repo (default: auto-detect from cwd)
+  --path string         path to repo (default: auto-detect from cwd); repeat for a combined report
+  --repos-file file    combined report of the repositories listed in file, one path per line
+  --scan dir           combined report of every repository found under dir
+  --recurse-submodules nest a report of each checked-out submodule over the same date window
+  --config string       config file (default: .roastgit.json in the repo root)
   --since YYYY-MM-DD
   --until YYYY-MM-DD
   --author string
@@ -260,6 +522,23 @@ Flags:
   --max-commits int    limit commits analyzed from newest backwards (default 0 = no limit)
   --tz string          "local" (default) or "commit"
   --explain            include scoring explanation in text output
+  --range string       revision range to analyze, e.g. v1.0..HEAD
+  --compare-range A B  compare two revision ranges of this repo
+  --trend string       score trend per "week", "month" or "quarter"
+  --exclude-bots       leave bot commits out of the analysis
+  --top int            number of offenders listed (default 5, 0 = all)
+  --baseline string    baseline file (default: .roastgit-baseline.json in the repo root)
+  --no-baseline        ignore the baseline file
+  --fail-on list       exit 5 if new offenders match these checks (comma-separated, or "any")
+  --json-compat int    emit JSON in an older schema version (implies --json)
+  --backend string     "exec" (default, runs git) or "native" (reads .git directly)
+  --jobs int           concurrent numstat workers (default 0 = number of CPUs)
+  --no-cache           neither read nor write the commit cache
+  --incremental        only read commits added since the last incremental run
+  --refuse-shallow     with --fail-on, exit 7 instead of gating a history cut off by a shallow clone
+  --timeout duration   stop after this long and print a partial report, e.g. 10m (default 0 = no limit)
+  --git-timeout duration
+                       limit of each short git command such as rev-parse (default 30s, 0 = no limit)
   -h, --help
 `
 }
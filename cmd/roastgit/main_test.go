@@ -0,0 +1,30 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFlagsCompareRange(t *testing.T) {
+	cfg, err := parseFlags([]string{"--compare-range", "v1.0..v2.0", "v2.0..HEAD", "--json", "--top", "0"})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.CompareRange, []string{"v1.0..v2.0", "v2.0..HEAD"}) || !cfg.JSON || cfg.Top != 0 {
+		t.Fatalf("unexpected config: ranges %v, json %v, top %d", cfg.CompareRange, cfg.JSON, cfg.Top)
+	}
+	if _, err := parseFlags([]string{"--compare-range", "v1.0..v2.0", "v2.0..HEAD", "extra"}); err == nil {
+		t.Fatalf("expected an error for a third range")
+	}
+	if _, err := parseFlags([]string{"--compare-range", "v1.0..v2.0", "--json"}); err == nil {
+		t.Fatalf("expected an error for a missing second range")
+	}
+}
+
+func TestRangeTip(t *testing.T) {
+	for r, want := range map[string]string{"v1.0..v2.0": "v2.0", "main...topic": "topic", "v1.0..": "HEAD", "v2.0": "v2.0"} {
+		if got := rangeTip(r); got != want {
+			t.Errorf("rangeTip(%q) = %q, want %q", r, got, want)
+		}
+	}
+}
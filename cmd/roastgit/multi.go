@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"roastgit/internal/aggregate"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/render"
+)
+
+// isMulti reports whether cfg selects several repositories.
+func isMulti(cfg model.Config) bool {
+	return len(cfg.Paths) > 1 || cfg.ReposFile != "" || cfg.Scan != ""
+}
+
+// runMulti analyzes every selected repository in parallel and prints one
+// combined report. Repositories that fail are listed rather than aborting
+// the run.
+func runMulti(ctx context.Context, cfg model.Config) int {
+	paths, err := repoPaths(cfg)
+	if err != nil {
+		exitWith(exitUsage, err.Error(), false)
+	}
+	if len(paths) == 0 {
+		exitWith(exitNotRepo, "no git repositories found", false)
+	}
+	reports, failures := analyzeRepos(ctx, paths, cfg)
+	multi := aggregate.Reports(reports, failures)
+
+	if cfg.JSON {
+		out, err := render.MultiJSON(multi)
+		if err != nil {
+			exitWith(exitGitError, err.Error(), false)
+		}
+		fmt.Fprintln(os.Stdout, out)
+	} else {
+		fmt.Fprintln(os.Stdout, render.MultiText(multi, render.TextConfig{NoColor: cfg.NoColor, Explain: cfg.Explain}))
+	}
+
+	code := exitOK
+	for _, r := range multi.Repos {
+		switch {
+		case r.Partial != nil:
+			return partialExitCode(r.Partial)
+		case refuseGate(r, cfg):
+			code = exitShallow
+		case code == exitOK && r.Gate != nil && r.Gate.Failed:
+			code = exitGateFailed
+		}
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return exitTimeout
+	case ctx.Err() != nil:
+		return exitInterrupted
+	}
+	if len(failures) > 0 {
+		return exitGitError
+	}
+	return code
+}
+
+// analyzeRepos builds the report of each repository, running as many at
+// once as --jobs allows and splitting the numstat workers between them.
+func analyzeRepos(ctx context.Context, paths []string, cfg model.Config) ([]model.Report, []model.RepoFailure) {
+	workers := git.Jobs(cfg.Jobs)
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+	repoCfg := cfg
+	repoCfg.Jobs = git.Jobs(cfg.Jobs) / workers
+	if repoCfg.Jobs < 1 {
+		repoCfg.Jobs = 1
+	}
+	repoCfg.Quiet = true
+
+	reports := make([]*model.Report, len(paths))
+	errs := make([]error, len(paths))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				report, err := buildReport(ctx, paths[i], repoCfg)
+				if err != nil {
+					errs[i] = err
+					continue
+				}
+				reports[i] = &report
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	out := []model.Report{}
+	failures := []model.RepoFailure{}
+	for i, path := range paths {
+		switch {
+		case reports[i] != nil:
+			out = append(out, *reports[i])
+		case errs[i] != nil && !errors.Is(errs[i], context.Canceled) && !errors.Is(errs[i], context.DeadlineExceeded):
+			// A repository that failed on its own is not labelled
+			// interrupted because a later one was.
+			failures = append(failures, model.RepoFailure{Path: path, Error: git.Reason(errs[i])})
+		default:
+			failures = append(failures, model.RepoFailure{Path: path, Error: "interrupted before any commits were analyzed"})
+		}
+	}
+	return out, failures
+}
+
+// repoPaths collects the repositories given with --path, --repos-file and
+// --scan, resolved to their roots without duplicates. A path that is not a
+// repository is kept, so the report lists it as failed.
+func repoPaths(cfg model.Config) ([]string, error) {
+	candidates := append([]string{}, cfg.Paths...)
+	if cfg.ReposFile != "" {
+		listed, err := readReposFile(cfg.ReposFile)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, listed...)
+	}
+	if cfg.Scan != "" {
+		found, err := scanRepos(cfg.Scan)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, found...)
+	}
+	seen := map[string]bool{}
+	paths := []string{}
+	for _, c := range candidates {
+		path, err := resolveRepo(c)
+		if err != nil {
+			if path, err = filepath.Abs(c); err != nil {
+				path = c
+			}
+		}
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// readReposFile reads one repository path per line. Blank lines and lines
+// starting with # are skipped; relative paths are relative to the file.
+func readReposFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	paths := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(path), line)
+		}
+		paths = append(paths, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return paths, nil
+}
+
+// scanRepos finds repositories under root: work trees with a .git entry and
+// bare repositories. It does not look inside a repository it found, and
+// skips hidden and unreadable directories.
+func scanRepos(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("--scan: %s is not a directory", root)
+	}
+	found := []string{}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) && path != root {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			return fs.SkipDir
+		}
+		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil || git.IsGitDir(path) {
+			found = append(found, path)
+			return fs.SkipDir
+		}
+		return nil
+	})
+	return found, err
+}
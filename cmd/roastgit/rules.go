@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/config"
+	"roastgit/internal/git"
+)
+
+// ruleInfo is one rule as "roastgit rules --json" lists it.
+type ruleInfo struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Weight      int    `json:"weight"`
+	// Source is "builtin", "registered", "config" or "script".
+	Source string `json:"source"`
+}
+
+// runRules lists the offender rules a report uses: the built-in ones, those
+// the repository's config file declares and its rule scripts.
+func runRules(args []string) int {
+	fs := flag.NewFlagSet("roastgit rules", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("path", "", "path to repo (default: auto-detect from cwd)")
+	configFile := fs.String("config", "", "config file")
+	jsonOut := fs.Bool("json", false, "output JSON")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usageText())
+			return exitOK
+		}
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if fs.NArg() > 0 {
+		exitWith(exitUsage, "usage: roastgit rules [--path dir] [--config file] [--json]", true)
+	}
+
+	// Outside a repository only the built-in rules and an explicit
+	// --config apply.
+	repoPath, err := resolveRepo(*path)
+	if err != nil && (*path != "" || !errors.Is(err, git.ErrNotRepo)) {
+		if errors.Is(err, git.ErrNotRepo) {
+			exitWith(exitNotRepo, "not a git repository", false)
+		}
+		exitWith(exitGitError, err.Error(), false)
+	}
+	var fileCfg config.File
+	if repoPath != "" || *configFile != "" {
+		if fileCfg, err = config.LoadRepo(repoPath, *configFile); err != nil {
+			exitWith(exitUsage, err.Error(), false)
+		}
+	}
+	custom, err := fileCfg.CustomRules()
+	if err != nil {
+		exitWith(exitUsage, err.Error(), false)
+	}
+
+	rules := analyze.Rules(custom...)
+	infos := make([]ruleInfo, len(rules))
+	for i, r := range rules {
+		source := "registered"
+		switch {
+		case i < len(analyze.Checks):
+			source = "builtin"
+		case i >= len(rules)-len(fileCfg.Scripts()):
+			source = "script"
+		case i >= len(rules)-len(custom):
+			source = "config"
+		}
+		infos[i] = ruleInfo{ID: r.ID(), Description: r.Description(), Category: r.Category(), Weight: r.Weight(), Source: source}
+	}
+	if *jsonOut {
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			exitWith(exitGitError, err.Error(), false)
+		}
+		fmt.Fprintln(os.Stdout, string(data))
+		return exitOK
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tCATEGORY\tWEIGHT\tSOURCE\tREASON")
+	for _, r := range infos {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", r.ID, r.Category, r.Weight, r.Source, r.Description)
+	}
+	tw.Flush()
+	return exitOK
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/server"
+)
+
+// runServe serves reports of one or more repositories over HTTP until
+// interrupted.
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("roastgit serve", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	var paths stringList
+	fs.Var(&paths, "path", "repository to serve (repeatable; default: auto-detect from cwd)")
+	reposFile := fs.String("repos-file", "", "serve the repositories listed in file")
+	scan := fs.String("scan", "", "serve every repository found under this directory")
+	configFile := fs.String("config", "", "config file")
+	intensity := fs.Int("intensity", 3, "roast intensity 0-5")
+	tz := fs.String("tz", "local", "time zone: local or commit")
+	backend := fs.String("backend", git.BackendExec, "git backend: exec or native")
+	jobs := fs.Int("jobs", 0, "concurrent numstat workers (0 = number of CPUs)")
+	noCache := fs.Bool("no-cache", false, "neither read nor write the commit cache")
+	timeout := fs.Duration("timeout", 0, "bound each analysis; the report is partial when it expires")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usageText())
+			return exitOK
+		}
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if fs.NArg() > 0 {
+		exitWith(exitUsage, "usage: roastgit serve [--addr host:port] [flags]", true)
+	}
+	cfg := model.Config{
+		Paths:      paths,
+		ReposFile:  *reposFile,
+		Scan:       *scan,
+		ConfigFile: *configFile,
+		Intensity:  *intensity,
+		TZ:         *tz,
+		Backend:    *backend,
+		Jobs:       *jobs,
+		NoCache:    *noCache,
+		Top:        5,
+		JSONCompat: model.SchemaVersion,
+		Timeout:    *timeout,
+	}
+	if len(paths) > 0 {
+		cfg.Path = paths[0]
+	}
+	if err := validateConfig(cfg); err != nil {
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if cfg.Backend == git.BackendExec {
+		if err := git.EnsureGit(); err != nil {
+			exitWith(exitGitError, "git executable not found in PATH (try --backend native)", false)
+		}
+	}
+
+	repos, err := serveRepos(cfg)
+	if err != nil {
+		exitWith(exitUsage, err.Error(), false)
+	}
+	base := cfg
+	base.Paths, base.ReposFile, base.Scan = nil, "", ""
+	srv := server.New(server.Options{
+		Repos:   repos,
+		Base:    base,
+		Build:   buildReport,
+		Head:    serveHead(cfg.Backend),
+		Timeout: cfg.Timeout,
+		Validate: func(q model.Config) error {
+			if q.MaxCommits < 0 {
+				return fmt.Errorf("max_commits must be 0 or more")
+			}
+			return validateConfig(q)
+		},
+	})
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		exitWith(exitUsage, err.Error(), false)
+	}
+	httpSrv := &http.Server{Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		httpSrv.Shutdown(shutdown)
+	}()
+	fmt.Fprintf(os.Stderr, "roastgit: serving %d repositories on http://%s\n", len(repos), ln.Addr())
+	if err := httpSrv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		exitWith(exitGitError, err.Error(), false)
+	}
+	return exitOK
+}
+
+// serveRepos resolves the repositories selected like a multi-repo run, or
+// the one at --path or the working directory.
+func serveRepos(cfg model.Config) ([]server.Repo, error) {
+	var paths []string
+	if isMulti(cfg) {
+		var err error
+		if paths, err = repoPaths(cfg); err != nil {
+			return nil, err
+		}
+	} else {
+		repoPath, err := resolveRepo(cfg.Path)
+		if err != nil {
+			if errors.Is(err, git.ErrNotRepo) {
+				exitWith(exitNotRepo, "not a git repository", false)
+			}
+			return nil, err
+		}
+		paths = []string{repoPath}
+	}
+	if len(paths) == 0 {
+		exitWith(exitNotRepo, "no git repositories found", false)
+	}
+	repos := make([]server.Repo, 0, len(paths))
+	for _, path := range paths {
+		repos = append(repos, server.Repo{Name: git.RepoName(path), Path: path})
+	}
+	return repos, nil
+}
+
+// serveHead reads HEAD through the selected backend, so cached answers
+// are dropped once a mirror fetches new commits.
+func serveHead(backend string) func(ctx context.Context, repo string) (string, error) {
+	return func(ctx context.Context, repo string) (string, error) {
+		b, err := git.Open(backend, repo)
+		if err != nil {
+			return "", err
+		}
+		defer b.Close()
+		return b.HeadSHA(ctx)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/cache"
+	"roastgit/internal/config"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/render"
+	"roastgit/internal/roast"
+	"roastgit/pkg/roastgit"
+)
+
+// showNeighbourhood is how far around a commit "roastgit show" looks for the
+// panic windows and fix chains it may belong to.
+const showNeighbourhood = 6 * time.Hour
+
+// runShow roasts a single commit in depth.
+func runShow(args []string) int {
+	fs := flag.NewFlagSet("roastgit show", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("path", "", "path to repo (default: auto-detect from cwd)")
+	configFile := fs.String("config", "", "config file")
+	intensity := fs.Int("intensity", 3, "roast intensity 0-5")
+	wholesome := fs.Bool("wholesome", false, "wholesome mode")
+	censor := fs.Bool("censor", false, "censor profanity")
+	tz := fs.String("tz", "local", "time zone: local or commit")
+	backend := fs.String("backend", git.BackendExec, "git backend: exec or native")
+	noCache := fs.Bool("no-cache", false, "neither read nor write the commit cache")
+	jsonOut := fs.Bool("json", false, "output JSON")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usageText())
+			return exitOK
+		}
+		exitWith(exitUsage, err.Error(), true)
+	}
+	rev := "HEAD"
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		rev = fs.Arg(0)
+	default:
+		exitWith(exitUsage, "usage: roastgit show [flags] [<rev>]", true)
+	}
+	cfg := model.Config{ConfigFile: *configFile, Intensity: *intensity, TZ: *tz, Backend: *backend, NoCache: *noCache, JSONCompat: model.SchemaVersion}
+	if err := validateConfig(cfg); err != nil {
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if strings.HasPrefix(rev, "-") {
+		exitWith(exitUsage, fmt.Sprintf("invalid revision %q", rev), true)
+	}
+
+	if cfg.Backend == git.BackendExec {
+		if err := git.EnsureGit(); err != nil {
+			exitWith(exitGitError, "git executable not found in PATH (try --backend native)", false)
+		}
+	}
+	repoPath, err := resolveRepo(*path)
+	if err != nil {
+		if errors.Is(err, git.ErrNotRepo) {
+			exitWith(exitNotRepo, "not a git repository", false)
+		}
+		exitWith(exitGitError, err.Error(), false)
+	}
+	fileCfg, err := config.LoadRepo(repoPath, cfg.ConfigFile)
+	if err != nil {
+		exitWith(exitUsage, err.Error(), false)
+	}
+	analyzeCfg, err := commitAnalyzeConfig(fileCfg, cfg.TZ)
+	if err != nil {
+		exitWith(exitUsage, err.Error(), false)
+	}
+
+	repo, err := git.Open(cfg.Backend, repoPath)
+	if err != nil {
+		handleGitError(err)
+	}
+	defer repo.Close()
+
+	ctx := context.Background()
+	sha, err := repo.ResolveCommit(ctx, rev)
+	if err != nil {
+		if _, headErr := repo.HeadSHA(ctx); errors.Is(headErr, git.ErrUnbornHead) {
+			err = headErr
+		}
+		handleGitError(err)
+	}
+	store := cache.OpenRepo(ctx, repo.GitDir, cfg.Backend, cfg.NoCache)
+	detail, err := inspectCommit(ctx, repo, store, sha, analyzeCfg)
+	if err != nil {
+		handleGitError(err)
+	}
+	roastCommit(&detail, *intensity, *wholesome, *censor)
+
+	if *jsonOut {
+		out, err := render.CommitJSON(detail)
+		if err != nil {
+			exitWith(exitGitError, err.Error(), false)
+		}
+		fmt.Fprintln(os.Stdout, out)
+	} else {
+		fmt.Fprintln(os.Stdout, render.CommitText(detail, render.TextConfig{NoColor: *noColor}))
+	}
+	return exitOK
+}
+
+// commitAnalyzeConfig sets up the checks of single commits from the config
+// file, as the full analysis would.
+func commitAnalyzeConfig(fileCfg config.File, tz string) (analyze.AnalyzeConfig, error) {
+	allow, err := fileCfg.AllowRules()
+	if err != nil {
+		return analyze.AnalyzeConfig{}, err
+	}
+	rules, err := fileCfg.CustomRules()
+	if err != nil {
+		return analyze.AnalyzeConfig{}, err
+	}
+	return analyze.AnalyzeConfig{TZ: tz, Allow: allow, Bots: fileCfg.BotMatcher(), Rules: rules}, nil
+}
+
+// inspectCommit checks one commit against the commits made around it,
+// reading its per-file numstat through the cache.
+func inspectCommit(ctx context.Context, repo git.Backend, store *cache.Cache, sha string, cfg analyze.AnalyzeConfig) (model.CommitDetail, error) {
+	commits, err := showCommits(ctx, repo, sha)
+	if err != nil {
+		return model.CommitDetail{}, err
+	}
+	files, ok := store.Files(sha)
+	if !ok {
+		if files, err = repo.FileStats(ctx, sha); err != nil {
+			return model.CommitDetail{}, err
+		}
+		store.PutFiles(sha, files)
+		_ = store.Save()
+	}
+	detail, ok := analyze.InspectCommit(commits, sha, files, cfg)
+	if !ok {
+		return model.CommitDetail{}, fmt.Errorf("commit %s not found in git log", sha)
+	}
+	if err := analyze.RuleErr(analyze.Rules(cfg.Rules...)); err != nil {
+		return model.CommitDetail{}, roastgit.ConfigError{Err: err}
+	}
+	return detail, nil
+}
+
+// roastCommit fills in the roast and tips of an inspected commit.
+func roastCommit(detail *model.CommitDetail, intensity int, wholesome, censor bool) {
+	reasons := detail.Reasons
+	if len(detail.FixChain) > 0 {
+		reasons = append(append([]string{}, reasons...), "fix chain")
+	}
+	detail.Roast, detail.Tips = roast.CommitRoast(reasons, intensity, wholesome, censor, detail.SHA)
+}
+
+// showCommits loads the commit sha together with the commits made around it
+// on its own history and on HEAD, newest first.
+func showCommits(ctx context.Context, repo git.Backend, sha string) ([]model.Commit, error) {
+	target, err := repo.LogCommits(ctx, git.LogOptions{MaxCommits: 1, Revisions: []string{sha}})
+	if err != nil {
+		return nil, err
+	}
+	if len(target) == 0 {
+		return nil, fmt.Errorf("commit %s not found", sha)
+	}
+	date := target[0].Date
+	const stamp = "2006-01-02 15:04:05 -0700"
+	neighbours, err := repo.LogCommits(ctx, git.LogOptions{
+		Since:     date.Add(-showNeighbourhood).Format(stamp),
+		Until:     date.Add(showNeighbourhood).Format(stamp),
+		Revisions: []string{sha, "HEAD"},
+	})
+	if err != nil {
+		// Without neighbours the commit is still shown, just without
+		// panic windows and fix chains.
+		return target, nil
+	}
+	commits := target
+	for _, c := range neighbours {
+		if c.SHA != sha {
+			commits = append(commits, c)
+		}
+	}
+	sort.SliceStable(commits, func(i, j int) bool { return commits[i].Date.After(commits[j].Date) })
+	return commits, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"roastgit/internal/cache"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/tui"
+	"roastgit/pkg/roastgit"
+)
+
+// runTUI browses the full report in a full-screen terminal interface. It
+// takes the same flags as a plain run.
+func runTUI(args []string) int {
+	cfg, repoPath, ok := setup(args)
+	if !ok {
+		return exitOK
+	}
+	if isMulti(cfg) {
+		exitWith(exitUsage, "tui reports on one repository; drop --path repeats, --repos-file and --scan", true)
+	}
+	if cfg.JSON || len(cfg.CompareRange) > 0 {
+		exitWith(exitUsage, "tui cannot be combined with --json or --compare", true)
+	}
+	if !tui.Supported {
+		exitWith(exitUsage, tui.ErrUnsupported.Error(), false)
+	}
+	if !tui.IsTerminal(os.Stdin) || !tui.IsTerminal(os.Stdout) {
+		exitWith(exitUsage, "tui needs a terminal; use a plain run or --json when piping", false)
+	}
+	// Every flagged commit is listed, not just the worst few.
+	cfg.Top = 0
+
+	ctx, stop := rootContext(cfg)
+	report, err := buildReport(ctx, repoPath, cfg)
+	if err != nil {
+		handleRunError(ctx, err)
+	}
+	stop()
+
+	repo, err := git.Open(cfg.Backend, repoPath)
+	if err != nil {
+		handleGitError(err)
+	}
+	defer repo.Close()
+	store := cache.OpenRepo(context.Background(), repo.GitDir, cfg.Backend, cfg.NoCache)
+	err = tui.Run(os.Stdin, os.Stdout, tui.Options{
+		Report:    report,
+		Intensity: cfg.Intensity,
+		Files: func(sha string) ([]model.FileStat, error) {
+			if files, ok := store.Files(sha); ok {
+				return files, nil
+			}
+			files, err := repo.FileStats(context.Background(), sha)
+			if err != nil {
+				return nil, err
+			}
+			store.PutFiles(sha, files)
+			return files, nil
+		},
+		Reroast: func(intensity int) model.RoastOutput {
+			return roastgit.Roast(report, intensity, cfg.Wholesome, cfg.Censor)
+		},
+	})
+	// The stats read while browsing are written once, on the way out.
+	_ = store.Save()
+	if err != nil {
+		exitWith(exitGitError, err.Error(), false)
+	}
+	return exitOK
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"roastgit/internal/cache"
+	"roastgit/internal/config"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/render"
+	"roastgit/internal/util"
+)
+
+// watchMaxShown caps the commits roasted one by one when HEAD jumps ahead
+// by many at once, as after a pull.
+const watchMaxShown = 5
+
+// watchMaxBackoff caps the wait after repeated failed polls, which double
+// the interval each time.
+const watchMaxBackoff = 5 * time.Minute
+
+// runWatch polls a repository for new commits and roasts each as it lands,
+// followed by the change in the overall score, until interrupted.
+func runWatch(args []string) int {
+	fs := flag.NewFlagSet("roastgit watch", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	path := fs.String("path", "", "path to repo (default: auto-detect from cwd)")
+	configFile := fs.String("config", "", "config file")
+	interval := fs.Duration("interval", time.Second, "how often to check for new commits")
+	intensity := fs.Int("intensity", 3, "roast intensity 0-5")
+	wholesome := fs.Bool("wholesome", false, "wholesome mode")
+	censor := fs.Bool("censor", false, "censor profanity")
+	deep := fs.Bool("deep", false, "score with the sizes of all commits")
+	tz := fs.String("tz", "local", "time zone: local or commit")
+	backend := fs.String("backend", git.BackendExec, "git backend: exec or native")
+	jobs := fs.Int("jobs", 0, "concurrent numstat workers (0 = number of CPUs)")
+	noCache := fs.Bool("no-cache", false, "neither read nor write the commit cache")
+	noColor := fs.Bool("no-color", false, "disable ANSI colors")
+	gitTimeout := fs.Duration("git-timeout", git.DefaultCommandTimeout, "limit of each short git command such as rev-parse (0 = no limit)")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, usageText())
+			return exitOK
+		}
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if fs.NArg() > 0 {
+		exitWith(exitUsage, "usage: roastgit watch [--interval d] [flags]", true)
+	}
+	if *interval <= 0 {
+		exitWith(exitUsage, "--interval must be positive", true)
+	}
+	cfg := model.Config{
+		Path:       *path,
+		ConfigFile: *configFile,
+		Intensity:  *intensity,
+		Wholesome:  *wholesome,
+		Censor:     *censor,
+		Deep:       *deep,
+		TZ:         *tz,
+		Backend:    *backend,
+		Jobs:       *jobs,
+		NoCache:    *noCache,
+		NoColor:    *noColor,
+		GitTimeout: *gitTimeout,
+		Quiet:      true,
+		JSONCompat: model.SchemaVersion,
+	}
+	if err := validateConfig(cfg); err != nil {
+		exitWith(exitUsage, err.Error(), true)
+	}
+	if cfg.Backend == git.BackendExec {
+		if err := git.EnsureGit(); err != nil {
+			exitWith(exitGitError, "git executable not found in PATH (try --backend native)", false)
+		}
+	}
+	repoPath, err := resolveRepo(cfg.Path)
+	if err != nil {
+		if errors.Is(err, git.ErrNotRepo) {
+			exitWith(exitNotRepo, "not a git repository", false)
+		}
+		exitWith(exitGitError, err.Error(), false)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx = git.WithCommandTimeout(ctx, cfg.GitTimeout)
+	w := &watcher{repoPath: repoPath, cfg: cfg, out: os.Stdout}
+	if err := w.start(ctx); err != nil {
+		handleRunError(ctx, err)
+	}
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return exitOK
+		case <-ticker.C:
+			if time.Now().Before(w.retryAt) {
+				continue
+			}
+			err := w.poll(ctx)
+			switch {
+			case ctx.Err() != nil:
+			case err != nil:
+				delay := w.backoff(*interval)
+				fmt.Fprintf(os.Stderr, "roastgit: %s (retrying in %s)\n", git.Summary(err), delay)
+			default:
+				w.failures = 0
+			}
+		}
+	}
+}
+
+// watcher remembers the HEAD and report last seen by watch mode.
+type watcher struct {
+	repoPath string
+	cfg      model.Config
+	out      io.Writer
+	gitDir   string
+	state    string
+	head     string
+	report   model.Report
+	// failures counts polls failed in a row; none is tried before retryAt.
+	failures int
+	retryAt  time.Time
+}
+
+// backoff records a failed poll and returns how long to wait before the
+// next: the interval, doubled for each earlier failure in a row.
+func (w *watcher) backoff(interval time.Duration) time.Duration {
+	delay := interval
+	for i := 0; i < w.failures && delay < watchMaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, watchMaxBackoff)
+	w.failures++
+	w.retryAt = time.Now().Add(delay)
+	return delay
+}
+
+// start reads the current report and prints what is being watched.
+func (w *watcher) start(ctx context.Context) error {
+	repo, err := git.Open(w.cfg.Backend, w.repoPath)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	if w.gitDir, err = repo.GitDir(ctx); err != nil {
+		return err
+	}
+	w.state = git.RefsState(w.gitDir)
+	if w.report, err = buildReport(ctx, w.repoPath, w.cfg); err != nil {
+		return err
+	}
+	w.head = w.report.Repo.Head
+	at := "no commits yet"
+	if w.head != "" {
+		at = fmt.Sprintf("%s, score %d/100", util.ShortSHA(w.head), w.report.Score.Overall)
+	}
+	fmt.Fprintf(w.out, "Watching %s (%s). Press Ctrl-C to stop.\n", w.report.Repo.Name, at)
+	return nil
+}
+
+// poll roasts the commits made since the last poll, if HEAD moved. Nothing
+// is printed and what it has seen is only recorded once the whole poll
+// succeeds, so a failed poll is retried on the next tick without repeating
+// itself.
+func (w *watcher) poll(ctx context.Context) error {
+	state := git.RefsState(w.gitDir)
+	if state == w.state {
+		return nil
+	}
+	// The backend is opened per change so the native one sees new packs.
+	repo, err := git.Open(w.cfg.Backend, w.repoPath)
+	if err != nil {
+		return err
+	}
+	defer repo.Close()
+	head, err := repo.HeadSHA(ctx)
+	if errors.Is(err, git.ErrUnbornHead) || (err == nil && head == w.head) {
+		w.state = state
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	revisions := []string{head}
+	if w.head != "" {
+		revisions = append(revisions, "^"+w.head)
+	}
+	added, err := repo.LogCommits(ctx, git.LogOptions{Revisions: revisions})
+	if err != nil {
+		return err
+	}
+	b := &bytes.Buffer{}
+	fmt.Fprintf(b, "\n[%s] ", time.Now().Format("15:04:05"))
+	switch {
+	case len(added) == 0:
+		fmt.Fprintf(b, "HEAD moved to %s\n", util.ShortSHA(head))
+	case len(added) == 1:
+		fmt.Fprintln(b, "New commit")
+	default:
+		fmt.Fprintf(b, "%d new commits\n", len(added))
+	}
+	if err := w.roastCommits(ctx, repo, added, b); err != nil {
+		return err
+	}
+
+	report, err := buildReport(ctx, w.repoPath, w.cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(b, render.ScoreDeltaText(w.report.Score, report.Score, render.TextConfig{NoColor: w.cfg.NoColor}))
+	if _, err := w.out.Write(b.Bytes()); err != nil {
+		return err
+	}
+	w.state, w.head, w.report = state, head, report
+	return nil
+}
+
+// roastCommits writes a short roast of each added commit to out, oldest
+// first, and only the newest few after a large jump.
+func (w *watcher) roastCommits(ctx context.Context, repo git.Backend, added []model.Commit, out io.Writer) error {
+	fileCfg, err := config.LoadRepo(w.repoPath, w.cfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+	analyzeCfg, err := commitAnalyzeConfig(fileCfg, w.cfg.TZ)
+	if err != nil {
+		return err
+	}
+	if len(added) > watchMaxShown {
+		fmt.Fprintf(out, "(%d older commits not shown)\n", len(added)-watchMaxShown)
+		added = added[:watchMaxShown]
+	}
+	store := cache.OpenRepo(ctx, repo.GitDir, w.cfg.Backend, w.cfg.NoCache)
+	for i := len(added) - 1; i >= 0; i-- {
+		detail, err := inspectCommit(ctx, repo, store, added[i].SHA, analyzeCfg)
+		if err != nil {
+			return err
+		}
+		roastCommit(&detail, w.cfg.Intensity, w.cfg.Wholesome, w.cfg.Censor)
+		fmt.Fprintln(out, render.WatchCommitText(detail, render.TextConfig{NoColor: w.cfg.NoColor}))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"roastgit/internal/config"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+)
+
+func TestWatchBackoff(t *testing.T) {
+	w := &watcher{}
+	var delays []time.Duration
+	for i := 0; i < 12; i++ {
+		delays = append(delays, w.backoff(2*time.Second))
+	}
+	if delays[0] != 2*time.Second || delays[1] != 4*time.Second || delays[2] != 8*time.Second || delays[11] != watchMaxBackoff {
+		t.Fatalf("unexpected delays: %v", delays)
+	}
+	if time.Until(w.retryAt) <= 0 {
+		t.Fatalf("retryAt is not ahead: %v", w.retryAt)
+	}
+}
+
+// TestWatchRetriesFailedPoll breaks the config while a commit lands, so the
+// poll that sees it fails; once the config is fixed the next poll must still
+// roast that commit even though the refs have not moved since.
+func TestWatchRetriesFailedPoll(t *testing.T) {
+	if err := git.EnsureGit(); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane", "GIT_AUTHOR_EMAIL=jane@example.com",
+			"GIT_COMMITTER_NAME=Jane", "GIT_COMMITTER_EMAIL=jane@example.com", "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q")
+	run("commit", "-q", "--allow-empty", "-m", "Initial commit")
+
+	ctx := context.Background()
+	out := &bytes.Buffer{}
+	w := &watcher{repoPath: dir, cfg: model.Config{Intensity: 3, TZ: "commit", Backend: git.BackendExec, NoCache: true, NoColor: true, Quiet: true}, out: out}
+	if err := w.start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cfgPath := filepath.Join(dir, config.DefaultFile)
+	if err := os.WriteFile(cfgPath, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("commit", "-q", "--allow-empty", "-m", "wip")
+	out.Reset()
+	if err := w.poll(ctx); err == nil {
+		t.Fatal("expected the poll to fail on the broken config")
+	}
+	if out.Len() != 0 {
+		t.Fatalf("the failed poll printed, and its retry would print again:\n%s", out)
+	}
+
+	if err := os.Remove(cfgPath); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err := w.poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := git.Open(git.BackendExec, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	head, err := repo.HeadSHA(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.head != head || w.report.Repo.Head != head || !strings.Contains(out.String(), "New commit") {
+		t.Fatalf("the failed poll was not retried: head %s, want %s; output:\n%s", w.head, head, out)
+	}
+}
@@ -0,0 +1,121 @@
+package aggregate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"roastgit/internal/model"
+)
+
+// Reports combines per-repository reports into one org-wide report. Repos
+// are ordered by name; failures are kept as given.
+func Reports(reports []model.Report, failures []model.RepoFailure) model.MultiReport {
+	multi := model.MultiReport{
+		SchemaVersion: model.SchemaVersion,
+		Repos:         append([]model.Report{}, reports...),
+		Failures:      append([]model.RepoFailure{}, failures...),
+	}
+	sort.SliceStable(multi.Repos, func(i, j int) bool {
+		if multi.Repos[i].Repo.Name != multi.Repos[j].Repo.Name {
+			return multi.Repos[i].Repo.Name < multi.Repos[j].Repo.Name
+		}
+		return multi.Repos[i].Repo.Path < multi.Repos[j].Repo.Path
+	})
+	for _, r := range multi.Repos {
+		multi.CommitCount += r.Repo.CommitCount
+	}
+	multi.Score = Score(multi.Repos)
+	multi.Authors = Authors(multi.Repos)
+	return multi
+}
+
+// Score weights each repository's category scores by its commit count, so
+// a busy repository counts for more than a dormant one. Repositories
+// without commits are left out; with none left the score is 100.
+func Score(reports []model.Report) model.Score {
+	var total float64
+	var sums [4]float64
+	used := 0
+	for _, r := range reports {
+		n := float64(r.Repo.CommitCount)
+		if n == 0 {
+			continue
+		}
+		b := r.Score.Breakdown
+		sums[0] += n * float64(b.MessageQuality)
+		sums[1] += n * float64(b.Hygiene)
+		sums[2] += n * float64(b.Cadence)
+		sums[3] += n * float64(b.SizeDiscipline)
+		total += n
+		used++
+	}
+	breakdown := model.ScoreBreakdown{MessageQuality: 30, Hygiene: 30, Cadence: 20, SizeDiscipline: 20}
+	if total > 0 {
+		breakdown = model.ScoreBreakdown{
+			MessageQuality: int(math.Round(sums[0] / total)),
+			Hygiene:        int(math.Round(sums[1] / total)),
+			Cadence:        int(math.Round(sums[2] / total)),
+			SizeDiscipline: int(math.Round(sums[3] / total)),
+		}
+	}
+	return model.Score{
+		Overall:   breakdown.MessageQuality + breakdown.Hygiene + breakdown.Cadence + breakdown.SizeDiscipline,
+		Breakdown: breakdown,
+		Explain: map[string]string{
+			"overall": fmt.Sprintf("commit-weighted mean of each category over %d repositories = %d",
+				used, breakdown.MessageQuality+breakdown.Hygiene+breakdown.Cadence+breakdown.SizeDiscipline),
+		},
+	}
+}
+
+// Authors sums per-author stats across repositories, matching authors by
+// email (or name when there is none), busiest first. The name shown is the
+// one used in the repository with the most of their commits.
+func Authors(reports []model.Report) []model.OrgAuthor {
+	type acc struct {
+		author       model.OrgAuthor
+		qualityTotal float64
+		topCommits   int
+	}
+	byKey := map[string]*acc{}
+	keys := []string{}
+	for _, r := range reports {
+		for _, a := range r.Authors {
+			key := strings.ToLower(a.Email)
+			if key == "" {
+				key = a.Name
+			}
+			x, ok := byKey[key]
+			if !ok {
+				x = &acc{author: model.OrgAuthor{AuthorStats: model.AuthorStats{Email: a.Email}, Repos: []string{}}}
+				byKey[key] = x
+				keys = append(keys, key)
+			}
+			if a.Commits > x.topCommits {
+				x.author.Name = a.Name
+				x.topCommits = a.Commits
+			}
+			x.author.Commits += a.Commits
+			x.author.LowQuality += a.LowQuality
+			x.qualityTotal += a.AverageQuality * float64(a.Commits)
+			x.author.Repos = append(x.author.Repos, r.Repo.Name)
+		}
+	}
+	out := make([]model.OrgAuthor, 0, len(keys))
+	for _, key := range keys {
+		x := byKey[key]
+		if x.author.Commits > 0 {
+			x.author.AverageQuality = x.qualityTotal / float64(x.author.Commits)
+		}
+		out = append(out, x.author)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Commits != out[j].Commits {
+			return out[i].Commits > out[j].Commits
+		}
+		return strings.ToLower(out[i].Email) < strings.ToLower(out[j].Email)
+	})
+	return out
+}
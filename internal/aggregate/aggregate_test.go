@@ -0,0 +1,59 @@
+package aggregate
+
+import (
+	"math"
+	"testing"
+
+	"roastgit/internal/model"
+)
+
+func TestReports(t *testing.T) {
+	api := model.Report{
+		Repo:  model.RepoInfo{Name: "api", CommitCount: 300},
+		Score: model.Score{Overall: 80, Breakdown: model.ScoreBreakdown{MessageQuality: 25, Hygiene: 25, Cadence: 15, SizeDiscipline: 15}},
+		Authors: []model.AuthorStats{
+			{Name: "Jane D.", Email: "Jane@example.com", Commits: 200, LowQuality: 10, AverageQuality: 80},
+			{Name: "Bob", Email: "bob@example.com", Commits: 100, LowQuality: 50, AverageQuality: 40},
+		},
+	}
+	web := model.Report{
+		Repo:  model.RepoInfo{Name: "web", CommitCount: 100},
+		Score: model.Score{Overall: 40, Breakdown: model.ScoreBreakdown{MessageQuality: 5, Hygiene: 15, Cadence: 15, SizeDiscipline: 5}},
+		Authors: []model.AuthorStats{
+			{Name: "Jane", Email: "jane@example.com", Commits: 100, LowQuality: 30, AverageQuality: 50},
+		},
+	}
+	empty := model.Report{Repo: model.RepoInfo{Name: "new"}, Score: model.Score{Overall: 100}}
+	failures := []model.RepoFailure{{Path: "/srv/gone", Error: "not a git repository"}}
+
+	multi := Reports([]model.Report{web, empty, api}, failures)
+	if multi.Repos[0].Repo.Name != "api" || multi.Repos[2].Repo.Name != "web" {
+		t.Fatalf("repos not ordered by name: %v, %v, %v", multi.Repos[0].Repo.Name, multi.Repos[1].Repo.Name, multi.Repos[2].Repo.Name)
+	}
+	if multi.CommitCount != 400 || len(multi.Failures) != 1 {
+		t.Fatalf("commit count %d, failures %v", multi.CommitCount, multi.Failures)
+	}
+	// (300*25 + 100*5) / 400 = 20, (300*25 + 100*15) / 400 = 22.5, 15, (300*15 + 100*5) / 400 = 12.5
+	want := model.ScoreBreakdown{MessageQuality: 20, Hygiene: 23, Cadence: 15, SizeDiscipline: 13}
+	if multi.Score.Breakdown != want || multi.Score.Overall != 71 {
+		t.Fatalf("score = %+v, want %+v (71)", multi.Score, want)
+	}
+
+	if len(multi.Authors) != 2 {
+		t.Fatalf("authors = %+v", multi.Authors)
+	}
+	jane := multi.Authors[0]
+	if jane.Name != "Jane D." || jane.Commits != 300 || jane.LowQuality != 40 || len(jane.Repos) != 2 {
+		t.Fatalf("jane = %+v", jane)
+	}
+	if math.Abs(jane.AverageQuality-70) > 1e-9 {
+		t.Fatalf("jane average quality = %v, want 70", jane.AverageQuality)
+	}
+}
+
+func TestScoreWithoutCommits(t *testing.T) {
+	score := Score([]model.Report{{Repo: model.RepoInfo{Name: "new"}}})
+	if score.Overall != 100 {
+		t.Fatalf("score of empty repos = %+v", score)
+	}
+}
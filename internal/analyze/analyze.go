@@ -1,7 +1,6 @@
 package analyze
 
 import (
-	"fmt"
 	"sort"
 	"strings"
 	"time"
@@ -11,193 +10,79 @@ import (
 
 type AnalyzeConfig struct {
 	TZ string
+	// Allow exempts matching commits from checks, on top of any
+	// Roastgit-Ignore trailers in their messages.
+	Allow []AllowRule
+	// Bots identifies automation commits, which are kept out of message
+	// metrics and message checks.
+	Bots *BotMatcher
+	// Messages holds precomputed message checks by SHA, e.g. from the
+	// cache; commits missing from it are checked on the fly.
+	Messages map[string]MessageInfo
+	// Rules are custom rules run after the built-in and registered ones.
+	Rules []Rule
+	// Files holds per-file stats by SHA for rules that match on paths.
+	Files map[string][]model.FileStat
 }
 
-type commitFlags struct {
-	msgInfo    MessageInfo
-	lowQuality bool
-	lying      bool
-	panic      bool
-	large      bool
-	binary     bool
-	midnight   bool
-	deadline   bool
-}
-
-// Analyze computes metrics and offenders for a set of commits.
-func Analyze(commits []model.Commit, sizes map[string]model.CommitSize, branches []string, cfg AnalyzeConfig) (model.Metrics, []model.Offender) {
-	metrics := model.Metrics{}
-	if len(commits) == 0 {
-		return metrics, nil
+// message returns the message checks of c.
+func (cfg AnalyzeConfig) message(c model.Commit) MessageInfo {
+	if info, ok := cfg.Messages[c.SHA]; ok {
+		return info
 	}
-	flags := make([]commitFlags, len(commits))
-	genericCounts := map[string]int{}
-	msgLenTotal := 0
-	msgQualityTotal := 0
-	lowQualityCount := 0
-	lyingCount := 0
-
-	// Attach size info and message metrics.
-	sizeMetrics := model.SizeMetrics{}
-	var sizeLineTotal int
-	for i := range commits {
-		c := &commits[i]
-		if size, ok := sizes[c.SHA]; ok {
-			c.Size = &size
-		}
-		info := AnalyzeMessage(c.Subject)
-		flags[i].msgInfo = info
-		if info.Generic {
-			genericCounts[info.GenericKey]++
-		}
-		msgLenTotal += len([]rune(c.Subject))
-		msgQualityTotal += info.Score
-		low := info.Generic || info.EmojiOnly || info.TooLong || info.TooShort
-		flags[i].lowQuality = low
-		if low {
-			lowQualityCount++
-		}
-		if c.Size != nil {
-			lines := c.Size.Added + c.Size.Deleted
-			sizeLineTotal += lines
-			if lines > sizeMetrics.MaxLines {
-				sizeMetrics.MaxLines = lines
-			}
-			if lines >= 800 || c.Size.Files >= 20 {
-				sizeMetrics.LargeCommitCount++
-				flags[i].large = true
-			}
-			if c.Size.BinaryFiles > 0 {
-				sizeMetrics.BinaryCommitCount++
-				flags[i].binary = true
-			}
-			sizeMetrics.SampleSize++
-			if IsLyingMessage(c.Subject, lines, c.Size.Files) {
-				flags[i].lying = true
-				lyingCount++
-			}
-		}
-	}
-	metrics.Message.Total = len(commits)
-	metrics.Message.Generic = countGeneric(flags)
-	metrics.Message.EmojiOnly = countEmoji(flags)
-	metrics.Message.TooLong = countTooLong(flags)
-	metrics.Message.TooShort = countTooShort(flags)
-	metrics.Message.Lying = lyingCount
-	metrics.Message.LowQuality = lowQualityCount
-	metrics.Message.AverageLength = float64(msgLenTotal) / float64(len(commits))
-	metrics.Message.AverageQuality = float64(msgQualityTotal) / float64(len(commits))
-	metrics.Message.TopGenericWords = topGenericWords(genericCounts, 3)
-
-	metrics.Size = sizeMetrics
-	if sizeMetrics.SampleSize > 0 {
-		metrics.Size.AverageLines = float64(sizeLineTotal) / float64(sizeMetrics.SampleSize)
-	}
-
-	// Time metrics and panic detection.
-	applyTZ := func(t time.Time) time.Time {
-		if cfg.TZ == "commit" {
-			return t
-		}
-		return t.In(time.Local)
-	}
-	dayCounts := map[string]int{}
-	weekCounts := map[string]int{}
-	midnightCount := 0
-	deadlineCount := 0
-	timesAsc, idxAsc := orderTimesAsc(commits, applyTZ)
-	for i, idx := range idxAsc {
-		local := timesAsc[i]
-		dayKey := local.Format("2006-01-02")
-		dayCounts[dayKey]++
-		year, week := local.ISOWeek()
-		weekKey := fmt.Sprintf("%04d-W%02d", year, week)
-		weekCounts[weekKey]++
-		hour := local.Hour()
-		if hour >= 0 && hour < 5 {
-			midnightCount++
-			flags[idx].midnight = true
-		}
-		if isDeadlineTime(local) {
-			deadlineCount++
-			flags[idx].deadline = true
-		}
-	}
-	metrics.Time.UniqueDays = len(dayCounts)
-	metrics.Time.UniqueWeeks = len(weekCounts)
-	metrics.Time.CommitsPerDayAvg = float64(len(commits)) / float64(max(1, metrics.Time.UniqueDays))
-	metrics.Time.CommitsPerWeekAvg = float64(len(commits)) / float64(max(1, metrics.Time.UniqueWeeks))
-	metrics.Time.MidnightRatio = float64(midnightCount) / float64(len(commits))
-	metrics.Time.DeadlineRatio = float64(deadlineCount) / float64(len(commits))
-	metrics.Time.LongestStreakDays = longestStreak(dayCounts)
-
-	panicFlags := detectPanic(timesAsc, flags, idxAsc)
-	panicCount := 0
-	for i, isPanic := range panicFlags {
-		if isPanic {
-			flags[i].panic = true
-			panicCount++
-		}
-	}
-	metrics.Message.Panic = panicCount
+	return AnalyzeMessage(c.Subject)
+}
 
-	// Hygiene metrics.
-	mergeCount := 0
-	for _, c := range commits {
-		if len(c.Parents) > 1 {
-			mergeCount++
-		}
+// localTime converts t to the zone selected by TZ.
+func (cfg AnalyzeConfig) localTime(t time.Time) time.Time {
+	if cfg.TZ == "commit" {
+		return t
 	}
-	metrics.Hygiene.MergeRatio = float64(mergeCount) / float64(len(commits))
-	metrics.Hygiene.LinearRatio = 1 - metrics.Hygiene.MergeRatio
-	metrics.Hygiene.BranchCount = len(branches)
-	badBranches := badBranchNames(branches)
-	metrics.Hygiene.BadBranchCount = len(badBranches)
-	metrics.Hygiene.BadBranches = badBranches
+	return t.In(time.Local)
+}
 
-	// Offenders
-	offenders := buildOffenders(commits, flags)
-	return metrics, offenders
+type commitFlags struct {
+	// message is the unexempted message checks rules see; msgInfo has the
+	// exempted hits cleared, for the metrics.
+	message    MessageInfo
+	msgInfo    MessageInfo
+	lowQuality bool
+	panic      bool
+	bot        bool
+	exempt     map[string]bool
+	exempted   int
+	hits       []hit
 }
 
-func countGeneric(flags []commitFlags) int {
-	count := 0
-	for _, f := range flags {
-		if f.msgInfo.Generic {
-			count++
-		}
+// allow reports whether a check hit survives the commit's exemptions,
+// counting the hits that were skipped.
+func (f *commitFlags) allow(id string, hit bool) bool {
+	if hit && f.exempts(id) {
+		f.exempted++
+		return false
 	}
-	return count
+	return hit
 }
 
-func countEmoji(flags []commitFlags) int {
-	count := 0
-	for _, f := range flags {
-		if f.msgInfo.EmojiOnly {
-			count++
-		}
-	}
-	return count
+// counts reports whether a check hit survives the commit's exemptions for
+// the metrics. Unlike allow it tallies nothing: judge does that once, when
+// the check's rule runs.
+func (f *commitFlags) counts(id string, hit bool) bool {
+	return hit && !f.exempts(id)
 }
 
-func countTooLong(flags []commitFlags) int {
-	count := 0
-	for _, f := range flags {
-		if f.msgInfo.TooLong {
-			count++
-		}
-	}
-	return count
+func (f *commitFlags) exempts(id string) bool {
+	return f.exempt[id] || f.exempt[CheckAll]
 }
 
-func countTooShort(flags []commitFlags) int {
-	count := 0
-	for _, f := range flags {
-		if f.msgInfo.TooShort {
-			count++
-		}
+// Analyze computes metrics and offenders for a set of commits.
+func Analyze(commits []model.Commit, sizes map[string]model.CommitSize, branches []string, cfg AnalyzeConfig) (model.Metrics, []model.Offender) {
+	if len(commits) == 0 {
+		return model.Metrics{}, nil
 	}
-	return count
+	var totals Totals
+	totals.Add(commits, sizes, cfg)
+	return totals.Metrics(branches), totals.Offenders
 }
 
 func topGenericWords(counts map[string]int, limit int) []string {
@@ -234,6 +119,10 @@ func orderTimesAsc(commits []model.Commit, apply func(time.Time) time.Time) ([]t
 	return times, idx
 }
 
+func isHuge(lines, files int) bool {
+	return lines >= 800 || files >= 20
+}
+
 func isDeadlineTime(t time.Time) bool {
 	weekday := t.Weekday()
 	hour := t.Hour()
@@ -274,17 +163,17 @@ func longestStreak(dayCounts map[string]int) int {
 	return longest
 }
 
-func detectPanic(timesAsc []time.Time, flags []commitFlags, idxAsc []int) []bool {
+// panicWindows returns the merged [start, end] index ranges of timesAsc in
+// which an hour holds 4+ commits, 3+ of them with low-quality messages.
+func panicWindows(timesAsc []time.Time, low []bool) [][2]int {
 	n := len(timesAsc)
-	panicFlags := make([]bool, n)
 	if n == 0 {
-		return panicFlags
+		return nil
 	}
 	lowPrefix := make([]int, n+1)
 	for i := 0; i < n; i++ {
-		origIdx := idxAsc[i]
 		lowPrefix[i+1] = lowPrefix[i]
-		if flags[origIdx].lowQuality {
+		if low[i] {
 			lowPrefix[i+1]++
 		}
 	}
@@ -301,7 +190,7 @@ func detectPanic(timesAsc []time.Time, flags []commitFlags, idxAsc []int) []bool
 		}
 	}
 	if len(intervals) == 0 {
-		return panicFlags
+		return nil
 	}
 	// Merge intervals
 	sort.Slice(intervals, func(i, j int) bool { return intervals[i][0] < intervals[j][0] })
@@ -316,12 +205,7 @@ func detectPanic(timesAsc []time.Time, flags []commitFlags, idxAsc []int) []bool
 			merged = append(merged, in)
 		}
 	}
-	for _, in := range merged {
-		for i := in[0]; i <= in[1]; i++ {
-			panicFlags[idxAsc[i]] = true
-		}
-	}
-	return panicFlags
+	return merged
 }
 
 func badBranchNames(branches []string) []string {
@@ -340,86 +224,6 @@ func badBranchNames(branches []string) []string {
 	return bad
 }
 
-func buildOffenders(commits []model.Commit, flags []commitFlags) []model.Offender {
-	type candidate struct {
-		idx     int
-		score   int
-		reasons []string
-	}
-	cands := []candidate{}
-	for i := range commits {
-		reasons := []string{}
-		score := 0
-		f := flags[i]
-		if f.msgInfo.Generic {
-			reasons = append(reasons, "generic message")
-			score += 8
-		}
-		if f.msgInfo.EmojiOnly {
-			reasons = append(reasons, "emoji-only message")
-			score += 7
-		}
-		if f.msgInfo.TooLong {
-			reasons = append(reasons, "too long")
-			score += 3
-		}
-		if f.msgInfo.TooShort {
-			reasons = append(reasons, "too short")
-			score += 3
-		}
-		if f.lying {
-			reasons = append(reasons, "lying message")
-			score += 9
-		}
-		if f.panic {
-			reasons = append(reasons, "panic streak")
-			score += 6
-		}
-		if f.large {
-			reasons = append(reasons, "huge commit")
-			score += 7
-		}
-		if f.binary {
-			reasons = append(reasons, "binary blobs")
-			score += 5
-		}
-		if f.midnight {
-			reasons = append(reasons, "midnight gremlin")
-			score += 2
-		}
-		if f.deadline {
-			reasons = append(reasons, "deadline scramble")
-			score += 2
-		}
-		if len(reasons) == 0 {
-			continue
-		}
-		cands = append(cands, candidate{idx: i, score: score, reasons: reasons})
-	}
-	sort.Slice(cands, func(i, j int) bool {
-		if cands[i].score == cands[j].score {
-			return commits[cands[i].idx].Date.After(commits[cands[j].idx].Date)
-		}
-		return cands[i].score > cands[j].score
-	})
-	limit := 5
-	if len(cands) < limit {
-		limit = len(cands)
-	}
-	offenders := make([]model.Offender, 0, limit)
-	for i := 0; i < limit; i++ {
-		c := commits[cands[i].idx]
-		offenders = append(offenders, model.Offender{
-			SHA:     c.SHA,
-			Subject: c.Subject,
-			Date:    c.Date.Format(time.RFC3339),
-			Reasons: cands[i].reasons,
-			Score:   cands[i].score,
-		})
-	}
-	return offenders
-}
-
 func max(a, b int) int {
 	if a > b {
 		return a
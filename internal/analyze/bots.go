@@ -0,0 +1,180 @@
+package analyze
+
+import (
+	"sort"
+	"strings"
+
+	"roastgit/internal/model"
+)
+
+// DefaultBotPatterns match common automation identities. Patterns are
+// case-insensitive substrings of the author name or email. "noreply@" is
+// anchored on the @ so GitHub's per-user users.noreply.github.com addresses
+// are not mistaken for bots.
+var DefaultBotPatterns = []string{
+	"[bot]",
+	"noreply@",
+	"no-reply@",
+	"dependabot",
+	"renovate",
+	"github-actions",
+	"semantic-release",
+}
+
+// BotMatcher detects automation commits. A nil matcher matches nothing.
+type BotMatcher struct {
+	patterns []string
+}
+
+// NewBotMatcher builds a matcher from extra patterns, optionally on top of
+// DefaultBotPatterns.
+func NewBotMatcher(extra []string, withDefaults bool) *BotMatcher {
+	m := &BotMatcher{}
+	if withDefaults {
+		extra = append(append([]string{}, DefaultBotPatterns...), extra...)
+	}
+	for _, p := range extra {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return m
+}
+
+// IsBot reports whether c was authored by automation.
+func (m *BotMatcher) IsBot(c model.Commit) bool {
+	if m == nil {
+		return false
+	}
+	name := strings.ToLower(c.AuthorName)
+	email := strings.ToLower(c.AuthorEmail)
+	for _, p := range m.patterns {
+		if strings.Contains(name, p) || strings.Contains(email, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutBots returns the commits not authored by automation.
+func WithoutBots(commits []model.Commit, bots *BotMatcher) []model.Commit {
+	out := make([]model.Commit, 0, len(commits))
+	for _, c := range commits {
+		if !bots.IsBot(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Authors splits per-author stats into humans and bots, busiest first.
+// Authors are keyed by email, falling back to name. Each commit is a bot's
+// or a human's on its own, so an address shared by a person and automation
+// is listed once on each side. Low-quality counts honor exemptions, as in
+// Analyze.
+func Authors(commits []model.Commit, cfg AnalyzeConfig) ([]model.AuthorStats, []model.AuthorStats) {
+	totals := AuthorTotals{}
+	totals.Add(commits, cfg)
+	return totals.Split()
+}
+
+// Automation summarizes bot activity among commits.
+func Automation(commits []model.Commit, cfg AnalyzeConfig, excluded bool) model.AutomationSummary {
+	totals := AuthorTotals{}
+	totals.Add(commits, cfg)
+	return totals.Automation(excluded)
+}
+
+// AuthorTotals are per-author sums keyed as in Authors, which the commits
+// made since can be added to.
+type AuthorTotals map[string]*AuthorTotal
+
+// AuthorTotal sums one author's commits.
+type AuthorTotal struct {
+	Name         string `json:"name"`
+	Email        string `json:"email"`
+	Commits      int    `json:"commits"`
+	LowQuality   int    `json:"low_quality"`
+	QualityTotal int    `json:"quality_total"`
+	Bot          bool   `json:"bot,omitempty"`
+}
+
+// Add adds commits, newest first, to the totals. They must be newer than
+// the commits added before: an author is listed under the name and email of
+// their newest commit.
+func (t AuthorTotals) Add(commits []model.Commit, cfg AnalyzeConfig) {
+	named := map[string]bool{}
+	for _, c := range commits {
+		bot := cfg.Bots.IsBot(c)
+		key := strings.ToLower(c.AuthorEmail)
+		if key == "" {
+			key = c.AuthorName
+		}
+		if bot {
+			key += "\x00bot"
+		}
+		a, ok := t[key]
+		if !ok {
+			a = &AuthorTotal{Bot: bot}
+			t[key] = a
+		}
+		if !named[key] {
+			named[key] = true
+			a.Name, a.Email = c.AuthorName, c.AuthorEmail
+		}
+		a.Commits++
+		a.QualityTotal += cfg.message(c).Score
+		if lowQualityMessage(c, cfg) {
+			a.LowQuality++
+		}
+	}
+}
+
+// Split returns the human and the bot authors, busiest first.
+func (t AuthorTotals) Split() ([]model.AuthorStats, []model.AuthorStats) {
+	humans := []model.AuthorStats{}
+	automation := []model.AuthorStats{}
+	for _, a := range t {
+		stats := model.AuthorStats{
+			Name:           a.Name,
+			Email:          a.Email,
+			Commits:        a.Commits,
+			LowQuality:     a.LowQuality,
+			AverageQuality: float64(a.QualityTotal) / float64(a.Commits),
+		}
+		if a.Bot {
+			automation = append(automation, stats)
+		} else {
+			humans = append(humans, stats)
+		}
+	}
+	sortAuthors(humans)
+	sortAuthors(automation)
+	return humans, automation
+}
+
+// Automation summarizes bot activity among the commits of the totals.
+func (t AuthorTotals) Automation(excluded bool) model.AutomationSummary {
+	_, automation := t.Split()
+	summary := model.AutomationSummary{Excluded: excluded, Bots: automation}
+	total := 0
+	for _, a := range t {
+		total += a.Commits
+	}
+	for _, b := range automation {
+		summary.Commits += b.Commits
+	}
+	if total > 0 {
+		summary.Ratio = float64(summary.Commits) / float64(total)
+	}
+	return summary
+}
+
+func sortAuthors(stats []model.AuthorStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Commits == stats[j].Commits {
+			return stats[i].Email < stats[j].Email
+		}
+		return stats[i].Commits > stats[j].Commits
+	})
+}
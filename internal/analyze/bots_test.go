@@ -0,0 +1,68 @@
+package analyze
+
+import (
+	"testing"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+func TestBotMatcher(t *testing.T) {
+	bots := NewBotMatcher([]string{"release-o-matic"}, true)
+	cases := []struct {
+		name, email string
+		want        bool
+	}{
+		{"dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", true},
+		{"GitHub", "noreply@github.com", true},
+		{"Release-O-Matic", "ci@example.com", true},
+		{"Jane", "1234+jane@users.noreply.github.com", false},
+	}
+	for _, tc := range cases {
+		if got := bots.IsBot(model.Commit{AuthorName: tc.name, AuthorEmail: tc.email}); got != tc.want {
+			t.Fatalf("IsBot(%s <%s>) = %t, want %t", tc.name, tc.email, got, tc.want)
+		}
+	}
+	var none *BotMatcher
+	if none.IsBot(model.Commit{AuthorName: "renovate[bot]"}) {
+		t.Fatalf("nil matcher should match nothing")
+	}
+}
+
+func TestBotsKeptOutOfMessageMetrics(t *testing.T) {
+	noon := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)
+	commits := []model.Commit{
+		{SHA: "b", AuthorName: "renovate[bot]", Date: noon.Add(time.Hour), Subject: "update"},
+		{SHA: "a", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: noon, Subject: "Add retry to fetcher"},
+	}
+	metrics, offenders := Analyze(commits, nil, nil, AnalyzeConfig{TZ: "commit", Bots: NewBotMatcher(nil, true)})
+	if metrics.Message.Total != 1 || metrics.Message.Generic != 0 {
+		t.Fatalf("expected bot excluded from message metrics, got %+v", metrics.Message)
+	}
+	if len(offenders) != 0 {
+		t.Fatalf("expected no message offenders for bots, got %+v", offenders)
+	}
+	humans, automation := Authors(commits, AnalyzeConfig{TZ: "commit", Bots: NewBotMatcher(nil, true)})
+	if len(humans) != 1 || len(automation) != 1 || automation[0].Name != "renovate[bot]" {
+		t.Fatalf("unexpected author split: %+v / %+v", humans, automation)
+	}
+}
+
+func TestAuthorsPerCommit(t *testing.T) {
+	noon := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)
+	commits := []model.Commit{
+		{SHA: "c", AuthorName: "ci-bot", AuthorEmail: "jane@example.com", Date: noon.Add(2 * time.Hour), Subject: "update"},
+		{SHA: "b", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: noon.Add(time.Hour), Subject: "wip", Body: "Roastgit-Ignore: generic-message, too-short"},
+		{SHA: "a", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: noon, Subject: "fix"},
+	}
+	humans, automation := Authors(commits, AnalyzeConfig{TZ: "commit", Bots: NewBotMatcher([]string{"ci-bot"}, false)})
+	if len(humans) != 1 || humans[0].Name != "Jane" || humans[0].Commits != 2 {
+		t.Fatalf("expected Jane's two commits on the human side, got %+v", humans)
+	}
+	if humans[0].LowQuality != 1 {
+		t.Fatalf("expected the exempted commit left out of low quality, got %d", humans[0].LowQuality)
+	}
+	if len(automation) != 1 || automation[0].Name != "ci-bot" || automation[0].Commits != 1 {
+		t.Fatalf("expected the bot commit on the automation side, got %+v", automation)
+	}
+}
@@ -0,0 +1,146 @@
+package analyze
+
+import (
+	"regexp"
+	"strings"
+
+	"roastgit/internal/model"
+)
+
+// IgnoreTrailer is the commit message trailer listing checks to skip, e.g.
+// "Roastgit-Ignore: huge-commit, binary".
+const IgnoreTrailer = "Roastgit-Ignore"
+
+// AllowRule exempts matching commits from checks. Every non-empty field must
+// match; an empty Checks list exempts the commit from all checks.
+type AllowRule struct {
+	// SHA matches by prefix.
+	SHA string
+	// Author matches the author name or email, case-insensitively.
+	Author  string
+	Subject *regexp.Regexp
+	Checks  []string
+}
+
+// Matches reports whether the rule applies to c.
+func (r AllowRule) Matches(c model.Commit) bool {
+	if r.SHA == "" && r.Author == "" && r.Subject == nil {
+		return false
+	}
+	if r.SHA != "" && !strings.HasPrefix(c.SHA, strings.ToLower(r.SHA)) {
+		return false
+	}
+	if r.Author != "" && !strings.EqualFold(r.Author, c.AuthorName) && !strings.EqualFold(r.Author, c.AuthorEmail) {
+		return false
+	}
+	if r.Subject != nil && !r.Subject.MatchString(c.Subject) {
+		return false
+	}
+	return true
+}
+
+// ParseIgnoreTrailer returns the check IDs listed in Roastgit-Ignore trailers.
+// As in git interpret-trailers, only the last paragraph of the body is a
+// trailer block, and only when all of its lines are trailers, or a quarter of
+// them are and one was written by git itself, like Signed-off-by.
+func ParseIgnoreTrailer(body string) []string {
+	ids := []string{}
+	for _, t := range trailers(body) {
+		key, value, _ := strings.Cut(t, ":")
+		if !strings.EqualFold(strings.TrimSpace(key), IgnoreTrailer) {
+			continue
+		}
+		for _, id := range strings.Split(value, ",") {
+			id = strings.ToLower(strings.TrimSpace(id))
+			if id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// gitTrailerPrefixes start the trailer lines git adds itself.
+var gitTrailerPrefixes = []string{"Signed-off-by: ", "(cherry picked from commit "}
+
+// trailers returns the trailers of a commit body, continuation lines joined
+// to the trailer they continue.
+func trailers(body string) []string {
+	lines := strings.Split(strings.TrimRight(body, " \t\r\n"), "\n")
+	start := len(lines)
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	lines = lines[start:]
+	var found []string
+	others, byGit := 0, false
+	inTrailer := false
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if line[0] == ' ' || line[0] == '\t' {
+			if inTrailer {
+				found[len(found)-1] += " " + strings.TrimSpace(line)
+			} else {
+				others++
+			}
+			continue
+		}
+		key, _, ok := strings.Cut(line, ":")
+		inTrailer = ok && isTrailerKey(strings.TrimRight(key, " \t"))
+		fromGit := false
+		for _, p := range gitTrailerPrefixes {
+			fromGit = fromGit || strings.HasPrefix(line, p)
+		}
+		byGit = byGit || fromGit
+		switch {
+		case inTrailer:
+			found = append(found, line)
+		case !fromGit:
+			others++
+		}
+	}
+	if others > 0 && !(byGit && (len(lines)-others)*4 >= len(lines)) {
+		return nil
+	}
+	return found
+}
+
+// isTrailerKey reports whether key is a trailer token: letters, digits and
+// hyphens.
+func isTrailerKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// exemptions collects the check IDs a commit is exempt from.
+func exemptions(c model.Commit, rules []AllowRule) map[string]bool {
+	var exempt map[string]bool
+	add := func(id string) {
+		if exempt == nil {
+			exempt = map[string]bool{}
+		}
+		exempt[id] = true
+	}
+	for _, id := range ParseIgnoreTrailer(c.Body) {
+		add(id)
+	}
+	for _, r := range rules {
+		if !r.Matches(c) {
+			continue
+		}
+		if len(r.Checks) == 0 {
+			add(CheckAll)
+		}
+		for _, id := range r.Checks {
+			add(id)
+		}
+	}
+	return exempt
+}
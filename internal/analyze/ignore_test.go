@@ -0,0 +1,37 @@
+package analyze
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseIgnoreTrailer(t *testing.T) {
+	cases := []struct {
+		body string
+		want []string
+	}{
+		{"Bump deps.\n\nRoastgit-Ignore: huge-commit, Binary\n", []string{"huge-commit", "binary"}},
+		{"Roastgit-Ignore: lockfile", []string{"lockfile"}},
+		{"Bump deps.\n\nRoastgit-Ignore: huge-commit,\n  binary\nReviewed-by: Jane <jane@example.com>", []string{"huge-commit", "binary"}},
+		// Not in the last paragraph.
+		{"Roastgit-Ignore: binary\n\nExplain the change.", []string{}},
+		// The last paragraph is prose, not a trailer block.
+		{"Explain the change.\nRoastgit-Ignore: binary", []string{}},
+		// A quarter of trailers is enough next to one git wrote.
+		{"Fix.\n\nSee the thread at\nhttps://example.com/issue/1\nRoastgit-Ignore: binary\nSigned-off-by: Jane <jane@example.com>", []string{"binary"}},
+		{"", []string{}},
+	}
+	for _, c := range cases {
+		if got := ParseIgnoreTrailer(c.body); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ParseIgnoreTrailer(%q) = %v, want %v", c.body, got, c.want)
+		}
+	}
+}
+
+func TestParseIgnoreTrailerLongLine(t *testing.T) {
+	body := "Paste the log.\n\n" + strings.Repeat("x", 100*1024) + "\n\nRoastgit-Ignore: too-long"
+	if got := ParseIgnoreTrailer(body); !reflect.DeepEqual(got, []string{"too-long"}) {
+		t.Fatalf("expected the trailer after a long line, got %v", got)
+	}
+}
@@ -1,18 +1,21 @@
 package analyze
 
 import (
+	"sort"
 	"strings"
 
+	"roastgit/internal/model"
 	"roastgit/internal/util"
 )
 
 type MessageInfo struct {
-	Generic    bool
-	EmojiOnly  bool
-	TooLong    bool
-	TooShort   bool
-	GenericKey string
-	Score      int
+	Generic    bool   `json:"generic,omitempty"`
+	EmojiOnly  bool   `json:"emoji_only,omitempty"`
+	TooLong    bool   `json:"too_long,omitempty"`
+	TooShort   bool   `json:"too_short,omitempty"`
+	Fixup      bool   `json:"fixup,omitempty"`
+	GenericKey string `json:"generic_key,omitempty"`
+	Score      int    `json:"score"`
 }
 
 var genericWords = []string{
@@ -38,6 +41,7 @@ func AnalyzeMessage(subject string) MessageInfo {
 	info.EmojiOnly = util.IsEmojiOnly(trim)
 	info.TooLong = len([]rune(trim)) > 72
 	info.TooShort = len([]rune(trim)) <= 4
+	info.Fixup = isFixupSubject(lower)
 	if len(words) > 0 {
 		if _, ok := genericSet[words[0]]; ok && len([]rune(trim)) <= 20 {
 			info.Generic = true
@@ -52,6 +56,17 @@ func AnalyzeMessage(subject string) MessageInfo {
 	return info
 }
 
+// isFixupSubject matches the subjects git commit --fixup/--squash write,
+// which are meant to be autosquashed before anything is shared.
+func isFixupSubject(lower string) bool {
+	for _, prefix := range []string{"fixup!", "squash!", "amend!"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func scoreMessage(info MessageInfo) int {
 	score := 100
 	if info.Generic {
@@ -80,3 +95,99 @@ func IsLyingMessage(subject string, linesChanged int, filesChanged int) bool {
 	}
 	return false
 }
+
+// MessageCheck is the result of checking a single pending commit message.
+type MessageCheck struct {
+	Info     MessageInfo
+	Reasons  []string
+	Exempted []string
+}
+
+// CheckMessage runs the message checks on one commit message, honoring its
+// Roastgit-Ignore trailer. size may be nil when the change size is unknown,
+// which skips the lying-message check.
+func CheckMessage(subject, body string, size *model.CommitSize) MessageCheck {
+	f := commitFlags{exempt: exemptions(model.Commit{Subject: subject, Body: body}, nil)}
+	info := AnalyzeMessage(subject)
+	info.Generic = f.allow(CheckGeneric, info.Generic)
+	info.EmojiOnly = f.allow(CheckEmojiOnly, info.EmojiOnly)
+	info.TooLong = f.allow(CheckTooLong, info.TooLong)
+	info.TooShort = f.allow(CheckTooShort, info.TooShort)
+	// Fixup commits are expected locally; they only matter once pushed.
+	info.Fixup = false
+	info.Score = scoreMessage(info)
+	f.judge(Checks, &CommitFacts{Commit: model.Commit{Subject: subject, Body: body, Size: size}, message: &info})
+	check := MessageCheck{Info: info, Reasons: []string{}, Exempted: []string{}}
+	for _, h := range f.hits {
+		check.Reasons = append(check.Reasons, h.Reason)
+	}
+	for id := range f.exempt {
+		check.Exempted = append(check.Exempted, id)
+	}
+	sort.Strings(check.Exempted)
+	return check
+}
+
+// MessageCleanup is how git cleans up a commit message, as read from
+// core.commentChar and commit.cleanup. The zero value is git's default:
+// "#" comments, stripped.
+type MessageCleanup struct {
+	// CommentChar starts comment lines; "auto" is the character git picked,
+	// taken from the comment block it appends to the message.
+	CommentChar string
+	// Mode is commit.cleanup: "strip", "whitespace", "verbatim", "scissors"
+	// or "default".
+	Mode string
+}
+
+// autoCommentChars are the characters git picks from for
+// core.commentChar=auto, in order.
+const autoCommentChars = "#;@!$%^&|:"
+
+// comment returns the prefix of comment lines in lines.
+func (mc MessageCleanup) comment(lines []string) string {
+	switch mc.CommentChar {
+	case "":
+		return "#"
+	case "auto":
+		// git appends its comment block after the message, so the last
+		// non-blank line starts with the character it picked.
+		for i := len(lines) - 1; i >= 0; i-- {
+			if line := strings.TrimSpace(lines[i]); line != "" {
+				if strings.ContainsRune(autoCommentChars, rune(line[0])) {
+					return line[:1]
+				}
+				break
+			}
+		}
+		return "#"
+	}
+	return mc.CommentChar
+}
+
+// SplitMessage extracts the subject and body from a raw commit message file
+// as git passes it to the commit-msg hook: everything below the scissors
+// line is ignored, and comment lines are dropped unless cleanup keeps them.
+func SplitMessage(raw string, cleanup MessageCleanup) (string, string) {
+	all := strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n")
+	// git only writes a scissors line when it cuts there, whatever the mode.
+	strip := true
+	switch cleanup.Mode {
+	case "verbatim", "whitespace", "scissors":
+		strip = false
+	}
+	comment := cleanup.comment(all)
+	lines := []string{}
+	for _, line := range all {
+		if strings.HasPrefix(line, comment+" ") && strings.Contains(line, ">8") {
+			break
+		}
+		if strip && strings.HasPrefix(line, comment) {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	text := strings.TrimSpace(strings.Join(lines, "\n"))
+	subject, body, _ := strings.Cut(text, "\n")
+	return strings.TrimSpace(subject), strings.TrimSpace(body)
+}
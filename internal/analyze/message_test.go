@@ -1,6 +1,10 @@
 package analyze
 
-import "testing"
+import (
+	"testing"
+
+	"roastgit/internal/model"
+)
 
 func TestAnalyzeMessageGeneric(t *testing.T) {
 	info := AnalyzeMessage("fix")
@@ -27,6 +31,15 @@ func TestAnalyzeMessageTooLong(t *testing.T) {
 	}
 }
 
+func TestAnalyzeMessageFixup(t *testing.T) {
+	if !AnalyzeMessage("fixup! Add parser for logs").Fixup {
+		t.Fatalf("expected fixup message")
+	}
+	if AnalyzeMessage("Fix parser crash on fixup! subjects").Fixup {
+		t.Fatalf("expected prefix-only fixup detection")
+	}
+}
+
 func TestIsLyingMessage(t *testing.T) {
 	if !IsLyingMessage("minor tweak", 500, 12) {
 		t.Fatalf("expected lying message")
@@ -35,3 +48,39 @@ func TestIsLyingMessage(t *testing.T) {
 		t.Fatalf("expected not lying")
 	}
 }
+
+func TestSplitMessage(t *testing.T) {
+	raw := "fix\n\nLonger explanation.\n\nRoastgit-Ignore: too-short\n# Please enter the commit message\n# ------------------------ >8 ------------------------\ndiff --git a/x b/x\n"
+	subject, body := SplitMessage(raw, MessageCleanup{})
+	if subject != "fix" || body != "Longer explanation.\n\nRoastgit-Ignore: too-short" {
+		t.Fatalf("unexpected split: %q / %q", subject, body)
+	}
+	check := CheckMessage(subject, body, &model.CommitSize{Files: 12, Added: 600})
+	if len(check.Reasons) != 1 || check.Reasons[0] != "generic message" {
+		t.Fatalf("unexpected reasons: %v", check.Reasons)
+	}
+	if check.Info.Score != 60 || len(check.Exempted) != 1 {
+		t.Fatalf("expected too-short exempted from score, got %+v", check)
+	}
+}
+
+func TestSplitMessageCleanup(t *testing.T) {
+	cases := []struct {
+		raw     string
+		cleanup MessageCleanup
+		body    string
+	}{
+		// Markdown headings survive when git comments with another character.
+		{"Add parser\n\n# Why\nBecause.\n; Please enter the commit message\n", MessageCleanup{CommentChar: ";"}, "# Why\nBecause."},
+		{"Add parser\n\n# Why\nBecause.\n; Please enter the commit message\n", MessageCleanup{CommentChar: "auto"}, "# Why\nBecause."},
+		{"Add parser\n\n# Why\nBecause.\n", MessageCleanup{Mode: "verbatim"}, "# Why\nBecause."},
+		{"Add parser\n\n# Why\n# ------------------------ >8 ------------------------\ndiff --git a/x b/x\n", MessageCleanup{Mode: "scissors"}, "# Why"},
+		{"Add parser\n\n# Why\nBecause.\n", MessageCleanup{}, "Because."},
+	}
+	for _, c := range cases {
+		subject, body := SplitMessage(c.raw, c.cleanup)
+		if subject != "Add parser" || body != c.body {
+			t.Errorf("SplitMessage(%q, %+v) = %q / %q, want body %q", c.raw, c.cleanup, subject, body, c.body)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package analyze
+
+import (
+	"sort"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+// Check IDs. CheckAll is only meaningful in exemptions.
+const (
+	CheckGeneric   = "generic-message"
+	CheckEmojiOnly = "emoji-only"
+	CheckTooLong   = "too-long"
+	CheckTooShort  = "too-short"
+	CheckFixup     = "fixup"
+	CheckLying     = "lying"
+	CheckPanic     = "panic"
+	CheckHuge      = "huge-commit"
+	CheckBinary    = "binary"
+	CheckMidnight  = "midnight"
+	CheckDeadline  = "deadline"
+	CheckAll       = "all"
+)
+
+// Checks lists the built-in rules in report order. Like any other rule they
+// judge a commit from its CommitFacts alone; whoever runs them applies the
+// commit's exemptions.
+var Checks = []Rule{
+	NewRule(CheckGeneric, "generic message", CategoryMessage, 8, func(c *CommitFacts) bool { return c.messageInfo().Generic }),
+	NewRule(CheckEmojiOnly, "emoji-only message", CategoryMessage, 7, func(c *CommitFacts) bool { return c.messageInfo().EmojiOnly }),
+	NewRule(CheckTooLong, "too long", CategoryMessage, 3, func(c *CommitFacts) bool { return c.messageInfo().TooLong }),
+	NewRule(CheckTooShort, "too short", CategoryMessage, 3, func(c *CommitFacts) bool { return c.messageInfo().TooShort }),
+	NewRule(CheckFixup, "fixup commit", CategoryMessage, 6, func(c *CommitFacts) bool { return c.messageInfo().Fixup }),
+	NewRule(CheckLying, "lying message", CategoryMessage, 9, func(c *CommitFacts) bool {
+		size := c.judgedSize()
+		return size != nil && !c.Bot && IsLyingMessage(c.Commit.Subject, size.Added+size.Deleted, size.Files)
+	}),
+	NewRule(CheckPanic, "panic streak", CategoryTime, 6, func(c *CommitFacts) bool { return c.Panic }),
+	NewRule(CheckHuge, "huge commit", CategorySize, 7, func(c *CommitFacts) bool {
+		size := c.judgedSize()
+		return size != nil && isHuge(size.Added+size.Deleted, size.Files)
+	}),
+	NewRule(CheckBinary, "binary blobs", CategorySize, 5, func(c *CommitFacts) bool {
+		size := c.judgedSize()
+		return size != nil && size.BinaryFiles > 0
+	}),
+	NewRule(CheckMidnight, "midnight gremlin", CategoryTime, 2, func(c *CommitFacts) bool {
+		return !c.Local.IsZero() && c.Local.Hour() < 5
+	}),
+	NewRule(CheckDeadline, "deadline scramble", CategoryTime, 2, func(c *CommitFacts) bool {
+		return !c.Local.IsZero() && isDeadlineTime(c.Local)
+	}),
+}
+
+// LookupCheck finds a built-in, registered or custom rule by ID or by
+// reason text.
+func LookupCheck(name string, custom ...Rule) (Rule, bool) {
+	return lookupRule(name, Rules(custom...))
+}
+
+// reasonWeight is the weight of a reason recorded without one, found by
+// the check that made it when known, else by the reason text.
+func reasonWeight(reason, check string, custom []Rule) int {
+	name := reason
+	if check != "" {
+		name = check
+	}
+	if r, ok := LookupCheck(name, custom...); ok {
+		return r.Weight()
+	}
+	return 0
+}
+
+// hit is a finding together with the rule that made it.
+type hit struct {
+	check string
+	Finding
+}
+
+// judge runs rules over one commit and records the findings that survive
+// its exemptions.
+func (f *commitFlags) judge(rules []Rule, facts *CommitFacts) {
+	for _, r := range rules {
+		for _, finding := range r.Evaluate(facts) {
+			if f.allow(r.ID(), true) {
+				f.hits = append(f.hits, hit{check: r.ID(), Finding: finding})
+			}
+		}
+	}
+}
+
+// buildOffenders returns every flagged commit, worst first.
+func buildOffenders(commits []model.Commit, flags []commitFlags) []model.Offender {
+	offenders := []model.Offender{}
+	for i, c := range commits {
+		if len(flags[i].hits) == 0 {
+			continue
+		}
+		off := model.Offender{
+			SHA:     c.SHA,
+			Subject: c.Subject,
+			Date:    c.Date.Format(time.RFC3339),
+			Reasons: []string{},
+			Author:  c.AuthorName,
+		}
+		for _, h := range flags[i].hits {
+			off.Reasons = append(off.Reasons, h.Reason)
+			off.Checks = append(off.Checks, h.check)
+			off.Weights = append(off.Weights, h.Weight)
+			off.Score += h.Weight
+		}
+		offenders = append(offenders, off)
+	}
+	sortOffenders(offenders)
+	return offenders
+}
+
+func sortOffenders(offenders []model.Offender) {
+	sort.SliceStable(offenders, func(i, j int) bool {
+		if offenders[i].Score == offenders[j].Score {
+			ti, _ := time.Parse(time.RFC3339, offenders[i].Date)
+			tj, _ := time.Parse(time.RFC3339, offenders[j].Date)
+			if ti.Equal(tj) {
+				return offenders[i].SHA < offenders[j].SHA
+			}
+			return ti.After(tj)
+		}
+		return offenders[i].Score > offenders[j].Score
+	})
+}
+
+// TopOffenders returns at most limit offenders from an already sorted list.
+func TopOffenders(offenders []model.Offender, limit int) []model.Offender {
+	if len(offenders) <= limit {
+		return offenders
+	}
+	return offenders[:limit]
+}
+
+// SuppressOffenders drops the reasons recorded for each SHA in known, as
+// loaded from a baseline file. Offenders left without reasons are removed and
+// the rest are rescored; reasons recorded without weights, as in reports from
+// older releases, are weighed by their rule among the built-in, registered
+// and custom ones. It returns the remaining offenders and the number of
+// offenders removed entirely.
+func SuppressOffenders(offenders []model.Offender, known map[string][]string, custom ...Rule) ([]model.Offender, int) {
+	kept := make([]model.Offender, 0, len(offenders))
+	suppressed := 0
+	for _, off := range offenders {
+		recorded, ok := known[off.SHA]
+		if !ok {
+			kept = append(kept, off)
+			continue
+		}
+		skip := map[string]struct{}{}
+		for _, r := range recorded {
+			skip[r] = struct{}{}
+		}
+		rest := model.Offender{SHA: off.SHA, Subject: off.Subject, Date: off.Date, Reasons: []string{}, Author: off.Author}
+		for i, r := range off.Reasons {
+			if _, ok := skip[r]; ok {
+				continue
+			}
+			check := ""
+			if len(off.Checks) == len(off.Reasons) {
+				check = off.Checks[i]
+				rest.Checks = append(rest.Checks, check)
+			}
+			var weight int
+			if len(off.Weights) == len(off.Reasons) {
+				weight = off.Weights[i]
+				rest.Weights = append(rest.Weights, weight)
+			} else {
+				weight = reasonWeight(r, check, custom)
+			}
+			rest.Reasons = append(rest.Reasons, r)
+			rest.Score += weight
+		}
+		if len(rest.Reasons) == 0 {
+			suppressed++
+			continue
+		}
+		kept = append(kept, rest)
+	}
+	sortOffenders(kept)
+	return kept, suppressed
+}
@@ -0,0 +1,53 @@
+package analyze
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+func TestSuppressOffendersKeepsNewReasons(t *testing.T) {
+	offenders := []model.Offender{
+		{SHA: "a", Date: "2024-01-02T00:00:00Z", Reasons: []string{"generic message", "huge commit"}, Score: 15},
+		{SHA: "b", Date: "2024-01-01T00:00:00Z", Reasons: []string{"too short"}, Score: 3},
+	}
+	known := map[string][]string{
+		"a": {"generic message"},
+		"b": {"too short"},
+	}
+	kept, suppressed := SuppressOffenders(offenders, known)
+	if suppressed != 1 || len(kept) != 1 {
+		t.Fatalf("expected one suppressed and one kept, got %d and %+v", suppressed, kept)
+	}
+	if kept[0].SHA != "a" || len(kept[0].Reasons) != 1 || kept[0].Reasons[0] != "huge commit" || kept[0].Score != 7 {
+		t.Fatalf("unexpected remaining offender: %+v", kept[0])
+	}
+}
+
+func TestAnalyzeHonorsExemptions(t *testing.T) {
+	base := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)
+	commits := []model.Commit{
+		{SHA: "b", Date: base.Add(time.Hour), Subject: "update", Body: "Bump vendored deps.\n\nRoastgit-Ignore: huge-commit, binary"},
+		{SHA: "a", Date: base, Subject: "Initial import"},
+	}
+	sizes := map[string]model.CommitSize{
+		"b": {Files: 40, Added: 5000, BinaryFiles: 2},
+		"a": {Files: 300, Added: 90000},
+	}
+	allow := []AllowRule{{Subject: regexp.MustCompile(`^Initial import`)}}
+	metrics, offenders := Analyze(commits, sizes, nil, AnalyzeConfig{TZ: "commit", Allow: allow})
+	if metrics.Size.LargeCommitCount != 0 || metrics.Size.BinaryCommitCount != 0 {
+		t.Fatalf("expected exempted size checks, got %+v", metrics.Size)
+	}
+	if metrics.Message.Generic != 1 {
+		t.Fatalf("expected generic message to still count, got %d", metrics.Message.Generic)
+	}
+	if metrics.Exemptions.Commits != 2 || metrics.Exemptions.Checks != 3 {
+		t.Fatalf("unexpected exemption counts: %+v", metrics.Exemptions)
+	}
+	if len(offenders) != 1 || offenders[0].SHA != "b" || len(offenders[0].Reasons) != 1 {
+		t.Fatalf("unexpected offenders: %+v", offenders)
+	}
+}
@@ -0,0 +1,240 @@
+package analyze
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+// Rule categories. Custom rules may use their own.
+const (
+	CategoryMessage = "message"
+	CategorySize    = "size"
+	CategoryTime    = "time"
+	CategoryCustom  = "custom"
+)
+
+// Rule is one offender check. Built-in rules are listed in Checks; teams add
+// their own with Register, AnalyzeConfig.Rules or the config file.
+type Rule interface {
+	// ID names the rule in --fail-on, allowlists and Roastgit-Ignore
+	// trailers.
+	ID() string
+	// Description is the offender reason shown in reports.
+	Description() string
+	Category() string
+	// Weight is the score of a finding, added to the offender's score.
+	Weight() int
+	// Evaluate returns the findings for one commit, none when it passes.
+	// It is called from one goroutine at a time.
+	Evaluate(c *CommitFacts) []Finding
+}
+
+// FileRule is a Rule that reads CommitFacts.Files. Per-file stats are only
+// loaded when some rule asks for them.
+type FileRule interface {
+	Rule
+	NeedsFiles() bool
+}
+
+// FallibleRule is a Rule that can fail while evaluating, like a script with
+// a bug. A failed rule flags nothing more; Err reports why.
+type FallibleRule interface {
+	Rule
+	Err() error
+}
+
+// Finding is one reason a rule flags a commit for, with its score.
+type Finding struct {
+	Reason string
+	Weight int
+}
+
+// CommitFacts is what rules see of one commit.
+type CommitFacts struct {
+	// Commit has Size set when the commit's numstat was read: for every
+	// commit with --deep, for a sample otherwise.
+	Commit model.Commit
+	// Files is the per-file numstat, when a FileRule asked for it and the
+	// commit has a size.
+	Files []model.FileStat
+	// Local is the commit date in the report's time zone. Time checks skip
+	// commits without one.
+	Local time.Time
+	// Bot marks automation commits, whose messages are not judged.
+	Bot bool
+	// Panic marks a commit inside a panic window: an hour of 4+ commits, 3+
+	// of them with low-quality messages. It depends on the surrounding
+	// history, so Analyze works it out before running the rules.
+	Panic bool
+
+	// message holds precomputed message checks, e.g. from the cache.
+	message *MessageInfo
+}
+
+// messageInfo returns the message checks of the commit, none for bots.
+func (c *CommitFacts) messageInfo() MessageInfo {
+	switch {
+	case c.Bot:
+		return MessageInfo{}
+	case c.message != nil:
+		return *c.message
+	}
+	return AnalyzeMessage(c.Commit.Subject)
+}
+
+// judgedSize returns the size of the commit when size checks apply to it:
+// it has one, and it is not a bare submodule pointer bump, which has no
+// lines of its own to judge.
+func (c *CommitFacts) judgedSize() *model.CommitSize {
+	if c.Commit.Size == nil || c.Commit.Size.SubmoduleBump() {
+		return nil
+	}
+	return c.Commit.Size
+}
+
+// funcRule is a Rule flagging the commits match accepts.
+type funcRule struct {
+	id, description, category string
+	weight                    int
+	match                     func(*CommitFacts) bool
+	files                     bool
+}
+
+func (r funcRule) ID() string          { return r.id }
+func (r funcRule) Description() string { return r.description }
+func (r funcRule) Category() string    { return r.category }
+func (r funcRule) Weight() int         { return r.weight }
+func (r funcRule) NeedsFiles() bool    { return r.files }
+
+func (r funcRule) Evaluate(c *CommitFacts) []Finding {
+	if !r.match(c) {
+		return nil
+	}
+	return []Finding{{Reason: r.description, Weight: r.weight}}
+}
+
+// NewRule returns a rule that flags the commits match accepts, with its
+// description as the reason.
+func NewRule(id, description, category string, weight int, match func(*CommitFacts) bool) Rule {
+	return funcRule{id: id, description: description, category: category, weight: weight, match: match}
+}
+
+// Pattern matches commits declaratively. Every condition set must hold;
+// size conditions never match commits without a size.
+type Pattern struct {
+	Subject *regexp.Regexp
+	// Author matches the author name or email.
+	Author *regexp.Regexp
+	// Path matches when any changed file's path matches.
+	Path               *regexp.Regexp
+	MinLines, MaxLines *int
+	MinFiles, MaxFiles *int
+}
+
+// Matches reports whether c meets every condition of p.
+func (p Pattern) Matches(c *CommitFacts) bool {
+	if p.Subject != nil && !p.Subject.MatchString(c.Commit.Subject) {
+		return false
+	}
+	if p.Author != nil && !p.Author.MatchString(c.Commit.AuthorName) && !p.Author.MatchString(c.Commit.AuthorEmail) {
+		return false
+	}
+	if p.Path != nil {
+		found := false
+		for _, f := range c.Files {
+			if p.Path.MatchString(f.Path) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if p.MinLines != nil || p.MaxLines != nil || p.MinFiles != nil || p.MaxFiles != nil {
+		size := c.Commit.Size
+		if size == nil {
+			return false
+		}
+		lines := size.Added + size.Deleted
+		if !within(lines, p.MinLines, p.MaxLines) || !within(size.Files, p.MinFiles, p.MaxFiles) {
+			return false
+		}
+	}
+	return true
+}
+
+func within(n int, lo, hi *int) bool {
+	return (lo == nil || n >= *lo) && (hi == nil || n <= *hi)
+}
+
+// PatternRule returns a rule that flags the commits p matches.
+func PatternRule(id, description, category string, weight int, p Pattern) Rule {
+	return funcRule{id: id, description: description, category: category, weight: weight, match: p.Matches, files: p.Path != nil}
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []Rule
+)
+
+// Register adds rules to every analysis, after the built-in ones. It is
+// meant to be called from init functions and panics when an ID is taken.
+func Register(rules ...Rule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, r := range rules {
+		_, builtin := lookupRule(r.ID(), Checks)
+		if _, ok := lookupRule(r.ID(), registry); ok || builtin || r.ID() == CheckAll {
+			panic(fmt.Sprintf("analyze: rule ID %q is taken", r.ID()))
+		}
+		registry = append(registry, r)
+	}
+}
+
+// Rules lists the built-in rules, the registered ones and then custom, in
+// report order.
+func Rules(custom ...Rule) []Rule {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]Rule, 0, len(Checks)+len(registry)+len(custom))
+	out = append(out, Checks...)
+	out = append(out, registry...)
+	return append(out, custom...)
+}
+
+// NeedsFiles reports whether any of rules reads CommitFacts.Files.
+func NeedsFiles(rules []Rule) bool {
+	for _, r := range rules {
+		if fr, ok := r.(FileRule); ok && fr.NeedsFiles() {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleErr returns the first failure of any of rules, so a report never
+// silently misses a broken rule's findings.
+func RuleErr(rules []Rule) error {
+	for _, r := range rules {
+		if fr, ok := r.(FallibleRule); ok {
+			if err := fr.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func lookupRule(name string, rules []Rule) (Rule, bool) {
+	for _, r := range rules {
+		if r.ID() == name || r.Description() == name {
+			return r, true
+		}
+	}
+	return nil, false
+}
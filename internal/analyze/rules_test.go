@@ -0,0 +1,124 @@
+package analyze
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+func TestAnalyzeCustomRules(t *testing.T) {
+	base := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)
+	commits := []model.Commit{
+		{SHA: "c", Date: base.Add(2 * time.Hour), Subject: "update", AuthorName: "Jane"},
+		{SHA: "b", Date: base.Add(time.Hour), Subject: "Regenerate the lockfile after the merge", Body: "Roastgit-Ignore: lockfile"},
+		{SHA: "a", Date: base, Subject: "Pin the lockfile by hand"},
+	}
+	sizes := map[string]model.CommitSize{
+		"c": {Files: 1, Added: 3},
+		"b": {Files: 1, Added: 3},
+		"a": {Files: 1, Added: 3},
+	}
+	lockfile := PatternRule("lockfile", "hand-edited lockfile", CategoryCustom, 4, Pattern{Path: regexp.MustCompile(`\.lock$`)})
+	jane := NewRule("jane", "jane was here", "people", 1, func(c *CommitFacts) bool {
+		return c.Commit.AuthorName == "Jane" && c.Commit.Size != nil && !c.Bot
+	})
+	cfg := AnalyzeConfig{
+		TZ:    "commit",
+		Rules: []Rule{lockfile, jane},
+		Files: map[string][]model.FileStat{
+			"c": {{Path: "go.lock", Added: 3}},
+			"b": {{Path: "go.lock", Added: 3}},
+			"a": {{Path: "yarn.lock", Added: 3}},
+		},
+	}
+	metrics, offenders := Analyze(commits, sizes, nil, cfg)
+	if metrics.Exemptions.Commits != 1 || metrics.Exemptions.Checks != 1 {
+		t.Fatalf("expected the trailer to exempt b, got %+v", metrics.Exemptions)
+	}
+	if len(offenders) != 2 {
+		t.Fatalf("expected 2 offenders, got %+v", offenders)
+	}
+	c := offenders[0]
+	if c.SHA != "c" || c.Score != 8+4+1 {
+		t.Fatalf("unexpected worst offender: %+v", c)
+	}
+	if want := []string{"generic message", "hand-edited lockfile", "jane was here"}; !reflect.DeepEqual(c.Reasons, want) {
+		t.Fatalf("reasons = %v, want built-in ones first, then custom ones in order", c.Reasons)
+	}
+	if want := []string{CheckGeneric, "lockfile", "jane"}; !reflect.DeepEqual(c.Checks, want) {
+		t.Fatalf("checks = %v, want %v", c.Checks, want)
+	}
+
+	// A baseline that knew the generic message leaves the custom weights.
+	kept, _ := SuppressOffenders(offenders, map[string][]string{"c": {"generic message"}})
+	if kept[0].SHA != "c" || kept[0].Score != 5 || !reflect.DeepEqual(kept[0].Checks, []string{"lockfile", "jane"}) {
+		t.Fatalf("unexpected offender after suppression: %+v", kept[0])
+	}
+}
+
+// TestChecksWithoutAnalyze runs the built-in rules on facts built by hand,
+// as a library caller would, without the context Analyze fills in.
+func TestChecksWithoutAnalyze(t *testing.T) {
+	friday := time.Date(2024, 3, 1, 16, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		check string
+		facts CommitFacts
+		want  bool
+	}{
+		{CheckGeneric, CommitFacts{Commit: model.Commit{Subject: "update"}}, true},
+		{CheckGeneric, CommitFacts{Commit: model.Commit{Subject: "update"}, Bot: true}, false},
+		{CheckEmojiOnly, CommitFacts{Commit: model.Commit{Subject: "🔥🔥"}}, true},
+		{CheckTooShort, CommitFacts{Commit: model.Commit{Subject: "x"}}, true},
+		{CheckFixup, CommitFacts{Commit: model.Commit{Subject: "fixup! Add the parser"}}, true},
+		{CheckLying, CommitFacts{Commit: model.Commit{Subject: "Small cleanup", Size: &model.CommitSize{Files: 30, Added: 2000}}}, true},
+		{CheckLying, CommitFacts{Commit: model.Commit{Subject: "Small cleanup"}}, false},
+		{CheckHuge, CommitFacts{Commit: model.Commit{Subject: "Add the parser", Size: &model.CommitSize{Files: 3, Added: 900}}}, true},
+		{CheckHuge, CommitFacts{Commit: model.Commit{Subject: "Bump the lib", Size: &model.CommitSize{Submodules: 1}}}, false},
+		{CheckBinary, CommitFacts{Commit: model.Commit{Subject: "Add the logo", Size: &model.CommitSize{Files: 1, BinaryFiles: 1}}}, true},
+		{CheckMidnight, CommitFacts{Commit: model.Commit{Subject: "Add the parser"}, Local: friday.Add(-14 * time.Hour)}, true},
+		{CheckMidnight, CommitFacts{Commit: model.Commit{Subject: "Add the parser"}}, false},
+		{CheckDeadline, CommitFacts{Commit: model.Commit{Subject: "Add the parser"}, Local: friday}, true},
+		{CheckPanic, CommitFacts{Commit: model.Commit{Subject: "Add the parser"}, Panic: true}, true},
+		{CheckPanic, CommitFacts{Commit: model.Commit{Subject: "Add the parser"}}, false},
+	} {
+		r, ok := LookupCheck(tc.check)
+		if !ok {
+			t.Fatalf("no rule %s", tc.check)
+		}
+		if got := len(r.Evaluate(&tc.facts)) > 0; got != tc.want {
+			t.Errorf("%s(%+v) = %v, want %v", tc.check, tc.facts.Commit, got, tc.want)
+		}
+	}
+}
+
+func TestPatternSizeBounds(t *testing.T) {
+	min, max := 10, 100
+	p := Pattern{Subject: regexp.MustCompile(`(?i)^revert`), MinLines: &min, MaxLines: &max}
+	for _, tc := range []struct {
+		subject string
+		size    *model.CommitSize
+		want    bool
+	}{
+		{"Revert the parser", &model.CommitSize{Added: 5, Deleted: 5}, true},
+		{"Revert the parser", &model.CommitSize{Added: 100, Deleted: 1}, false},
+		{"Revert the parser", nil, false},
+		{"Add the parser", &model.CommitSize{Added: 50}, false},
+	} {
+		c := &CommitFacts{Commit: model.Commit{Subject: tc.subject, Size: tc.size}}
+		if got := p.Matches(c); got != tc.want {
+			t.Errorf("Matches(%q, %+v) = %v, want %v", tc.subject, tc.size, got, tc.want)
+		}
+	}
+}
+
+func TestRegisterRejectsTakenID(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for a built-in ID")
+		}
+	}()
+	Register(NewRule(CheckHuge, "mine now", CategoryCustom, 1, nil))
+}
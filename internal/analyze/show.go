@@ -0,0 +1,203 @@
+package analyze
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+// fixChainGap is the longest pause between a commit and a fix that follows it
+// for the two to count as one fix chain.
+const fixChainGap = time.Hour
+
+// InspectCommit explains how the commit sha fares against every check.
+// commits must contain it and should include its neighbours, newest first as
+// git log lists them, so panic windows and fix chains around it can be found.
+// files is the commit's per-file numstat. ok is false when sha is not in
+// commits.
+func InspectCommit(commits []model.Commit, sha string, files []model.FileStat, cfg AnalyzeConfig) (detail model.CommitDetail, ok bool) {
+	idx := -1
+	for i, c := range commits {
+		if c.SHA == sha {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return model.CommitDetail{}, false
+	}
+	c := commits[idx]
+	size := sizeOf(files)
+	if NeedsFiles(Rules(cfg.Rules...)) {
+		cfg.Files = map[string][]model.FileStat{sha: files}
+	}
+	_, offenders := Analyze(append([]model.Commit(nil), commits...), map[string]model.CommitSize{sha: size}, nil, cfg)
+
+	local := cfg.localTime(c.Date)
+	lines := size.Added + size.Deleted
+	detail = model.CommitDetail{
+		SHA:     c.SHA,
+		Author:  c.AuthorName,
+		Email:   c.AuthorEmail,
+		Date:    c.Date.Format(time.RFC3339),
+		Subject: c.Subject,
+		Body:    c.Body,
+		Bot:     cfg.Bots.IsBot(c),
+		Merge:   len(c.Parents) > 1,
+		Size: model.CommitSizeDetail{
+			Files:       size.Files,
+			Added:       size.Added,
+			Deleted:     size.Deleted,
+			BinaryFiles: size.BinaryFiles,
+			Submodules:  size.Submodules,
+			Huge:        isHuge(lines, size.Files),
+			PerFile:     files,
+		},
+		Time: model.CommitTimeDetail{
+			Local:    local.Format(time.RFC3339),
+			Hour:     local.Hour(),
+			Weekday:  local.Weekday().String(),
+			Midnight: local.Hour() < 5,
+			Deadline: isDeadlineTime(local),
+		},
+		FixChain: []model.ChainCommit{},
+		Reasons:  []string{},
+		Exempted: []string{},
+	}
+	if !detail.Bot {
+		info := AnalyzeMessage(c.Subject)
+		detail.Message = model.CommitMessageDetail{
+			Score:     info.Score,
+			Generic:   info.Generic,
+			EmojiOnly: info.EmojiOnly,
+			TooLong:   info.TooLong,
+			TooShort:  info.TooShort,
+			Fixup:     info.Fixup,
+			Lying:     IsLyingMessage(c.Subject, lines, size.Files),
+		}
+	}
+	for _, off := range offenders {
+		if off.SHA == sha {
+			detail.Reasons = off.Reasons
+			break
+		}
+	}
+	for id := range exemptions(c, cfg.Allow) {
+		detail.Exempted = append(detail.Exempted, id)
+	}
+	sort.Strings(detail.Exempted)
+
+	timesAsc, idxAsc := orderTimesAsc(commits, cfg.localTime)
+	low := make([]bool, len(idxAsc))
+	pos := 0
+	for i, orig := range idxAsc {
+		low[i] = lowQualityMessage(commits[orig], cfg)
+		if orig == idx {
+			pos = i
+		}
+	}
+	for _, in := range panicWindows(timesAsc, low) {
+		if pos < in[0] || pos > in[1] {
+			continue
+		}
+		window := &model.PanicWindow{
+			Start:   timesAsc[in[0]].Format(time.RFC3339),
+			End:     timesAsc[in[1]].Format(time.RFC3339),
+			Commits: in[1] - in[0] + 1,
+		}
+		for i := in[0]; i <= in[1]; i++ {
+			if low[i] {
+				window.LowQuality++
+			}
+		}
+		detail.PanicWindow = window
+	}
+	for _, link := range fixChain(commits, idx) {
+		detail.FixChain = append(detail.FixChain, model.ChainCommit{
+			SHA:     link.SHA,
+			Subject: link.Subject,
+			Date:    link.Date.Format(time.RFC3339),
+		})
+	}
+	return detail, true
+}
+
+// lowQualityMessage mirrors the low-quality test Analyze uses for panic
+// detection, exemptions included.
+func lowQualityMessage(c model.Commit, cfg AnalyzeConfig) bool {
+	if cfg.Bots.IsBot(c) {
+		return false
+	}
+	f := commitFlags{exempt: exemptions(c, cfg.Allow)}
+	info := AnalyzeMessage(c.Subject)
+	return f.allow(CheckGeneric, info.Generic) ||
+		f.allow(CheckEmojiOnly, info.EmojiOnly) ||
+		f.allow(CheckTooLong, info.TooLong) ||
+		f.allow(CheckTooShort, info.TooShort)
+}
+
+// fixChain returns the run of commits by the same author around commits[idx]
+// in which each commit is a quick fix of the one before, oldest first. It
+// returns nil when commits[idx] is neither fixed nor a fix.
+func fixChain(commits []model.Commit, idx int) []model.Commit {
+	email := strings.ToLower(commits[idx].AuthorEmail)
+	mine := []model.Commit{}
+	for _, c := range commits {
+		if strings.ToLower(c.AuthorEmail) == email {
+			mine = append(mine, c)
+		}
+	}
+	sort.SliceStable(mine, func(i, j int) bool { return mine[i].Date.Before(mine[j].Date) })
+	pos := 0
+	for i, c := range mine {
+		if c.SHA == commits[idx].SHA {
+			pos = i
+		}
+	}
+	follows := func(i int) bool {
+		return isFixSubject(mine[i].Subject) && mine[i].Date.Sub(mine[i-1].Date) <= fixChainGap
+	}
+	start, end := pos, pos
+	for start > 0 && follows(start) {
+		start--
+	}
+	for end+1 < len(mine) && follows(end+1) {
+		end++
+	}
+	if start == end {
+		return nil
+	}
+	return mine[start : end+1]
+}
+
+// isFixSubject matches subjects of follow-up fixes such as "fix", "fix typo",
+// "oops" or "fixup! ...".
+func isFixSubject(subject string) bool {
+	lower := strings.ToLower(strings.TrimSpace(subject))
+	for _, prefix := range []string{"fix", "oops", "typo", "again", "forgot"} {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return isFixupSubject(lower) || strings.Contains(lower, "typo") || strings.HasSuffix(lower, "again")
+}
+
+func sizeOf(files []model.FileStat) model.CommitSize {
+	size := model.CommitSize{}
+	for _, f := range files {
+		if f.Submodule {
+			size.Submodules++
+			continue
+		}
+		size.Files++
+		if f.Binary {
+			size.BinaryFiles++
+			continue
+		}
+		size.Added += f.Added
+		size.Deleted += f.Deleted
+	}
+	return size
+}
@@ -0,0 +1,48 @@
+package analyze
+
+import (
+	"testing"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+func TestInspectCommitFindsPanicWindowAndFixChain(t *testing.T) {
+	base := time.Date(2024, 3, 6, 14, 0, 0, 0, time.UTC)
+	jane := func(sha, subject string, minutes int) model.Commit {
+		return model.Commit{SHA: sha, AuthorName: "Jane", AuthorEmail: "jane@example.com", Subject: subject, Date: base.Add(time.Duration(minutes) * time.Minute)}
+	}
+	// Newest first, as git log lists them.
+	commits := []model.Commit{
+		jane("e", "Add release notes", 300),
+		jane("d", "oops", 30),
+		jane("c", "fix", 20),
+		jane("b", "wip", 10),
+		jane("a", "Add config loader", 0),
+	}
+	files := []model.FileStat{{Path: "config.go", Added: 2, Deleted: 1}}
+	detail, ok := InspectCommit(commits, "c", files, AnalyzeConfig{TZ: "commit"})
+	if !ok {
+		t.Fatalf("expected commit to be found")
+	}
+	if detail.PanicWindow == nil || detail.PanicWindow.Commits != 4 || detail.PanicWindow.LowQuality != 3 {
+		t.Fatalf("unexpected panic window: %+v", detail.PanicWindow)
+	}
+	if len(detail.FixChain) != 3 || detail.FixChain[0].SHA != "b" || detail.FixChain[2].SHA != "d" {
+		t.Fatalf("unexpected fix chain: %+v", detail.FixChain)
+	}
+	if !detail.Message.Generic || detail.Size.Files != 1 || detail.Size.Added != 2 {
+		t.Fatalf("unexpected detail: %+v", detail)
+	}
+	if len(detail.Reasons) != 3 || detail.Reasons[0] != "generic message" || detail.Reasons[2] != "panic streak" {
+		t.Fatalf("unexpected reasons: %v", detail.Reasons)
+	}
+
+	detail, _ = InspectCommit(commits, "e", nil, AnalyzeConfig{TZ: "commit"})
+	if detail.PanicWindow != nil || len(detail.FixChain) != 0 {
+		t.Fatalf("expected a standalone commit, got %+v", detail)
+	}
+	if _, ok := InspectCommit(commits, "zzz", nil, AnalyzeConfig{}); ok {
+		t.Fatalf("expected unknown commit to be missing")
+	}
+}
@@ -0,0 +1,466 @@
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+// Totals are the sums behind a history's metrics and offenders, which the
+// commits made since can be added to. Windowed metrics need more than sums:
+// Days and Weeks keep every active day and ISO week for streaks, and Tail
+// keeps the commits of the last hour, which later commits can still share
+// a panic window with.
+type Totals struct {
+	Commits        int            `json:"commits"`
+	Humans         int            `json:"humans"`
+	MessageLength  int            `json:"message_length"`
+	MessageQuality int            `json:"message_quality"`
+	LowQuality     int            `json:"low_quality"`
+	Generic        int            `json:"generic"`
+	EmojiOnly      int            `json:"emoji_only"`
+	TooLong        int            `json:"too_long"`
+	TooShort       int            `json:"too_short"`
+	Fixup          int            `json:"fixup"`
+	Lying          int            `json:"lying"`
+	Panic          int            `json:"panic"`
+	GenericWords   map[string]int `json:"generic_words"`
+
+	Sized    int `json:"sized"`
+	Lines    int `json:"lines"`
+	MaxLines int `json:"max_lines"`
+	Large    int `json:"large"`
+	Binary   int `json:"binary"`
+
+	SubmoduleCommits int `json:"submodule_commits"`
+	SubmoduleBumps   int `json:"submodule_bumps"`
+
+	Days     map[string]int `json:"days"`
+	Weeks    map[string]int `json:"weeks"`
+	Midnight int            `json:"midnight"`
+	Deadline int            `json:"deadline"`
+	Merges   int            `json:"merges"`
+
+	ExemptCommits int `json:"exempt_commits"`
+	ExemptChecks  int `json:"exempt_checks"`
+
+	// Offenders holds every flagged commit, worst first.
+	Offenders []model.Offender `json:"-"`
+	Tail      []TailCommit     `json:"tail"`
+}
+
+// TailCommit is a commit of the last hour of a history, in the order panic
+// windows are found over.
+type TailCommit struct {
+	SHA     string    `json:"sha"`
+	Subject string    `json:"subject"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Low     bool      `json:"low,omitempty"`
+	Panic   bool      `json:"panic,omitempty"`
+	// PanicExempt is set when the commit is exempt from the panic check;
+	// Exempted counts the checks it was exempted from.
+	PanicExempt bool `json:"panic_exempt,omitempty"`
+	Exempted    int  `json:"exempted,omitempty"`
+}
+
+// Add adds commits, newest first, to the totals. They must not be in the
+// totals yet. Like Analyze, it attaches sizes to the commits.
+func (t *Totals) Add(commits []model.Commit, sizes map[string]model.CommitSize, cfg AnalyzeConfig) {
+	if len(commits) == 0 {
+		return
+	}
+	if t.GenericWords == nil {
+		t.GenericWords = map[string]int{}
+	}
+	if t.Days == nil {
+		t.Days = map[string]int{}
+	}
+	if t.Weeks == nil {
+		t.Weeks = map[string]int{}
+	}
+	flags := make([]commitFlags, len(commits))
+
+	// Attach size info and message metrics.
+	for i := range commits {
+		c := &commits[i]
+		if size, ok := sizes[c.SHA]; ok {
+			c.Size = &size
+		}
+		f := &flags[i]
+		f.exempt = exemptions(*c, cfg.Allow)
+		bot := cfg.Bots.IsBot(*c)
+		f.bot = bot
+		info := MessageInfo{}
+		if !bot {
+			t.Humans++
+			info = cfg.message(*c)
+			t.MessageLength += len([]rune(c.Subject))
+			t.MessageQuality += info.Score
+		}
+		f.message = info
+		info.Generic = f.counts(CheckGeneric, info.Generic)
+		info.EmojiOnly = f.counts(CheckEmojiOnly, info.EmojiOnly)
+		info.TooLong = f.counts(CheckTooLong, info.TooLong)
+		info.TooShort = f.counts(CheckTooShort, info.TooShort)
+		info.Fixup = f.counts(CheckFixup, info.Fixup)
+		f.msgInfo = info
+		if info.Generic {
+			t.Generic++
+			t.GenericWords[info.GenericKey]++
+		}
+		if info.EmojiOnly {
+			t.EmojiOnly++
+		}
+		if info.TooLong {
+			t.TooLong++
+		}
+		if info.TooShort {
+			t.TooShort++
+		}
+		if info.Fixup {
+			t.Fixup++
+		}
+		low := info.Generic || info.EmojiOnly || info.TooLong || info.TooShort
+		f.lowQuality = low
+		if low {
+			t.LowQuality++
+		}
+		if c.Size != nil && c.Size.Submodules > 0 {
+			t.SubmoduleCommits++
+		}
+		if c.Size != nil && c.Size.SubmoduleBump() {
+			// A pointer bump has no lines of its own to judge.
+			t.SubmoduleBumps++
+		} else if c.Size != nil {
+			lines := c.Size.Added + c.Size.Deleted
+			t.Lines += lines
+			if lines > t.MaxLines {
+				t.MaxLines = lines
+			}
+			if f.counts(CheckHuge, isHuge(lines, c.Size.Files)) {
+				t.Large++
+			}
+			if f.counts(CheckBinary, c.Size.BinaryFiles > 0) {
+				t.Binary++
+			}
+			t.Sized++
+			if f.counts(CheckLying, !bot && IsLyingMessage(c.Subject, lines, c.Size.Files)) {
+				t.Lying++
+			}
+		}
+	}
+
+	// Time metrics.
+	for i, c := range commits {
+		local := cfg.localTime(c.Date)
+		t.Days[local.Format("2006-01-02")]++
+		year, week := local.ISOWeek()
+		t.Weeks[fmt.Sprintf("%04d-W%02d", year, week)]++
+		hour := local.Hour()
+		if flags[i].counts(CheckMidnight, hour >= 0 && hour < 5) {
+			t.Midnight++
+		}
+		if flags[i].counts(CheckDeadline, isDeadlineTime(local)) {
+			t.Deadline++
+		}
+		if len(c.Parents) > 1 {
+			t.Merges++
+		}
+	}
+	t.Commits += len(commits)
+
+	rules := Rules(cfg.Rules...)
+	seq := t.panicSequence(commits, flags)
+	times := make([]time.Time, len(seq))
+	low := make([]bool, len(seq))
+	for i, e := range seq {
+		times[i], low[i] = e.at, e.low
+	}
+	for _, in := range panicWindows(times, low) {
+		for i := in[0]; i <= in[1]; i++ {
+			if e := seq[i]; e.commit >= 0 {
+				flags[e.commit].panic = true
+			} else {
+				t.flagPanic(e.tail, rules)
+			}
+		}
+	}
+	for i := range flags {
+		if flags[i].counts(CheckPanic, flags[i].panic) {
+			t.Panic++
+		}
+	}
+
+	for i := range commits {
+		flags[i].judge(rules, &CommitFacts{
+			Commit:  commits[i],
+			Files:   cfg.Files[commits[i].SHA],
+			Local:   cfg.localTime(commits[i].Date),
+			Bot:     flags[i].bot,
+			Panic:   flags[i].panic,
+			message: &flags[i].message,
+		})
+	}
+	for _, f := range flags {
+		if f.exempted > 0 {
+			t.ExemptCommits++
+			t.ExemptChecks += f.exempted
+		}
+	}
+	t.Tail = t.nextTail(seq, commits, flags)
+
+	if t.Offenders == nil {
+		t.Offenders = []model.Offender{}
+	}
+	t.Offenders = append(t.Offenders, buildOffenders(commits, flags)...)
+	sortOffenders(t.Offenders)
+}
+
+// Metrics computes the metrics of the totals.
+func (t *Totals) Metrics(branches []string) model.Metrics {
+	metrics := model.Metrics{}
+	if t.Commits == 0 {
+		return metrics
+	}
+	metrics.Message = model.MessageMetrics{
+		Total:      t.Humans,
+		Generic:    t.Generic,
+		EmojiOnly:  t.EmojiOnly,
+		TooLong:    t.TooLong,
+		TooShort:   t.TooShort,
+		Fixup:      t.Fixup,
+		Lying:      t.Lying,
+		Panic:      t.Panic,
+		LowQuality: t.LowQuality,
+	}
+	if t.Humans > 0 {
+		metrics.Message.AverageLength = float64(t.MessageLength) / float64(t.Humans)
+		metrics.Message.AverageQuality = float64(t.MessageQuality) / float64(t.Humans)
+	}
+	metrics.Message.TopGenericWords = topGenericWords(t.GenericWords, 3)
+
+	metrics.Size = model.SizeMetrics{
+		SampleSize:        t.Sized,
+		LargeCommitCount:  t.Large,
+		BinaryCommitCount: t.Binary,
+		MaxLines:          t.MaxLines,
+	}
+	if t.Sized > 0 {
+		metrics.Size.AverageLines = float64(t.Lines) / float64(t.Sized)
+	}
+
+	metrics.Time.UniqueDays = len(t.Days)
+	metrics.Time.UniqueWeeks = len(t.Weeks)
+	metrics.Time.CommitsPerDayAvg = float64(t.Commits) / float64(max(1, metrics.Time.UniqueDays))
+	metrics.Time.CommitsPerWeekAvg = float64(t.Commits) / float64(max(1, metrics.Time.UniqueWeeks))
+	metrics.Time.MidnightRatio = float64(t.Midnight) / float64(t.Commits)
+	metrics.Time.DeadlineRatio = float64(t.Deadline) / float64(t.Commits)
+	metrics.Time.LongestStreakDays = longestStreak(t.Days)
+
+	metrics.Hygiene.MergeRatio = float64(t.Merges) / float64(t.Commits)
+	metrics.Hygiene.LinearRatio = 1 - metrics.Hygiene.MergeRatio
+	metrics.Hygiene.BranchCount = len(branches)
+	badBranches := badBranchNames(branches)
+	metrics.Hygiene.BadBranchCount = len(badBranches)
+	metrics.Hygiene.BadBranches = badBranches
+
+	metrics.Exemptions.Commits = t.ExemptCommits
+	metrics.Exemptions.Checks = t.ExemptChecks
+	metrics.Submodules.Commits = t.SubmoduleCommits
+	metrics.Submodules.Bumps = t.SubmoduleBumps
+	return metrics
+}
+
+// panicEntry is a commit of the sequence panic windows are found over.
+// commit indexes the commits being added and tail indexes Totals.Tail; the
+// other one is -1.
+type panicEntry struct {
+	at     time.Time
+	low    bool
+	commit int
+	tail   int
+}
+
+// panicSequence orders the commits being added for panic detection. On
+// their own they keep reverse list order, as git log lists newest first;
+// after a tail they are ordered by date together with it, since a merged
+// branch can bring in commits older than the tail.
+func (t *Totals) panicSequence(commits []model.Commit, flags []commitFlags) []panicEntry {
+	seq := make([]panicEntry, 0, len(t.Tail)+len(commits))
+	for i, tc := range t.Tail {
+		seq = append(seq, panicEntry{at: tc.Date, low: tc.Low, commit: -1, tail: i})
+	}
+	for i := len(commits) - 1; i >= 0; i-- {
+		seq = append(seq, panicEntry{at: commits[i].Date, low: flags[i].lowQuality, commit: i, tail: -1})
+	}
+	if len(t.Tail) > 0 {
+		sort.SliceStable(seq, func(i, j int) bool { return seq[i].at.Before(seq[j].at) })
+	}
+	return seq
+}
+
+// flagPanic marks tail commit i, which commits added later drew into a
+// panic window, as judging it again would: the panic finding joins its
+// offender in rule order, or counts as exempted. Custom rules that read
+// the panic flag are not run again.
+func (t *Totals) flagPanic(i int, rules []Rule) {
+	tc := &t.Tail[i]
+	if tc.Panic {
+		return
+	}
+	tc.Panic = true
+	if tc.PanicExempt {
+		if tc.Exempted == 0 {
+			t.ExemptCommits++
+		}
+		tc.Exempted++
+		t.ExemptChecks++
+		return
+	}
+	t.Panic++
+	order := make(map[string]int, len(rules))
+	var rule Rule
+	for n, r := range rules {
+		order[r.ID()] = n
+		if r.ID() == CheckPanic {
+			rule = r
+		}
+	}
+	if rule == nil {
+		return
+	}
+	at := -1
+	for n := range t.Offenders {
+		if t.Offenders[n].SHA == tc.SHA {
+			at = n
+			break
+		}
+	}
+	if at < 0 {
+		t.Offenders = append(t.Offenders, model.Offender{
+			SHA:     tc.SHA,
+			Subject: tc.Subject,
+			Date:    tc.Date.Format(time.RFC3339),
+			Reasons: []string{},
+			Author:  tc.Author,
+		})
+		at = len(t.Offenders) - 1
+	}
+	off := &t.Offenders[at]
+	pos := len(off.Checks)
+	for n, id := range off.Checks {
+		if order[id] > order[CheckPanic] {
+			pos = n
+			break
+		}
+	}
+	for _, finding := range rule.Evaluate(&CommitFacts{Panic: true}) {
+		off.Reasons = slices.Insert(off.Reasons, pos, finding.Reason)
+		off.Checks = slices.Insert(off.Checks, pos, CheckPanic)
+		off.Weights = slices.Insert(off.Weights, pos, finding.Weight)
+		off.Score += finding.Weight
+		pos++
+	}
+}
+
+// nextTail returns the commits of seq in the hour up to its latest one.
+func (t *Totals) nextTail(seq []panicEntry, commits []model.Commit, flags []commitFlags) []TailCommit {
+	var latest time.Time
+	for _, e := range seq {
+		if e.at.After(latest) {
+			latest = e.at
+		}
+	}
+	from := latest.Add(-time.Hour)
+	tail := []TailCommit{}
+	for _, e := range seq {
+		switch {
+		case e.at.Before(from):
+		case e.tail >= 0:
+			tail = append(tail, t.Tail[e.tail])
+		default:
+			c, f := commits[e.commit], flags[e.commit]
+			tail = append(tail, TailCommit{
+				SHA:         c.SHA,
+				Subject:     c.Subject,
+				Author:      c.AuthorName,
+				Date:        c.Date,
+				Low:         f.lowQuality,
+				Panic:       f.panic,
+				PanicExempt: f.exempts(CheckPanic),
+				Exempted:    f.exempted,
+			})
+		}
+	}
+	return tail
+}
+
+// storedOffender is an offender with the fields JSON reports leave out.
+type storedOffender struct {
+	SHA     string   `json:"sha"`
+	Subject string   `json:"subject"`
+	Date    string   `json:"date"`
+	Author  string   `json:"author"`
+	Reasons []string `json:"reasons"`
+	Checks  []string `json:"checks"`
+	Weights []int    `json:"weights"`
+}
+
+// MarshalJSON keeps the checks and weights of the offenders, which later
+// commits' panic findings and baselines rely on.
+func (t Totals) MarshalJSON() ([]byte, error) {
+	type plain Totals
+	out := struct {
+		plain
+		Offenders []storedOffender `json:"offenders"`
+	}{plain: plain(t), Offenders: make([]storedOffender, len(t.Offenders))}
+	for i, off := range t.Offenders {
+		out.Offenders[i] = storedOffender{
+			SHA:     off.SHA,
+			Subject: off.Subject,
+			Date:    off.Date,
+			Author:  off.Author,
+			Reasons: off.Reasons,
+			Checks:  off.Checks,
+			Weights: off.Weights,
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON restores totals written by MarshalJSON, rescoring the
+// offenders from their weights.
+func (t *Totals) UnmarshalJSON(data []byte) error {
+	type plain Totals
+	in := struct {
+		*plain
+		Offenders []storedOffender `json:"offenders"`
+	}{plain: (*plain)(t)}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	t.Offenders = make([]model.Offender, len(in.Offenders))
+	for i, off := range in.Offenders {
+		if off.Reasons == nil {
+			off.Reasons = []string{}
+		}
+		t.Offenders[i] = model.Offender{
+			SHA:     off.SHA,
+			Subject: off.Subject,
+			Date:    off.Date,
+			Reasons: off.Reasons,
+			Author:  off.Author,
+			Checks:  off.Checks,
+			Weights: off.Weights,
+		}
+		for _, w := range off.Weights {
+			t.Offenders[i].Score += w
+		}
+	}
+	return nil
+}
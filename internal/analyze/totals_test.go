@@ -0,0 +1,55 @@
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+func TestTotalsAddMatchesAnalyze(t *testing.T) {
+	base := time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)
+	subjects := []string{"Add the importer", "wip", "fix", "tmp", "oops", "Document the parser"}
+	offsets := []time.Duration{0, 3 * time.Hour, 190 * time.Minute, 200 * time.Minute, 210 * time.Minute, 32 * time.Hour}
+	history := []model.Commit{}
+	for i := len(subjects) - 1; i >= 0; i-- {
+		history = append(history, model.Commit{
+			SHA:     fmt.Sprintf("c%d", i),
+			Subject: subjects[i],
+			Date:    base.Add(offsets[i]),
+			Parents: []string{},
+		})
+	}
+	// c2 sits on the old side of the boundary, in a panic window that only
+	// the new commits complete.
+	history[3].Body = "Roastgit-Ignore: panic"
+	cfg := AnalyzeConfig{TZ: "commit"}
+	wantMetrics, wantOffenders := Analyze(history, nil, nil, cfg)
+	if wantMetrics.Message.Panic != 3 || wantMetrics.Exemptions.Checks != 1 || wantMetrics.Time.LongestStreakDays != 2 {
+		t.Fatalf("unexpected full metrics: %+v", wantMetrics)
+	}
+
+	var old Totals
+	old.Add(history[3:], nil, cfg)
+	if old.Panic != 0 || len(old.Tail) != 2 {
+		t.Fatalf("old totals: panic=%d tail=%+v", old.Panic, old.Tail)
+	}
+	data, err := json.Marshal(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Totals
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	saved.Add(history[:3], nil, cfg)
+	if got := saved.Metrics(nil); !reflect.DeepEqual(got, wantMetrics) {
+		t.Fatalf("incremental metrics:\n got %+v\nwant %+v", got, wantMetrics)
+	}
+	if !reflect.DeepEqual(saved.Offenders, wantOffenders) {
+		t.Fatalf("incremental offenders:\n got %+v\nwant %+v", saved.Offenders, wantOffenders)
+	}
+}
@@ -0,0 +1,77 @@
+package analyze
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+// Trend periods accepted by BuildTrend.
+const (
+	PeriodWeek    = "week"
+	PeriodMonth   = "month"
+	PeriodQuarter = "quarter"
+)
+
+// BuildTrend buckets commits by period and scores each bucket independently.
+// Only periods containing commits are returned, oldest first.
+func BuildTrend(commits []model.Commit, sizes map[string]model.CommitSize, branches []string, cfg AnalyzeConfig, period string) (model.Trend, error) {
+	trend := model.Trend{Period: period, Points: []model.TrendPoint{}}
+	type bucket struct {
+		start   time.Time
+		label   string
+		commits []model.Commit
+	}
+	buckets := map[string]*bucket{}
+	for _, c := range commits {
+		start, label, err := periodStart(cfg.localTime(c.Date), period)
+		if err != nil {
+			return trend, err
+		}
+		b, ok := buckets[label]
+		if !ok {
+			b = &bucket{start: start, label: label}
+			buckets[label] = b
+		}
+		// Commits arrive newest first; keep that order for Analyze.
+		b.commits = append(b.commits, c)
+	}
+	ordered := make([]*bucket, 0, len(buckets))
+	for _, b := range buckets {
+		ordered = append(ordered, b)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].start.Before(ordered[j].start) })
+	for _, b := range ordered {
+		metrics, _ := Analyze(b.commits, sizes, branches, cfg)
+		score := Score(metrics)
+		trend.Points = append(trend.Points, model.TrendPoint{
+			Label:     b.label,
+			Start:     b.start.Format("2006-01-02"),
+			Commits:   len(b.commits),
+			Overall:   score.Overall,
+			Breakdown: score.Breakdown,
+		})
+	}
+	return trend, nil
+}
+
+func periodStart(t time.Time, period string) (time.Time, string, error) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch period {
+	case PeriodWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		start := day.AddDate(0, 0, -offset)
+		year, week := start.ISOWeek()
+		return start, fmt.Sprintf("%04d-W%02d", year, week), nil
+	case PeriodMonth:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+		return start, start.Format("2006-01"), nil
+	case PeriodQuarter:
+		q := (int(t.Month()) - 1) / 3
+		start := time.Date(t.Year(), time.Month(q*3+1), 1, 0, 0, 0, 0, t.Location())
+		return start, fmt.Sprintf("%04d-Q%d", t.Year(), q+1), nil
+	}
+	return time.Time{}, "", fmt.Errorf("unknown trend period %q", period)
+}
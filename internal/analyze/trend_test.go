@@ -0,0 +1,45 @@
+package analyze
+
+import (
+	"testing"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+func TestBuildTrendBucketsByMonth(t *testing.T) {
+	commits := []model.Commit{
+		{SHA: "c4", Date: time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC), Subject: "Add release notes"},
+		{SHA: "c3", Date: time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC), Subject: "fix"},
+		{SHA: "c2", Date: time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), Subject: "wip"},
+		{SHA: "c1", Date: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), Subject: "Initial import of the parser"},
+	}
+	trend, err := BuildTrend(commits, nil, nil, AnalyzeConfig{TZ: "commit"}, PeriodMonth)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(trend.Points) != 2 {
+		t.Fatalf("expected 2 periods, got %d", len(trend.Points))
+	}
+	if trend.Points[0].Label != "2024-01" || trend.Points[0].Commits != 3 {
+		t.Fatalf("unexpected first point: %+v", trend.Points[0])
+	}
+	if trend.Points[1].Label != "2024-03" || trend.Points[1].Overall <= trend.Points[0].Overall {
+		t.Fatalf("expected March to score better: %+v", trend.Points)
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	day := time.Date(2024, 5, 16, 9, 0, 0, 0, time.UTC)
+	start, label, _ := periodStart(day, PeriodWeek)
+	if label != "2024-W20" || start.Weekday() != time.Monday || start.Day() != 13 {
+		t.Fatalf("unexpected week bucket %s %s", label, start)
+	}
+	_, label, _ = periodStart(day, PeriodQuarter)
+	if label != "2024-Q2" {
+		t.Fatalf("unexpected quarter label %s", label)
+	}
+	if _, _, err := periodStart(day, "decade"); err == nil {
+		t.Fatalf("expected error for unknown period")
+	}
+}
@@ -0,0 +1,91 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+// DefaultFile is the baseline file name looked up at the repo root.
+const DefaultFile = ".roastgit-baseline.json"
+
+// Path is file when given, else DefaultFile at the root of repoPath.
+func Path(repoPath, file string) string {
+	if file != "" {
+		return file
+	}
+	return filepath.Join(repoPath, DefaultFile)
+}
+
+// formatVersion is bumped when the file layout changes incompatibly.
+const formatVersion = 1
+
+// File is the on-disk baseline of known offenders.
+type File struct {
+	Version     int     `json:"version"`
+	GeneratedAt string  `json:"generated_at"`
+	Head        string  `json:"head"`
+	Entries     []Entry `json:"offenders"`
+}
+
+// Entry records the reasons a commit was already flagged for.
+type Entry struct {
+	SHA     string   `json:"sha"`
+	Subject string   `json:"subject"`
+	Reasons []string `json:"reasons"`
+}
+
+// New builds a baseline from the current offenders, ordered by SHA so the
+// file diffs cleanly when it is rewritten.
+func New(head string, offenders []model.Offender, now time.Time) File {
+	f := File{
+		Version:     formatVersion,
+		GeneratedAt: now.UTC().Format(time.RFC3339),
+		Head:        head,
+		Entries:     make([]Entry, 0, len(offenders)),
+	}
+	for _, off := range offenders {
+		f.Entries = append(f.Entries, Entry{SHA: off.SHA, Subject: off.Subject, Reasons: off.Reasons})
+	}
+	sort.Slice(f.Entries, func(i, j int) bool { return f.Entries[i].SHA < f.Entries[j].SHA })
+	return f
+}
+
+// Load reads a baseline file.
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return File{}, fmt.Errorf("%s: invalid baseline: %w", path, err)
+	}
+	if f.Version != formatVersion {
+		return File{}, fmt.Errorf("%s: unsupported baseline version %d", path, f.Version)
+	}
+	return f, nil
+}
+
+// Write stores the baseline as indented JSON.
+func Write(path string, f File) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Known maps each recorded SHA to its recorded reasons.
+func (f File) Known() map[string][]string {
+	known := make(map[string][]string, len(f.Entries))
+	for _, e := range f.Entries {
+		known[e.SHA] = append(known[e.SHA], e.Reasons...)
+	}
+	return known
+}
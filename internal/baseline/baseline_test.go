@@ -0,0 +1,31 @@
+package baseline
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"roastgit/internal/model"
+)
+
+func TestWriteLoadRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	offenders := []model.Offender{
+		{SHA: "bbb", Subject: "wip", Reasons: []string{"generic message", "too short"}},
+		{SHA: "aaa", Subject: "huge", Reasons: []string{"huge commit"}},
+	}
+	if err := Write(path, New("head", offenders, time.Unix(0, 0))); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(f.Entries) != 2 || f.Entries[0].SHA != "aaa" {
+		t.Fatalf("expected entries sorted by sha: %+v", f.Entries)
+	}
+	known := f.Known()
+	if len(known["bbb"]) != 2 {
+		t.Fatalf("unexpected known reasons: %v", known)
+	}
+}
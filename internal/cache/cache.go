@@ -0,0 +1,344 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+)
+
+// FileName is the cache file inside the cache directory.
+const FileName = "commits.json"
+
+// FormatVersion is bumped when the file layout changes or when the cached
+// analysis (numstat semantics, message checks) would come out differently;
+// a file with another version is discarded and rebuilt. Version 2 drops
+// sizes of shallow-clone boundary commits cached by earlier releases;
+// version 3 drops sizes that counted submodule pointers as files;
+// version 4 keeps sizes and file stats per backend.
+const FormatVersion = 4
+
+// Cache holds per-commit data that never changes for a given SHA: the
+// numstat summary, per-file stats and the message checks. Sizes and file
+// stats are kept per backend, since the backends do not always agree on
+// them (only exec detects an edited rename, for one); a Cache reads and
+// writes those of the backend it was opened for. Methods are safe for
+// concurrent use, and a nil *Cache caches nothing.
+type Cache struct {
+	path    string
+	backend string
+
+	mu      sync.Mutex
+	entries map[string]*Entry
+	dirty   bool
+}
+
+// Entry is the cached data of one commit. Each part is filled in as it is
+// first computed; Sizes and Files are keyed by backend name.
+type Entry struct {
+	Sizes   map[string]*Size            `json:"sizes,omitempty"`
+	Files   map[string][]model.FileStat `json:"files,omitempty"`
+	Message *analyze.MessageInfo        `json:"message,omitempty"`
+}
+
+// Size mirrors model.CommitSize with JSON names.
+type Size struct {
+	Files       int `json:"files"`
+	Added       int `json:"added"`
+	Deleted     int `json:"deleted"`
+	BinaryFiles int `json:"binary_files,omitempty"`
+	Submodules  int `json:"submodules,omitempty"`
+}
+
+type file struct {
+	Version int               `json:"version"`
+	Commits map[string]*Entry `json:"commits"`
+}
+
+// Stats summarizes a cache directory.
+type Stats struct {
+	Path     string `json:"path"`
+	Version  int    `json:"version"`
+	Commits  int    `json:"commits"`
+	Sizes    int    `json:"sizes"`
+	Files    int    `json:"files"`
+	Messages int    `json:"messages"`
+	Bytes    int64  `json:"bytes"`
+	// The State fields describe the incremental state, if any.
+	StateHead    string `json:"state_head,omitempty"`
+	StateCommits int    `json:"state_commits"`
+	StateBytes   int64  `json:"state_bytes"`
+}
+
+// Dir picks the cache directory for a repository: roastgit/ inside its git
+// common directory, or a per-repository directory under the user cache dir
+// when the git directory is not writable.
+func Dir(gitDir string) string {
+	dir := filepath.Join(gitDir, "roastgit")
+	if err := os.MkdirAll(dir, 0o755); err == nil {
+		return dir
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return dir
+	}
+	abs, err := filepath.Abs(gitDir)
+	if err != nil {
+		abs = gitDir
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(base, "roastgit", hex.EncodeToString(sum[:8]))
+}
+
+// Open loads the cache in dir for the named backend; an empty name is the
+// exec backend. A missing, unreadable or outdated file yields an empty
+// cache that replaces it on Save.
+func Open(dir, backend string) *Cache {
+	if backend == "" {
+		backend = git.BackendExec
+	}
+	c := &Cache{path: filepath.Join(dir, FileName), backend: backend, entries: map[string]*Entry{}}
+	if f, err := load(c.path); err == nil && f.Version == FormatVersion {
+		for sha, e := range f.Commits {
+			if e != nil {
+				c.entries[sha] = e
+			}
+		}
+	}
+	return c
+}
+
+// OpenRepo loads the cache of the repository whose git common directory
+// gitDir returns, for the named backend. It returns nil, which caches
+// nothing, when disabled or when the git directory cannot be located.
+func OpenRepo(ctx context.Context, gitDir func(context.Context) (string, error), backend string, disabled bool) *Cache {
+	if disabled {
+		return nil
+	}
+	dir, err := gitDir(ctx)
+	if err != nil {
+		return nil
+	}
+	return Open(Dir(dir), backend)
+}
+
+func load(path string) (file, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file{}, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return file{}, fmt.Errorf("%s: invalid cache: %w", path, err)
+	}
+	return f, nil
+}
+
+// Size returns the cached numstat summary of a commit.
+func (c *Cache) Size(sha string) (model.CommitSize, bool) {
+	if c == nil {
+		return model.CommitSize{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[sha]
+	if e == nil || e.Sizes[c.backend] == nil {
+		return model.CommitSize{}, false
+	}
+	s := e.Sizes[c.backend]
+	return model.CommitSize{Files: s.Files, Added: s.Added, Deleted: s.Deleted, BinaryFiles: s.BinaryFiles, Submodules: s.Submodules}, true
+}
+
+// PutSize records the numstat summary of a commit.
+func (c *Cache) PutSize(sha string, size model.CommitSize) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entry(sha)
+	if e.Sizes == nil {
+		e.Sizes = map[string]*Size{}
+	}
+	e.Sizes[c.backend] = &Size{Files: size.Files, Added: size.Added, Deleted: size.Deleted, BinaryFiles: size.BinaryFiles, Submodules: size.Submodules}
+	c.dirty = true
+}
+
+// Files returns the cached per-file stats of a commit.
+func (c *Cache) Files(sha string) ([]model.FileStat, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entries[sha]
+	if e == nil || e.Files[c.backend] == nil {
+		return nil, false
+	}
+	return append([]model.FileStat(nil), e.Files[c.backend]...), true
+}
+
+// PutFiles records the per-file stats of a commit.
+func (c *Cache) PutFiles(sha string, files []model.FileStat) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entry(sha)
+	if e.Files == nil {
+		e.Files = map[string][]model.FileStat{}
+	}
+	e.Files[c.backend] = append([]model.FileStat{}, files...)
+	c.dirty = true
+}
+
+// Messages returns the message checks of each commit, running them for
+// commits that are not cached yet.
+func (c *Cache) Messages(commits []model.Commit) map[string]analyze.MessageInfo {
+	out := make(map[string]analyze.MessageInfo, len(commits))
+	if c == nil {
+		return out
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, commit := range commits {
+		e := c.entry(commit.SHA)
+		if e.Message == nil {
+			info := analyze.AnalyzeMessage(commit.Subject)
+			e.Message = &info
+			c.dirty = true
+		}
+		out[commit.SHA] = *e.Message
+	}
+	return out
+}
+
+// entry returns the entry for sha, creating it. c.mu must be held.
+func (c *Cache) entry(sha string) *Entry {
+	e := c.entries[sha]
+	if e == nil {
+		e = &Entry{}
+		c.entries[sha] = e
+	}
+	return e
+}
+
+// Save writes the cache if anything was added. The file is replaced
+// atomically so a concurrent run never reads a partial cache.
+func (c *Cache) Save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(file{Version: FormatVersion, Commits: c.entries})
+	if err != nil {
+		return err
+	}
+	if err := writeAtomic(c.path, data); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
+
+// writeAtomic replaces path through a temporary file in the same directory.
+func writeAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// ReadStats summarizes the cache and incremental state in dir. Missing
+// files count as empty; a cache in another format reports its version and
+// no commits.
+func ReadStats(dir string) (Stats, error) {
+	path := filepath.Join(dir, FileName)
+	stats := Stats{Path: path, Version: FormatVersion}
+	if info, err := os.Stat(filepath.Join(dir, StateFileName)); err == nil {
+		stats.StateBytes = info.Size()
+		state, ok, err := LoadState(dir)
+		if err != nil {
+			return stats, err
+		}
+		if ok {
+			stats.StateHead = state.Head
+			stats.StateCommits = state.Totals.Commits
+		}
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return stats, err
+	}
+	stats.Bytes = info.Size()
+	f, err := load(path)
+	if err != nil {
+		return stats, err
+	}
+	stats.Version = f.Version
+	if f.Version != FormatVersion {
+		return stats, nil
+	}
+	for _, e := range f.Commits {
+		if e == nil {
+			continue
+		}
+		stats.Commits++
+		if len(e.Sizes) > 0 {
+			stats.Sizes++
+		}
+		if len(e.Files) > 0 {
+			stats.Files++
+		}
+		if e.Message != nil {
+			stats.Messages++
+		}
+	}
+	return stats, nil
+}
+
+// Clear removes the cache and the incremental state in dir. Missing files
+// are not an error.
+func Clear(dir string) error {
+	for _, name := range []string{FileName, StateFileName} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/model"
+)
+
+func TestCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	c := Open(dir, "")
+	if _, ok := c.Size("a1"); ok {
+		t.Fatal("empty cache reported a size")
+	}
+	size := model.CommitSize{Files: 2, Added: 10, Deleted: 3, BinaryFiles: 1}
+	files := []model.FileStat{{Path: "main.go", Added: 10, Deleted: 3}, {Path: "logo.png", Binary: true}}
+	c.PutSize("a1", size)
+	c.PutFiles("a1", files)
+	msgs := c.Messages([]model.Commit{{SHA: "a1", Subject: "wip"}, {SHA: "b2", Subject: "Add the parser"}})
+	if !msgs["a1"].Generic || msgs["b2"].Generic {
+		t.Fatalf("unexpected message checks: %+v", msgs)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened := Open(dir, "")
+	if got, ok := reopened.Size("a1"); !ok || got != size {
+		t.Fatalf("size = %+v, %v; want %+v", got, ok, size)
+	}
+	if got, ok := reopened.Files("a1"); !ok || !reflect.DeepEqual(got, files) {
+		t.Fatalf("files = %+v, %v", got, ok)
+	}
+	// Cached message checks are returned even for a different subject.
+	if got := reopened.Messages([]model.Commit{{SHA: "a1", Subject: "Something else"}}); !got["a1"].Generic {
+		t.Fatalf("message checks were not cached: %+v", got)
+	}
+
+	stats, err := ReadStats(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Commits != 2 || stats.Sizes != 1 || stats.Files != 1 || stats.Messages != 2 || stats.Bytes == 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	if err := Clear(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := Clear(dir); err != nil {
+		t.Fatalf("clearing a missing cache: %v", err)
+	}
+	if stats, _ := ReadStats(dir); stats.Commits != 0 {
+		t.Fatalf("cache not cleared: %+v", stats)
+	}
+}
+
+func TestCacheDiscardsOtherVersions(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"version": 999, "commits": {"a1": {"size": {"files": 1, "added": 1, "deleted": 0}}}}`
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Open(dir, "").Size("a1"); ok {
+		t.Fatal("an outdated cache was used")
+	}
+	stats, err := ReadStats(dir)
+	if err != nil || stats.Version != 999 || stats.Commits != 0 {
+		t.Fatalf("stats = %+v, %v", stats, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, FileName), []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := Open(dir, "")
+	c.PutSize("b2", model.CommitSize{Files: 1})
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Open(dir, "").Size("b2"); !ok {
+		t.Fatal("a corrupt cache was not replaced")
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	c.PutSize("a1", model.CommitSize{Files: 1})
+	if _, ok := c.Size("a1"); ok {
+		t.Fatal("nil cache stored a size")
+	}
+	if got := c.Messages([]model.Commit{{SHA: "a1"}}); len(got) != 0 {
+		t.Fatalf("nil cache returned messages: %v", got)
+	}
+	if err := c.Save(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if _, ok, err := LoadState(dir); ok || err != nil {
+		t.Fatalf("missing state: ok=%v err=%v", ok, err)
+	}
+	when := time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("", 2*3600))
+	commits := []model.Commit{
+		{SHA: "b2", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: when, Subject: "Add parser", Body: "Roastgit-Ignore: midnight", Parents: []string{"a1"}},
+		{SHA: "a1", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: when.Add(-time.Hour), Subject: "Initial import", Parents: []string{}},
+	}
+	cfg := analyze.AnalyzeConfig{TZ: "commit"}
+	saved := State{Version: StateVersion, Head: "b2", Key: "k1", Shallow: []string{"a1"}, Authors: analyze.AuthorTotals{}}
+	saved.Totals.Add(commits, nil, cfg)
+	saved.Authors.Add(commits, cfg)
+	if err := SaveState(dir, saved); err != nil {
+		t.Fatal(err)
+	}
+	state, ok, err := LoadState(dir)
+	if err != nil || !ok || state.Head != "b2" || state.Key != "k1" || !reflect.DeepEqual(state.Shallow, []string{"a1"}) {
+		t.Fatalf("state = %+v, ok=%v err=%v", state, ok, err)
+	}
+	if got, want := state.Totals.Metrics(nil), saved.Totals.Metrics(nil); !reflect.DeepEqual(got, want) {
+		t.Fatalf("metrics = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(state.Totals.Offenders, saved.Totals.Offenders) || !reflect.DeepEqual(state.Authors, saved.Authors) {
+		t.Fatalf("offenders = %+v, authors = %+v", state.Totals.Offenders, state.Authors)
+	}
+	if tail := state.Totals.Tail; len(tail) != 2 {
+		t.Fatalf("tail = %+v", tail)
+	} else if _, offset := tail[1].Date.Zone(); offset != 2*3600 {
+		t.Fatalf("commit time zone was lost: %v", tail[1].Date)
+	}
+	stats, _ := ReadStats(dir)
+	if stats.StateHead != "b2" || stats.StateCommits != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if err := Clear(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := LoadState(dir); ok {
+		t.Fatal("state survived Clear")
+	}
+}
+
+func TestCachePerBackend(t *testing.T) {
+	dir := t.TempDir()
+	exec := Open(dir, "exec")
+	renamed := model.CommitSize{Files: 1, Added: 1, Deleted: 1}
+	exec.PutSize("a1", renamed)
+	exec.PutFiles("a1", []model.FileStat{{Path: "new.go", Added: 1, Deleted: 1}})
+	if err := exec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The native backend sees the rename as a delete and an add, so it must
+	// not be handed the exec backend's numbers.
+	native := Open(dir, "native")
+	if got, ok := native.Size("a1"); ok {
+		t.Fatalf("native read the exec size %+v", got)
+	}
+	if got, ok := native.Files("a1"); ok {
+		t.Fatalf("native read the exec files %+v", got)
+	}
+	split := model.CommitSize{Files: 2, Added: 40, Deleted: 40}
+	native.PutSize("a1", split)
+	if err := native.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := Open(dir, "").Size("a1"); !ok || got != renamed {
+		t.Fatalf("exec size = %+v, %v; want %+v", got, ok, renamed)
+	}
+	if got, ok := Open(dir, "native").Size("a1"); !ok || got != split {
+		t.Fatalf("native size = %+v, %v; want %+v", got, ok, split)
+	}
+	if stats, err := ReadStats(dir); err != nil || stats.Commits != 1 || stats.Sizes != 1 {
+		t.Fatalf("stats = %+v, %v", stats, err)
+	}
+}
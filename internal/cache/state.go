@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"roastgit/internal/analyze"
+)
+
+// StateFileName is the incremental state file inside the cache directory.
+const StateFileName = "state.json"
+
+// StateVersion is the format version of the incremental state. States of
+// older releases, which held the whole commit list, carry another version
+// and are ignored.
+const StateVersion = 2
+
+// State holds the analysis totals of HEAD as of the last incremental run.
+// The next run only reads lastHead..HEAD from git and adds the new commits
+// to the totals, so its cost follows the new commits rather than the
+// history. Windowed metrics are carried over by the totals: the active days
+// and weeks for streaks and a tail of the last hour for panic windows.
+type State struct {
+	Version int    `json:"version"`
+	Head    string `json:"head"`
+	// Key fingerprints the settings the totals were computed with, such as
+	// the time zone, the config file and the rules; a state saved under
+	// other settings is not reused.
+	Key string `json:"key"`
+	// Shallow lists the boundary commits when the history was read from a
+	// shallow clone; deepening the clone invalidates the state.
+	Shallow []string             `json:"shallow,omitempty"`
+	Totals  analyze.Totals       `json:"totals"`
+	Authors analyze.AuthorTotals `json:"authors"`
+}
+
+// LoadState reads the state in dir. ok is false when there is none or it
+// was written by another format version.
+func LoadState(dir string) (State, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, StateFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return State{}, false, nil
+	}
+	if err != nil {
+		return State{}, false, err
+	}
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return State{}, false, fmt.Errorf("%s: invalid state: %w", StateFileName, err)
+	}
+	if s.Version != StateVersion || s.Head == "" {
+		return State{}, false, nil
+	}
+	if s.Authors == nil {
+		s.Authors = analyze.AuthorTotals{}
+	}
+	return s, true, nil
+}
+
+// SaveState atomically replaces the state in dir.
+func SaveState(dir string, s State) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return writeAtomic(filepath.Join(dir, StateFileName), data)
+}
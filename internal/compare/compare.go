@@ -0,0 +1,129 @@
+package compare
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"roastgit/internal/model"
+)
+
+// Reports computes score, metric and offender-type deltas from base to head.
+func Reports(baseLabel string, base model.Report, headLabel string, head model.Report) model.Comparison {
+	cmp := model.Comparison{
+		Base:                  side(baseLabel, base),
+		Head:                  side(headLabel, head),
+		NewOffenderTypes:      []string{},
+		ResolvedOffenderTypes: []string{},
+	}
+	cmp.Score = []model.Delta{
+		delta("overall", float64(base.Score.Overall), float64(head.Score.Overall)),
+		delta("message_quality", float64(base.Score.Breakdown.MessageQuality), float64(head.Score.Breakdown.MessageQuality)),
+		delta("hygiene", float64(base.Score.Breakdown.Hygiene), float64(head.Score.Breakdown.Hygiene)),
+		delta("cadence", float64(base.Score.Breakdown.Cadence), float64(head.Score.Breakdown.Cadence)),
+		delta("size_discipline", float64(base.Score.Breakdown.SizeDiscipline), float64(head.Score.Breakdown.SizeDiscipline)),
+	}
+	cmp.Metrics = diffMaps(flattenMetrics(base.Metrics), flattenMetrics(head.Metrics))
+	before := OffenderTypes(base)
+	after := OffenderTypes(head)
+	cmp.OffenderTypes = diffMaps(before, after)
+	for _, d := range cmp.OffenderTypes {
+		switch {
+		case d.Before == 0 && d.After > 0:
+			cmp.NewOffenderTypes = append(cmp.NewOffenderTypes, d.Name)
+		case d.Before > 0 && d.After == 0:
+			cmp.ResolvedOffenderTypes = append(cmp.ResolvedOffenderTypes, d.Name)
+		}
+	}
+	return cmp
+}
+
+// OffenderTypes counts commits per offender reason. Counts come from the
+// metrics so they cover every commit, not only the listed top offenders.
+// Custom rule reasons have no metric and are left out: counting them from
+// the top offenders alone would report made-up changes.
+func OffenderTypes(report model.Report) map[string]float64 {
+	m := report.Metrics
+	total := float64(m.Message.Total)
+	return map[string]float64{
+		"generic message":    float64(m.Message.Generic),
+		"emoji-only message": float64(m.Message.EmojiOnly),
+		"too long":           float64(m.Message.TooLong),
+		"too short":          float64(m.Message.TooShort),
+		"fixup commit":       float64(m.Message.Fixup),
+		"lying message":      float64(m.Message.Lying),
+		"panic streak":       float64(m.Message.Panic),
+		"huge commit":        float64(m.Size.LargeCommitCount),
+		"binary blobs":       float64(m.Size.BinaryCommitCount),
+		"midnight gremlin":   math.Round(m.Time.MidnightRatio * total),
+		"deadline scramble":  math.Round(m.Time.DeadlineRatio * total),
+	}
+}
+
+func side(label string, report model.Report) model.ComparisonSide {
+	return model.ComparisonSide{
+		Label:       label,
+		Head:        report.Repo.Head,
+		CommitCount: report.Repo.CommitCount,
+		Overall:     report.Score.Overall,
+	}
+}
+
+func delta(name string, before, after float64) model.Delta {
+	return model.Delta{Name: name, Before: before, After: after, Change: round(after - before)}
+}
+
+// flattenMetrics maps every numeric metric to a dotted JSON path such as
+// "message.generic", so new metrics are compared without extra code.
+func flattenMetrics(metrics model.Metrics) map[string]float64 {
+	out := map[string]float64{}
+	b, err := json.Marshal(metrics)
+	if err != nil {
+		return out
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return out
+	}
+	var walk func(prefix string, node any)
+	walk = func(prefix string, node any) {
+		switch v := node.(type) {
+		case map[string]any:
+			for k, child := range v {
+				name := k
+				if prefix != "" {
+					name = prefix + "." + k
+				}
+				walk(name, child)
+			}
+		case float64:
+			out[prefix] = v
+		}
+	}
+	walk("", doc)
+	return out
+}
+
+func diffMaps(before, after map[string]float64) []model.Delta {
+	keys := map[string]struct{}{}
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	deltas := make([]model.Delta, 0, len(names))
+	for _, name := range names {
+		deltas = append(deltas, delta(name, before[name], after[name]))
+	}
+	return deltas
+}
+
+func round(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
@@ -0,0 +1,43 @@
+package compare
+
+import (
+	"testing"
+
+	"roastgit/internal/model"
+)
+
+func TestReportsDeltas(t *testing.T) {
+	base := model.Report{
+		Score: model.Score{Overall: 60, Breakdown: model.ScoreBreakdown{MessageQuality: 10}},
+		Metrics: model.Metrics{
+			Message: model.MessageMetrics{Total: 10, Generic: 4},
+			Size:    model.SizeMetrics{BinaryCommitCount: 2},
+		},
+	}
+	head := model.Report{
+		Score: model.Score{Overall: 75, Breakdown: model.ScoreBreakdown{MessageQuality: 25}},
+		Metrics: model.Metrics{
+			Message: model.MessageMetrics{Total: 10, Generic: 1, Lying: 1},
+		},
+		Offenders: []model.Offender{{SHA: "a", Reasons: []string{"custom crime"}}},
+	}
+	cmp := Reports("before", base, "after", head)
+	if cmp.Score[0].Name != "overall" || cmp.Score[0].Change != 15 {
+		t.Fatalf("unexpected overall delta: %+v", cmp.Score[0])
+	}
+	found := false
+	for _, d := range cmp.Metrics {
+		if d.Name == "message.generic" {
+			found = d.Before == 4 && d.After == 1 && d.Change == -3
+		}
+	}
+	if !found {
+		t.Fatalf("expected message.generic delta in %+v", cmp.Metrics)
+	}
+	if len(cmp.NewOffenderTypes) != 1 || cmp.NewOffenderTypes[0] != "lying message" {
+		t.Fatalf("unexpected new offender types: %v", cmp.NewOffenderTypes)
+	}
+	if len(cmp.ResolvedOffenderTypes) != 1 || cmp.ResolvedOffenderTypes[0] != "binary blobs" {
+		t.Fatalf("unexpected resolved offender types: %v", cmp.ResolvedOffenderTypes)
+	}
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/gate"
+	"roastgit/internal/script"
+)
+
+// DefaultFile is the config file name looked up at the repo root.
+const DefaultFile = ".roastgit.json"
+
+// RulesDir holds the repository's rule scripts, relative to its root.
+var RulesDir = filepath.Join(".roastgit", "rules")
+
+// File is the repository config file.
+type File struct {
+	Allowlist []AllowEntry `json:"allowlist,omitempty"`
+	Bots      BotConfig    `json:"bots"`
+	Hooks     HookConfig   `json:"hooks"`
+	Gates     GateConfig   `json:"gates"`
+	Rules     []RuleEntry  `json:"rules,omitempty"`
+
+	// scripts are the rules loaded from RulesDir by LoadRepo.
+	scripts []*script.Rule
+}
+
+// RuleEntry declares a custom offender rule. A commit is flagged when every
+// condition given matches: Subject, Author and Path are regular expressions,
+// Author matching the name or email and Path any changed file, and the
+// size bounds are inclusive. Size and path conditions only see commits
+// whose numstat was read, which is all of them with --deep.
+type RuleEntry struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Category    string `json:"category,omitempty"`
+	Weight      int    `json:"weight"`
+	Subject     string `json:"subject,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Path        string `json:"path,omitempty"`
+	MinLines    *int   `json:"min_lines,omitempty"`
+	MaxLines    *int   `json:"max_lines,omitempty"`
+	MinFiles    *int   `json:"min_files,omitempty"`
+	MaxFiles    *int   `json:"max_files,omitempty"`
+}
+
+// GateConfig lists the checks that block git operations in hooks.
+type GateConfig struct {
+	// PrePush fails "roastgit pre-push" when outgoing commits hit these
+	// checks; see --fail-on.
+	PrePush []string `json:"pre_push,omitempty"`
+}
+
+// HookConfig configures check-msg and the installed git hooks.
+type HookConfig struct {
+	// Intensity overrides the roast intensity; nil keeps the default.
+	Intensity *int `json:"intensity,omitempty"`
+	Wholesome bool `json:"wholesome,omitempty"`
+	// MinMessageScore rejects commit messages scoring below it (0-100).
+	MinMessageScore int `json:"min_message_score,omitempty"`
+}
+
+// BotConfig tunes automation detection. Patterns are case-insensitive
+// substrings of the author name or email, added to the defaults unless
+// NoDefaults is set.
+type BotConfig struct {
+	Patterns   []string `json:"patterns,omitempty"`
+	NoDefaults bool     `json:"no_defaults,omitempty"`
+}
+
+// AllowEntry exempts matching commits from checks. SHA matches by prefix,
+// Author matches the whole name or email, case-insensitively, and Subject is
+// a regular expression.
+// An empty Checks list exempts the commit from every check.
+type AllowEntry struct {
+	SHA     string   `json:"sha,omitempty"`
+	Author  string   `json:"author,omitempty"`
+	Subject string   `json:"subject,omitempty"`
+	Checks  []string `json:"checks,omitempty"`
+}
+
+// Load reads and validates a config file. Unknown keys are rejected so typos
+// do not silently disable settings.
+func Load(path string) (File, error) {
+	return load(path, nil)
+}
+
+// load reads the config file at path alongside scripts, which its
+// allowlist and gates may name.
+func load(path string, scripts []*script.Rule) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	f := File{scripts: scripts}
+	if err := dec.Decode(&f); err != nil {
+		return File{}, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := f.validate(); err != nil {
+		return File{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
+
+func (f File) validate() error {
+	rules, err := f.CustomRules()
+	if err != nil {
+		return err
+	}
+	if _, err := f.AllowRules(); err != nil {
+		return err
+	}
+	if i := f.Hooks.Intensity; i != nil && (*i < 0 || *i > 5) {
+		return fmt.Errorf("hooks.intensity must be between 0 and 5")
+	}
+	if s := f.Hooks.MinMessageScore; s < 0 || s > 100 {
+		return fmt.Errorf("hooks.min_message_score must be between 0 and 100")
+	}
+	if err := gate.Validate(f.Gates.PrePush, rules...); err != nil {
+		return fmt.Errorf("gates.pre_push: %w", err)
+	}
+	return nil
+}
+
+// LoadRepo reads the config file of the repository at repoPath: path when
+// given, else DefaultFile at its root. A missing default file yields an
+// empty config; a missing file named by path is an error. Rule scripts in
+// RulesDir are loaded either way.
+func LoadRepo(repoPath, path string) (File, error) {
+	var scripts []*script.Rule
+	if repoPath != "" {
+		var err error
+		if scripts, err = script.LoadDir(filepath.Join(repoPath, RulesDir)); err != nil {
+			return File{}, err
+		}
+	}
+	if path == "" {
+		f, err := load(filepath.Join(repoPath, DefaultFile), scripts)
+		if errors.Is(err, os.ErrNotExist) {
+			f = File{scripts: scripts}
+			return f, f.validate()
+		}
+		return f, err
+	}
+	return load(path, scripts)
+}
+
+// Scripts returns the rule scripts LoadRepo found, in name order.
+func (f File) Scripts() []*script.Rule {
+	return f.scripts
+}
+
+// BotMatcher builds the automation matcher for analyze.
+func (f File) BotMatcher() *analyze.BotMatcher {
+	return analyze.NewBotMatcher(f.Bots.Patterns, !f.Bots.NoDefaults)
+}
+
+// AllowRules compiles the allowlist for analyze.
+func (f File) AllowRules() ([]analyze.AllowRule, error) {
+	custom, err := f.CustomRules()
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]analyze.AllowRule, 0, len(f.Allowlist))
+	for i, e := range f.Allowlist {
+		if e.SHA == "" && e.Author == "" && e.Subject == "" {
+			return nil, fmt.Errorf("allowlist[%d]: needs sha, author or subject", i)
+		}
+		rule := analyze.AllowRule{SHA: e.SHA, Author: e.Author, Checks: e.Checks}
+		if e.Subject != "" {
+			re, err := regexp.Compile(e.Subject)
+			if err != nil {
+				return nil, fmt.Errorf("allowlist[%d]: subject: %w", i, err)
+			}
+			rule.Subject = re
+		}
+		for _, id := range e.Checks {
+			if _, ok := analyze.LookupCheck(id, custom...); !ok && id != analyze.CheckAll {
+				return nil, fmt.Errorf("allowlist[%d]: unknown check %q", i, id)
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ruleID is the shape of custom rule IDs, like the built-in check IDs.
+var ruleID = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// CustomRules compiles the declared rules for analyze, followed by the rule
+// scripts.
+func (f File) CustomRules() ([]analyze.Rule, error) {
+	rules := make([]analyze.Rule, 0, len(f.Rules)+len(f.scripts))
+	for i, e := range f.Rules {
+		switch {
+		case !ruleID.MatchString(e.ID) || e.ID == analyze.CheckAll:
+			return nil, fmt.Errorf("rules[%d]: id must be lowercase letters, digits and dashes, got %q", i, e.ID)
+		case e.Description == "":
+			return nil, fmt.Errorf("rules[%d]: needs a description", i)
+		case e.Weight < 1 || e.Weight > 100:
+			return nil, fmt.Errorf("rules[%d]: weight must be between 1 and 100", i)
+		}
+		if _, ok := analyze.LookupCheck(e.ID, rules...); ok {
+			return nil, fmt.Errorf("rules[%d]: id %q is taken", i, e.ID)
+		}
+		if _, ok := analyze.LookupCheck(e.Description, rules...); ok {
+			return nil, fmt.Errorf("rules[%d]: description %q is taken", i, e.Description)
+		}
+		var p analyze.Pattern
+		for _, re := range []struct {
+			name string
+			expr string
+			dst  **regexp.Regexp
+		}{{"subject", e.Subject, &p.Subject}, {"author", e.Author, &p.Author}, {"path", e.Path, &p.Path}} {
+			if re.expr == "" {
+				continue
+			}
+			compiled, err := regexp.Compile(re.expr)
+			if err != nil {
+				return nil, fmt.Errorf("rules[%d]: %s: %w", i, re.name, err)
+			}
+			*re.dst = compiled
+		}
+		p.MinLines, p.MaxLines, p.MinFiles, p.MaxFiles = e.MinLines, e.MaxLines, e.MinFiles, e.MaxFiles
+		if p == (analyze.Pattern{}) {
+			return nil, fmt.Errorf("rules[%d]: needs subject, author, path or a size bound", i)
+		}
+		category := e.Category
+		if category == "" {
+			category = analyze.CategoryCustom
+		}
+		rules = append(rules, analyze.PatternRule(e.ID, e.Description, category, e.Weight, p))
+	}
+	for _, r := range f.scripts {
+		if !ruleID.MatchString(r.ID()) || r.ID() == analyze.CheckAll {
+			return nil, fmt.Errorf("%s: file name must be lowercase letters, digits and dashes", r.Path())
+		}
+		if _, ok := analyze.LookupCheck(r.ID(), rules...); ok {
+			return nil, fmt.Errorf("%s: id %q is taken", r.Path(), r.ID())
+		}
+		if _, ok := analyze.LookupCheck(r.Description(), rules...); ok {
+			return nil, fmt.Errorf("%s: description %q is taken", r.Path(), r.Description())
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/model"
+)
+
+func TestLoadAllowlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	data := `{"allowlist": [{"subject": "^Initial import", "checks": ["huge-commit", "binary"]}, {"author": "ci@example.com"}], "bots": {"patterns": ["release-o-matic"]}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	rules, err := f.AllowRules()
+	if err != nil || len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d (%v)", len(rules), err)
+	}
+	if rules[0].Subject == nil || !rules[0].Subject.MatchString("Initial import of vendor tree") {
+		t.Fatalf("expected compiled subject regex")
+	}
+	if !f.BotMatcher().IsBot(model.Commit{AuthorName: "Release-O-Matic"}) {
+		t.Fatalf("expected configured bot pattern to match")
+	}
+}
+
+func TestLoadRejectsBadConfig(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"unknown key":   `{"allowlst": []}`,
+		"unknown check": `{"allowlist": [{"sha": "abc", "checks": ["nope"]}]}`,
+		"empty match":   `{"allowlist": [{"checks": ["binary"]}]}`,
+		"bad regex":     `{"allowlist": [{"subject": "("}]}`,
+		"rule id":       `{"rules": [{"id": "No Spaces", "description": "x", "weight": 1, "subject": "x"}]}`,
+		"taken id":      `{"rules": [{"id": "binary", "description": "x", "weight": 1, "subject": "x"}]}`,
+		"no condition":  `{"rules": [{"id": "x", "description": "x", "weight": 1}]}`,
+		"zero weight":   `{"rules": [{"id": "x", "description": "x", "subject": "x"}]}`,
+		"rule regex":    `{"rules": [{"id": "x", "description": "x", "weight": 1, "path": "["}]}`,
+		"unknown gate":  `{"gates": {"pre_push": ["undeclared"]}}`,
+	}
+	for name, data := range cases {
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFile)
+	data := `{
+		"rules": [{"id": "touches-vendor", "description": "vendored edit", "weight": 4, "path": "^vendor/", "max_lines": 50}],
+		"allowlist": [{"author": "ci@example.com", "checks": ["touches-vendor"]}],
+		"gates": {"pre_push": ["touches-vendor"]}
+	}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	rules, err := f.CustomRules()
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("expected 1 rule, got %d (%v)", len(rules), err)
+	}
+	r := rules[0]
+	if r.ID() != "touches-vendor" || r.Category() != analyze.CategoryCustom || r.Weight() != 4 {
+		t.Fatalf("unexpected rule %s %s %d", r.ID(), r.Category(), r.Weight())
+	}
+	if !analyze.NeedsFiles(rules) {
+		t.Fatalf("expected a path rule to need files")
+	}
+	small := &analyze.CommitFacts{
+		Commit: model.Commit{Size: &model.CommitSize{Files: 1, Added: 10}},
+		Files:  []model.FileStat{{Path: "vendor/lib/a.go", Added: 10}},
+	}
+	if got := r.Evaluate(small); len(got) != 1 || got[0].Reason != "vendored edit" || got[0].Weight != 4 {
+		t.Fatalf("expected one finding, got %+v", got)
+	}
+	small.Commit.Size.Added = 500
+	if got := r.Evaluate(small); len(got) != 0 {
+		t.Fatalf("expected max_lines to exclude the commit, got %+v", got)
+	}
+}
+
+func TestLoadRepoScripts(t *testing.T) {
+	repo := t.TempDir()
+	dir := filepath.Join(repo, RulesDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	script := "description = \"wip commit\"\n\ndef check(commit):\n    return commit.subject.lower().startswith(\"wip\")\n"
+	if err := os.WriteFile(filepath.Join(dir, "wip.star"), []byte(script), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	// Without a config file the scripts still load.
+	f, err := LoadRepo(repo, "")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	rules, err := f.CustomRules()
+	if err != nil || len(rules) != 1 || rules[0].ID() != "wip" || len(f.Scripts()) != 1 {
+		t.Fatalf("expected the wip script, got %d rules (%v)", len(rules), err)
+	}
+
+	// The config file may name scripts in its allowlist and gates.
+	data := `{"allowlist": [{"author": "ci@example.com", "checks": ["wip"]}], "gates": {"pre_push": ["wip"]}}`
+	if err := os.WriteFile(filepath.Join(repo, DefaultFile), []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadRepo(repo, ""); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if _, err := Load(filepath.Join(repo, DefaultFile)); err == nil {
+		t.Fatalf("expected Load without the scripts to reject the unknown check")
+	}
+
+	// A script may not take a built-in check's ID.
+	if err := os.WriteFile(filepath.Join(dir, "binary.star"), []byte(script), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := LoadRepo(repo, ""); err == nil {
+		t.Fatalf("expected binary.star to be rejected")
+	}
+}
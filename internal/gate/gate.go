@@ -0,0 +1,60 @@
+package gate
+
+import (
+	"fmt"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/model"
+)
+
+// Any makes every offender reason fail the gate.
+const Any = "any"
+
+// Validate checks that every name is the ID or reason of a built-in,
+// registered or custom rule, or Any.
+func Validate(failOn []string, custom ...analyze.Rule) error {
+	for _, name := range failOn {
+		if name == Any {
+			continue
+		}
+		if _, ok := analyze.LookupCheck(name, custom...); !ok {
+			return fmt.Errorf("unknown check %q", name)
+		}
+	}
+	return nil
+}
+
+// Evaluate fails when any offender has a reason listed in failOn. Offenders
+// should already have baseline suppressions applied so only new violations
+// count.
+func Evaluate(offenders []model.Offender, failOn []string, custom ...analyze.Rule) model.GateResult {
+	result := model.GateResult{FailOn: failOn}
+	checks := map[string]struct{}{}
+	reasons := map[string]struct{}{}
+	matchAll := false
+	for _, name := range failOn {
+		if name == Any {
+			matchAll = true
+			continue
+		}
+		if r, ok := analyze.LookupCheck(name, custom...); ok {
+			checks[r.ID()] = struct{}{}
+			reasons[r.Description()] = struct{}{}
+		}
+	}
+	for _, off := range offenders {
+		for i, r := range off.Reasons {
+			_, ok := reasons[r]
+			if i < len(off.Checks) {
+				_, byID := checks[off.Checks[i]]
+				ok = ok || byID
+			}
+			if ok || matchAll {
+				result.Violations++
+				break
+			}
+		}
+	}
+	result.Failed = result.Violations > 0
+	return result
+}
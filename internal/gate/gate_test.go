@@ -0,0 +1,44 @@
+package gate
+
+import (
+	"testing"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/model"
+)
+
+func TestEvaluate(t *testing.T) {
+	offenders := []model.Offender{
+		{SHA: "a", Reasons: []string{"generic message"}},
+		{SHA: "b", Reasons: []string{"huge commit", "binary blobs"}},
+	}
+	if res := Evaluate(offenders, []string{"huge-commit"}); !res.Failed || res.Violations != 1 {
+		t.Fatalf("expected one violation, got %+v", res)
+	}
+	if res := Evaluate(offenders, []string{Any}); res.Violations != 2 {
+		t.Fatalf("expected two violations, got %+v", res)
+	}
+	if res := Evaluate(offenders, []string{"lying"}); res.Failed {
+		t.Fatalf("expected gate to pass, got %+v", res)
+	}
+	if err := Validate([]string{"huge-commit", "nope"}); err == nil {
+		t.Fatalf("expected unknown check error")
+	}
+}
+
+func TestEvaluateCustomRules(t *testing.T) {
+	rule := analyze.NewRule("no-ticket", "missing ticket", analyze.CategoryCustom, 2, nil)
+	offenders := []model.Offender{
+		{SHA: "a", Reasons: []string{"generic message"}, Checks: []string{"generic-message"}},
+		{SHA: "b", Reasons: []string{"missing ticket"}, Checks: []string{"no-ticket"}},
+	}
+	if err := Validate([]string{"no-ticket"}); err == nil {
+		t.Fatalf("expected an undeclared rule to be unknown")
+	}
+	if err := Validate([]string{"no-ticket"}, rule); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if res := Evaluate(offenders, []string{"no-ticket"}, rule); res.Violations != 1 {
+		t.Fatalf("expected one violation, got %+v", res)
+	}
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"roastgit/internal/model"
+)
+
+// Backend names accepted by Open.
+const (
+	BackendExec   = "exec"
+	BackendNative = "native"
+)
+
+// Backend reads commits and diffs from one repository. The exec backend
+// shells out to git; the native backend reads the object database directly
+// and needs no git binary.
+type Backend interface {
+	// IsRepo reports whether the repository can be read.
+	IsRepo(ctx context.Context) bool
+	HeadSHA(ctx context.Context) (string, error)
+	// GitDir returns the absolute git common directory.
+	GitDir(ctx context.Context) (string, error)
+	// ShallowCommits returns the boundary commits of a shallow clone,
+	// whose parents were not fetched, sorted; it is empty for a full clone.
+	ShallowCommits(ctx context.Context) ([]string, error)
+	// Branches returns local branch names.
+	Branches(ctx context.Context) ([]string, error)
+	// ResolveCommit returns the full SHA of the commit rev names.
+	ResolveCommit(ctx context.Context, rev string) (string, error)
+	// LogCommits lists commits newest first. When ctx ends it returns the
+	// commits read so far with an error wrapping ctx.Err().
+	LogCommits(ctx context.Context, opts LogOptions) ([]model.Commit, error)
+	// NumstatForCommits reads sizes with up to jobs workers; see Jobs. When
+	// ctx ends it returns the sizes read so far with the error.
+	NumstatForCommits(ctx context.Context, shas []string, jobs int) (map[string]model.CommitSize, error)
+	NumstatForLog(ctx context.Context, opts LogOptions) (map[string]model.CommitSize, error)
+	// FileStats returns the per-file numstat of one commit.
+	FileStats(ctx context.Context, sha string) ([]model.FileStat, error)
+	Close() error
+}
+
+// Open returns the named backend for the repository rooted at repo. An empty
+// name selects the exec backend.
+func Open(name, repo string) (Backend, error) {
+	switch name {
+	case "", BackendExec:
+		return execBackend{repo: repo}, nil
+	case BackendNative:
+		return openNative(repo)
+	}
+	return nil, fmt.Errorf("unknown backend %q", name)
+}
+
+// execBackend runs the git binary for every operation.
+type execBackend struct {
+	repo string
+}
+
+func (b execBackend) IsRepo(ctx context.Context) bool {
+	return IsGitRepo(ctx, b.repo)
+}
+
+func (b execBackend) HeadSHA(ctx context.Context) (string, error) {
+	return HeadSHA(ctx, b.repo)
+}
+
+func (b execBackend) GitDir(ctx context.Context) (string, error) {
+	return GitDir(ctx, b.repo)
+}
+
+func (b execBackend) ShallowCommits(ctx context.Context) ([]string, error) {
+	return ShallowCommits(ctx, b.repo)
+}
+
+func (b execBackend) Branches(ctx context.Context) ([]string, error) {
+	return Branches(ctx, b.repo)
+}
+
+func (b execBackend) ResolveCommit(ctx context.Context, rev string) (string, error) {
+	return ResolveCommit(ctx, b.repo, rev)
+}
+
+func (b execBackend) LogCommits(ctx context.Context, opts LogOptions) ([]model.Commit, error) {
+	return LogCommits(ctx, b.repo, opts)
+}
+
+func (b execBackend) NumstatForCommits(ctx context.Context, shas []string, jobs int) (map[string]model.CommitSize, error) {
+	return NumstatForCommits(ctx, b.repo, shas, jobs)
+}
+
+func (b execBackend) NumstatForLog(ctx context.Context, opts LogOptions) (map[string]model.CommitSize, error) {
+	return NumstatForLog(ctx, b.repo, opts)
+}
+
+func (b execBackend) FileStats(ctx context.Context, sha string) ([]model.FileStat, error) {
+	return FileStats(ctx, b.repo, sha)
+}
+
+func (b execBackend) Close() error {
+	return nil
+}
+
+// FileStatsForCommits reads the per-file numstat of each commit through b
+// on up to jobs workers; see Jobs. When ctx ends it returns the commits
+// read so far with the error.
+func FileStatsForCommits(ctx context.Context, b Backend, shas []string, jobs int) (map[string][]model.FileStat, error) {
+	files := make([][]model.FileStat, len(shas))
+	err := runBatches(ctx, len(shas), jobs, func(ctx context.Context, i int) error {
+		stats, err := b.FileStats(ctx, shas[i])
+		if err == nil {
+			files[i] = stats
+		}
+		return err
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, err
+	}
+	result := make(map[string][]model.FileStat, len(shas))
+	for i, sha := range shas {
+		if files[i] != nil {
+			result[sha] = files[i]
+		}
+	}
+	return result, err
+}
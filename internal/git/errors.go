@@ -0,0 +1,151 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+
+	"roastgit/internal/gitobj"
+)
+
+// Common git failures. A *GitError matches one of them with errors.Is when
+// git's stderr identifies it; the native backend wraps them directly.
+var (
+	ErrBadRevision = errors.New("unknown revision")
+	ErrUnbornHead  = gitobj.ErrUnbornHead
+	// ErrShallow is a revision or object missing because the repository
+	// is a shallow clone.
+	ErrShallow = errors.New("history is cut off by a shallow clone")
+	// ErrUnsafeRepo is git refusing a repository owned by another user
+	// until it is listed in safe.directory.
+	ErrUnsafeRepo = errors.New("repository is owned by another user")
+)
+
+// GitError is a git command that exited with an error.
+type GitError struct {
+	// Dir is the repository the command ran in.
+	Dir  string
+	Args []string
+	// ExitCode is git's exit status, or -1 when it did not exit normally.
+	ExitCode int
+	// Stderr is what git printed on stderr, trimmed.
+	Stderr string
+	// Kind is the failure git's stderr identifies, one of the Err values
+	// above or ErrNotRepo, or nil.
+	Kind error
+	Err  error
+}
+
+func (e *GitError) Error() string {
+	args := e.Args
+	if len(args) > 6 {
+		args = append(args[:6:6], "...")
+	}
+	msg := "git " + strings.Join(args, " ") + ": " + e.Err.Error()
+	if line := firstLine(e.Stderr); line != "" {
+		msg += ": " + line
+	}
+	return msg
+}
+
+func (e *GitError) Unwrap() error { return e.Err }
+
+// Is reports whether target is the classified kind of the failure.
+func (e *GitError) Is(target error) bool {
+	return e.Kind != nil && e.Kind == target
+}
+
+// newGitError describes a failed command from its exit error and stderr.
+// A missing revision in a shallow clone is classified as ErrShallow.
+func newGitError(ctx context.Context, repo string, args []string, err error, stderr string) *GitError {
+	e := &GitError{Dir: repo, Args: args, ExitCode: -1, Stderr: strings.TrimSpace(stderr), Err: err}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		e.ExitCode = exitErr.ExitCode()
+	}
+	e.Kind = classify(e.Stderr)
+	if e.Kind == ErrBadRevision && isShallow(ctx, repo) {
+		e.Kind = ErrShallow
+	}
+	return e
+}
+
+// classify maps git's stderr to one of the common failures.
+func classify(stderr string) error {
+	s := strings.ToLower(stderr)
+	switch {
+	case strings.Contains(s, "detected dubious ownership"):
+		return ErrUnsafeRepo
+	case strings.Contains(s, "not a git repository"):
+		return ErrNotRepo
+	case strings.Contains(s, "does not have any commits yet"),
+		strings.Contains(s, "ambiguous argument 'head'"),
+		strings.Contains(s, "bad default revision 'head'"):
+		return ErrUnbornHead
+	case strings.Contains(s, "unknown revision"),
+		strings.Contains(s, "bad revision"),
+		strings.Contains(s, "bad object"),
+		strings.Contains(s, "invalid revision range"),
+		strings.Contains(s, "invalid object name"),
+		strings.Contains(s, "unable to read"):
+		return ErrBadRevision
+	}
+	return nil
+}
+
+// Summary is the first line git printed for a failed command, without its
+// "fatal: " prefix, or err's message when git printed nothing.
+func Summary(err error) string {
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && gitErr.Stderr != "" {
+		return strings.TrimPrefix(firstLine(gitErr.Stderr), "fatal: ")
+	}
+	return err.Error()
+}
+
+// Reason is the one-line reason a repository could not be analyzed, as
+// listed next to it in combined reports.
+func Reason(err error) string {
+	switch {
+	case errors.Is(err, ErrNotRepo):
+		return "not a git repository"
+	case errors.Is(err, ErrUnsafeRepo):
+		return "owned by another user; add it to safe.directory to read it"
+	case errors.Is(err, ErrUnbornHead):
+		return "HEAD has no commits yet"
+	}
+	return Summary(err)
+}
+
+// isShallow reports whether repo is a shallow clone.
+func isShallow(ctx context.Context, repo string) bool {
+	out, err := runGit(ctx, repo, []string{"rev-parse", "--is-shallow-repository"})
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(line)
+}
+
+// stderrBuffer keeps the start of a command's stderr, enough to classify
+// it, without letting a chatty command grow it without bound.
+type stderrBuffer struct {
+	b strings.Builder
+}
+
+const maxStderr = 64 << 10
+
+func (w *stderrBuffer) Write(p []byte) (int, error) {
+	if room := maxStderr - w.b.Len(); room > 0 {
+		if len(p) > room {
+			w.b.Write(p[:room])
+		} else {
+			w.b.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (w *stderrBuffer) String() string { return w.b.String() }
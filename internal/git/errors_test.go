@@ -0,0 +1,63 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	cases := map[string]error{
+		"fatal: detected dubious ownership in repository at '/srv/repo'":                        ErrUnsafeRepo,
+		"fatal: not a git repository (or any of the parent directories): .git":                  ErrNotRepo,
+		"fatal: your current branch 'main' does not have any commits yet":                       ErrUnbornHead,
+		"fatal: ambiguous argument 'HEAD': unknown revision or path not in the working tree.":   ErrUnbornHead,
+		"fatal: ambiguous argument 'HEAD~3': unknown revision or path not in the working tree.": ErrBadRevision,
+		"fatal: bad revision 'v1..v2'":                                                          ErrBadRevision,
+		"fatal: bad object 0123456789abcdef0123456789abcdef01234567":                            ErrBadRevision,
+		"error: pathspec 'x' did not match any file(s) known to git":                            nil,
+	}
+	for stderr, want := range cases {
+		if got := classify(stderr); got != want {
+			t.Errorf("classify(%q) = %v, want %v", stderr, got, want)
+		}
+	}
+}
+
+func TestGitErrorFromGit(t *testing.T) {
+	dir := buildTestRepo(t)
+	ctx := context.Background()
+
+	_, err := LogCommits(ctx, dir, LogOptions{Revisions: []string{"nope..HEAD"}})
+	var gitErr *GitError
+	if !errors.As(err, &gitErr) {
+		t.Fatalf("err = %v, want a *GitError", err)
+	}
+	if gitErr.ExitCode != 128 || gitErr.Dir != dir || gitErr.Args[0] != "log" || !strings.Contains(gitErr.Stderr, "nope..HEAD") {
+		t.Fatalf("unexpected error fields: %+v", gitErr)
+	}
+	if !errors.Is(err, ErrBadRevision) || errors.Is(err, ErrShallow) {
+		t.Fatalf("err = %v, want it classified as a bad revision", err)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("err = %v does not unwrap to the exit error", err)
+	}
+
+	empty := t.TempDir()
+	if out, err := exec.Command("git", "init", "-q", empty).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v\n%s", err, out)
+	}
+	for _, name := range []string{BackendExec, BackendNative} {
+		repo, err := Open(name, empty)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.HeadSHA(ctx); !errors.Is(err, ErrUnbornHead) {
+			t.Fatalf("%s HEAD of an empty repo: err = %v, want ErrUnbornHead", name, err)
+		}
+		repo.Close()
+	}
+}
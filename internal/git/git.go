@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
@@ -16,9 +18,33 @@ var (
 	ErrGitMissing = errors.New("git executable not found")
 )
 
-const defaultTimeout = 30 * time.Second
+// DefaultCommandTimeout bounds each short metadata command (rev-parse,
+// for-each-ref, ...) unless WithCommandTimeout sets another limit.
+// Streaming log and numstat commands have no timeout of their own and run
+// until the caller's context ends, which --timeout sets.
+const DefaultCommandTimeout = 30 * time.Second
 
-// FindRepoRoot walks up from start to find a .git directory or file.
+type commandTimeoutKey struct{}
+
+// WithCommandTimeout returns a context under which each short git command
+// is bounded by d instead of DefaultCommandTimeout; 0 means no limit.
+func WithCommandTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, commandTimeoutKey{}, d)
+}
+
+// commandTimeout returns the limit of a short git command run under ctx.
+func commandTimeout(ctx context.Context) time.Duration {
+	if d, ok := ctx.Value(commandTimeoutKey{}).(time.Duration); ok {
+		return d
+	}
+	return DefaultCommandTimeout
+}
+
+// FindRepoRoot walks up from start to the nearest directory with a .git
+// directory or file (a work tree, including linked worktrees) or that is a
+// bare repository itself. As for git, GIT_DIR overrides the search: the
+// repository is then GIT_WORK_TREE, the git directory when it is bare, or
+// start.
 func FindRepoRoot(start string) (string, error) {
 	if start == "" {
 		start = "."
@@ -27,11 +53,17 @@ func FindRepoRoot(start string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if gitDir := os.Getenv("GIT_DIR"); gitDir != "" {
+		return envRepoRoot(gitDir, abs)
+	}
 	for {
 		gitPath := filepath.Join(abs, ".git")
 		if _, err := os.Stat(gitPath); err == nil {
 			return abs, nil
 		}
+		if IsGitDir(abs) {
+			return abs, nil
+		}
 		parent := filepath.Dir(abs)
 		if parent == abs {
 			return "", ErrNotRepo
@@ -40,6 +72,72 @@ func FindRepoRoot(start string) (string, error) {
 	}
 }
 
+// envRepoRoot resolves the repository named by GIT_DIR and GIT_WORK_TREE.
+func envRepoRoot(gitDir, start string) (string, error) {
+	gitDir, err := filepath.Abs(gitDir)
+	if err != nil {
+		return "", err
+	}
+	if !IsGitDir(gitDir) {
+		return "", ErrNotRepo
+	}
+	if tree := os.Getenv("GIT_WORK_TREE"); tree != "" {
+		return filepath.Abs(tree)
+	}
+	if IsBare(gitDir) {
+		return gitDir, nil
+	}
+	return start, nil
+}
+
+// IsGitDir reports whether dir is a git directory, using the same test as
+// git: it has a HEAD file and objects and refs directories.
+func IsGitDir(dir string) bool {
+	if info, err := os.Stat(filepath.Join(dir, "HEAD")); err != nil || info.IsDir() {
+		return false
+	}
+	for _, sub := range []string{"objects", "refs"} {
+		if info, err := os.Stat(filepath.Join(dir, sub)); err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// IsBare reports whether the git directory gitDir has core.bare set.
+func IsBare(gitDir string) bool {
+	data, err := os.ReadFile(filepath.Join(gitDir, "config"))
+	if err != nil {
+		return false
+	}
+	section := ""
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(strings.Trim(line, "[] \t"))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && section == "core" && strings.EqualFold(strings.TrimSpace(key), "bare") {
+			return strings.EqualFold(strings.TrimSpace(value), "true")
+		}
+	}
+	return false
+}
+
+// RepoName names a repository after its directory, dropping the .git
+// suffix of bare repositories ("site.git" and "site/.git" are "site").
+func RepoName(repo string) string {
+	name := filepath.Base(repo)
+	if name == ".git" {
+		name = filepath.Base(filepath.Dir(repo))
+	}
+	if trimmed := strings.TrimSuffix(name, ".git"); trimmed != "" {
+		name = trimmed
+	}
+	return name
+}
+
 // EnsureGit checks if git is available.
 func EnsureGit() error {
 	if _, err := exec.LookPath("git"); err != nil {
@@ -57,44 +155,143 @@ func HeadSHA(ctx context.Context, repo string) (string, error) {
 	return strings.TrimSpace(out), nil
 }
 
-// runGit executes git and returns stdout.
+// GitDir returns the absolute git common directory, which linked worktrees
+// share with the main work tree.
+func GitDir(ctx context.Context, repo string) (string, error) {
+	out, err := runGit(ctx, repo, []string{"rev-parse", "--git-common-dir"})
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo, dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+// ShallowCommits returns the sorted boundary commits listed in the shallow
+// file of a shallow clone, or nil for a full clone.
+func ShallowCommits(ctx context.Context, repo string) ([]string, error) {
+	if !isShallow(ctx, repo) {
+		return nil, nil
+	}
+	out, err := runGit(ctx, repo, []string{"rev-parse", "--git-path", "shallow"})
+	if err != nil {
+		return nil, err
+	}
+	path := strings.TrimSpace(out)
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repo, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	shas := strings.Fields(string(data))
+	sort.Strings(shas)
+	return shas, nil
+}
+
+// ResolveCommit returns the full SHA of the commit rev names.
+func ResolveCommit(ctx context.Context, repo, rev string) (string, error) {
+	out, err := runGit(ctx, repo, []string{"rev-parse", "--verify", "--quiet", "--end-of-options", rev + "^{commit}"})
+	if err != nil {
+		var gitErr *GitError
+		if errors.As(err, &gitErr) && gitErr.Kind != nil {
+			return "", err
+		}
+		if isShallow(ctx, repo) {
+			return "", fmt.Errorf("unknown revision %q: %w", rev, ErrShallow)
+		}
+		return "", fmt.Errorf("%w %q", ErrBadRevision, rev)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// runGit executes a short git command, bounded by commandTimeout and ctx,
+// and returns stdout.
 func runGit(ctx context.Context, repo string, args []string) (string, error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
-		defer cancel()
+	timeout := commandTimeout(ctx)
+	var opCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		opCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		opCtx, cancel = context.WithCancel(ctx)
 	}
-	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repo}, args...)...)
+	defer cancel()
+	cmd := exec.CommandContext(opCtx, "git", append([]string{"-C", repo}, args...)...)
 	cmd.Env = os.Environ()
-	out, err := cmd.CombinedOutput()
+	stderr := &stderrBuffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
 	if err != nil {
-		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), ctx.Err())
+		}
+		if opCtx.Err() != nil {
+			return "", fmt.Errorf("git %s: timed out after %s", strings.Join(args, " "), timeout)
+		}
+		return "", newGitError(ctx, repo, args, err, stderr.String())
 	}
 	return string(out), nil
 }
 
-// runGitStreaming returns an exec.Cmd ready to start with stdout pipe.
-func runGitStreaming(ctx context.Context, repo string, args []string) (*exec.Cmd, func(), error) {
+// runGitStreaming returns an exec.Cmd ready to start with stdout pipe,
+// and the buffer collecting its stderr. It is killed when ctx ends; use
+// waitErr to report its exit.
+func runGitStreaming(ctx context.Context, repo string, args []string) (*exec.Cmd, *stderrBuffer, func()) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	cancel := func() {}
-	if _, hasDeadline := ctx.Deadline(); !hasDeadline {
-		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
-	}
+	ctx, cancel := context.WithCancel(ctx)
 	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", repo}, args...)...)
 	cmd.Env = os.Environ()
-	return cmd, cancel, nil
+	stderr := &stderrBuffer{}
+	cmd.Stderr = stderr
+	return cmd, stderr, cancel
 }
 
-// IsGitRepo verifies if repo is a git work tree.
-func IsGitRepo(ctx context.Context, repo string) bool {
-	out, err := runGit(ctx, repo, []string{"rev-parse", "--is-inside-work-tree"})
+// streamGit runs a streaming git command and hands its stdout to parse.
+// When parse fails, git is killed before waiting for it: with nobody
+// reading the pipe it would otherwise block forever once the pipe fills.
+func streamGit(ctx context.Context, repo string, args []string, parse func(io.Reader) error) error {
+	cmd, stderr, cancel := runGitStreaming(ctx, repo, args)
+	defer cancel()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return false
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if parseErr := parse(stdout); parseErr != nil {
+		cancel()
+		if err := cmd.Wait(); err != nil && ctx.Err() != nil {
+			return waitErr(ctx, repo, args, err, stderr)
+		}
+		return parseErr
+	}
+	if err := cmd.Wait(); err != nil {
+		return waitErr(ctx, repo, args, err, stderr)
 	}
-	return strings.TrimSpace(out) == "true"
+	return nil
+}
+
+// waitErr describes the failure of a streaming git command, preferring the
+// context's error when ctx ended and killed it.
+func waitErr(ctx context.Context, repo string, args []string, err error, stderr *stderrBuffer) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("git %s: %w", args[0], ctx.Err())
+	}
+	return newGitError(ctx, repo, args, err, stderr.String())
+}
+
+// IsGitRepo verifies if repo is a git repository, bare or with a work tree.
+func IsGitRepo(ctx context.Context, repo string) bool {
+	_, err := runGit(ctx, repo, []string{"rev-parse", "--git-dir"})
+	return err == nil
 }
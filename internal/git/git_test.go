@@ -0,0 +1,213 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindRepoRootLayouts(t *testing.T) {
+	dir := buildTestRepo(t)
+	tmp := t.TempDir()
+	bare := filepath.Join(tmp, "site.git")
+	worktree := filepath.Join(tmp, "wt")
+	for _, args := range [][]string{
+		{"clone", "-q", "--bare", dir, bare},
+		{"-C", dir, "worktree", "add", "-q", worktree, "feature"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	cases := map[string]string{
+		filepath.Join(dir, "docs"):        dir,
+		filepath.Join(bare, "refs/heads"): bare,
+		worktree:                          worktree,
+	}
+	for start, want := range cases {
+		if got, err := FindRepoRoot(start); err != nil || got != want {
+			t.Fatalf("FindRepoRoot(%s) = %q, %v; want %q", start, got, err, want)
+		}
+	}
+	if _, err := FindRepoRoot(tmp); err != ErrNotRepo {
+		t.Fatalf("FindRepoRoot outside a repo: err = %v", err)
+	}
+	if !IsBare(bare) || IsBare(filepath.Join(dir, ".git")) {
+		t.Fatal("IsBare misread core.bare")
+	}
+
+	t.Setenv("GIT_DIR", bare)
+	if got, err := FindRepoRoot(tmp); err != nil || got != bare {
+		t.Fatalf("with GIT_DIR of a bare repo: %q, %v", got, err)
+	}
+	t.Setenv("GIT_DIR", filepath.Join(dir, ".git"))
+	if got, err := FindRepoRoot(tmp); err != nil || got != tmp {
+		t.Fatalf("with GIT_DIR of a work tree: %q, %v; want the start directory", got, err)
+	}
+	t.Setenv("GIT_WORK_TREE", dir)
+	if got, err := FindRepoRoot(tmp); err != nil || got != dir {
+		t.Fatalf("with GIT_WORK_TREE: %q, %v", got, err)
+	}
+	os.Unsetenv("GIT_DIR")
+	os.Unsetenv("GIT_WORK_TREE")
+
+	ctx := context.Background()
+	want, err := HeadSHA(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{BackendExec, BackendNative} {
+		repo, err := Open(name, bare)
+		if err != nil {
+			t.Fatalf("%s: open bare: %v", name, err)
+		}
+		if !repo.IsRepo(ctx) {
+			t.Fatalf("%s: bare repo not recognized", name)
+		}
+		if got, err := repo.HeadSHA(ctx); err != nil || got != want {
+			t.Fatalf("%s: bare HEAD = %q, %v; want %q", name, got, err, want)
+		}
+		if got, err := repo.GitDir(ctx); err != nil || got != bare {
+			t.Fatalf("%s: bare git dir = %q, %v", name, got, err)
+		}
+		repo.Close()
+	}
+}
+
+func TestRepoName(t *testing.T) {
+	cases := map[string]string{
+		"/srv/git/site.git":  "site",
+		"/home/me/site":      "site",
+		"/home/me/site/.git": "site",
+		"/srv/.git":          "srv",
+	}
+	for in, want := range cases {
+		if got := RepoName(in); got != want {
+			t.Fatalf("RepoName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestShallowClone(t *testing.T) {
+	dir := buildTestRepo(t)
+	clone := filepath.Join(t.TempDir(), "shallow")
+	if out, err := exec.Command("git", "clone", "-q", "--depth", "2", "file://"+dir, clone).CombinedOutput(); err != nil {
+		t.Fatalf("git clone: %v\n%s", err, out)
+	}
+	ctx := context.Background()
+	execRepo, _ := Open(BackendExec, clone)
+	native, err := Open(BackendNative, clone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer native.Close()
+
+	want, err := execRepo.ShallowCommits(ctx)
+	if err != nil || len(want) == 0 {
+		t.Fatalf("exec shallow commits = %v, %v", want, err)
+	}
+	if got, err := native.ShallowCommits(ctx); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("native shallow commits = %v, %v; want %v", got, err, want)
+	}
+	if got, _ := ShallowCommits(ctx, dir); got != nil {
+		t.Fatalf("full clone reported shallow commits %v", got)
+	}
+
+	// The native backend must stop at the boundary like git does, instead
+	// of failing on parents that were never fetched.
+	wantLog, err := execRepo.LogCommits(ctx, LogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotLog, err := native.LogCommits(ctx, LogOptions{})
+	if err != nil || len(gotLog) != len(wantLog) {
+		t.Fatalf("native log = %d commits, %v; want %d", len(gotLog), err, len(wantLog))
+	}
+	for i := range wantLog {
+		if gotLog[i].SHA != wantLog[i].SHA || !reflect.DeepEqual(gotLog[i].Parents, wantLog[i].Parents) {
+			t.Fatalf("commit %d: got %s %v, want %s %v", i, gotLog[i].SHA, gotLog[i].Parents, wantLog[i].SHA, wantLog[i].Parents)
+		}
+	}
+}
+
+func TestParseGitmodules(t *testing.T) {
+	data := "[submodule \"ui\"]\n\tpath = libs/ui\n\turl = ../ui.git\n" +
+		"# comment\n[core]\n\tpath = nope\n" +
+		"[submodule \"core lib\"]\n\tpath = \"libs/core\"\n" +
+		"[submodule \"etc\"]\n\tpath = /etc\n" +
+		"[submodule \"up\"]\n\tpath = libs/../../outside\n" +
+		"[submodule \"win\"]\n\tpath = ..\\outside\n" +
+		"[submodule \"dots\"]\n\tpath = libs/..ui\n"
+	got := parseGitmodules(data)
+	if want := []string{"libs/..ui", "libs/core", "libs/ui"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("paths = %v, want %v", got, want)
+	}
+}
+
+func TestRefsState(t *testing.T) {
+	dir := buildTestRepo(t)
+	gitDir := filepath.Join(dir, ".git")
+	worktree := filepath.Join(t.TempDir(), "wt")
+	commit := func(in string) {
+		t.Helper()
+		cmd := exec.Command("git", "commit", "-q", "--allow-empty", "-m", "more")
+		cmd.Dir = in
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane", "GIT_AUTHOR_EMAIL=jane@example.com",
+			"GIT_COMMITTER_NAME=Jane", "GIT_COMMITTER_EMAIL=jane@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git commit: %v\n%s", err, out)
+		}
+	}
+	if out, err := exec.Command("git", "-C", dir, "worktree", "add", "-q", worktree, "feature").CombinedOutput(); err != nil {
+		t.Fatalf("git worktree add: %v\n%s", err, out)
+	}
+
+	before := RefsState(gitDir)
+	if before == "" || RefsState(gitDir) != before {
+		t.Fatalf("state should be stable while nothing changes: %q", before)
+	}
+	commit(dir)
+	after := RefsState(gitDir)
+	if after == before {
+		t.Fatal("state did not change after a commit")
+	}
+	commit(worktree)
+	if RefsState(gitDir) == after {
+		t.Fatal("state did not change after a commit in a linked worktree")
+	}
+}
+
+func TestStreamGitStopsOnParseError(t *testing.T) {
+	if err := EnsureGit(); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	// The diff is larger than a pipe buffer, so git blocks unless killed.
+	if err := os.WriteFile(filepath.Join(dir, "big.txt"), []byte(strings.Repeat("roast\n", 100000)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-q", "-m", "Add a big file"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane", "GIT_AUTHOR_EMAIL=jane@example.com",
+			"GIT_COMMITTER_NAME=Jane", "GIT_COMMITTER_EMAIL=jane@example.com", "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	bad := errors.New("bad record")
+	err := streamGit(ctx, dir, []string{"show", "HEAD"}, func(io.Reader) error { return bad })
+	if !errors.Is(err, bad) || ctx.Err() != nil {
+		t.Fatalf("err = %v (ctx %v), want the parse error before the deadline", err, ctx.Err())
+	}
+}
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"roastgit/internal/model"
+)
+
+// HooksDir returns the directory git runs hooks from, honoring
+// core.hooksPath and linked worktrees.
+func HooksDir(ctx context.Context, repo string) (string, error) {
+	out, err := runGit(ctx, repo, []string{"rev-parse", "--git-path", "hooks"})
+	if err != nil {
+		return "", err
+	}
+	dir := strings.TrimSpace(out)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo, dir)
+	}
+	return dir, nil
+}
+
+// SetConfig sets a local git config value.
+func SetConfig(ctx context.Context, repo, key, value string) error {
+	_, err := runGit(ctx, repo, []string{"config", key, value})
+	return err
+}
+
+// ConfigValue returns a git config value, or "" when it is not set. Outside
+// a repository, with repo ".", it reads the global and system config.
+func ConfigValue(ctx context.Context, repo, key string) (string, error) {
+	out, err := runGit(ctx, repo, []string{"config", "--get", key})
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && gitErr.ExitCode == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// StagedSize summarizes the changes staged in the index.
+func StagedSize(ctx context.Context, repo string) (model.CommitSize, error) {
+	out, err := runGit(ctx, repo, []string{"diff", "--cached", "--numstat"})
+	if err != nil {
+		return model.CommitSize{}, err
+	}
+	// Give the output a fake commit header so ParseNumstat can be reused.
+	stats, err := ParseNumstat(strings.NewReader("index" + string([]byte{unitSep}) + "\n" + out))
+	if err != nil {
+		return model.CommitSize{}, err
+	}
+	return stats["index"], nil
+}
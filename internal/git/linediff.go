@@ -0,0 +1,112 @@
+package git
+
+import "bytes"
+
+// binaryProbe is how many leading bytes git inspects for NULs to decide a
+// file is binary.
+const binaryProbe = 8000
+
+// maxEditDistance bounds the exact diff. Past it, line counts fall back to
+// comparing the lines as multisets, which is exact for rewrites and close
+// otherwise.
+const maxEditDistance = 4096
+
+func isBinary(data []byte) bool {
+	if len(data) > binaryProbe {
+		data = data[:binaryProbe]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// lineChanges counts the lines added and deleted between two versions of a
+// file, as git diff --numstat reports them.
+func lineChanges(oldData, newData []byte) (added, deleted int) {
+	a, b := splitLines(oldData), splitLines(newData)
+	for len(a) > 0 && len(b) > 0 && bytes.Equal(a[0], b[0]) {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && bytes.Equal(a[len(a)-1], b[len(b)-1]) {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return len(b), len(a)
+	}
+	ids := map[string]int{}
+	intern := func(lines [][]byte) []int {
+		out := make([]int, len(lines))
+		for i, l := range lines {
+			id, ok := ids[string(l)]
+			if !ok {
+				id = len(ids)
+				ids[string(l)] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	x, y := intern(a), intern(b)
+	if d, ok := editDistance(x, y, maxEditDistance); ok {
+		delta := len(y) - len(x)
+		return (d + delta) / 2, (d - delta) / 2
+	}
+	counts := make([]int, len(ids))
+	for _, id := range x {
+		counts[id]++
+	}
+	for _, id := range y {
+		counts[id]--
+	}
+	for _, c := range counts {
+		if c > 0 {
+			deleted += c
+		} else {
+			added -= c
+		}
+	}
+	return added, deleted
+}
+
+// splitLines splits data into lines that keep their terminators, so a
+// missing final newline counts as a change like git's diff does.
+func splitLines(data []byte) [][]byte {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editDistance returns the number of insertions plus deletions turning a
+// into b (Myers' O(ND) algorithm), or ok=false when it exceeds limit.
+func editDistance(a, b []int, limit int) (int, bool) {
+	n, m := len(a), len(b)
+	max := n + m
+	if max > limit {
+		max = limit
+	}
+	off := max + 1
+	v := make([]int, 2*max+3)
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return d, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -16,6 +16,8 @@ type LogOptions struct {
 	Until      string
 	Author     string
 	MaxCommits int
+	// Revisions limits the walk, e.g. "v1.0..v2.0". Empty means HEAD.
+	Revisions []string
 }
 
 const (
@@ -23,9 +25,33 @@ const (
 	unitSep   byte = 0x1f
 )
 
-// LogCommits streams git log and returns parsed commits.
+// LogCommits streams git log and returns parsed commits. When ctx ends
+// first, it returns the commits read completely so far together with an
+// error wrapping ctx.Err().
 func LogCommits(ctx context.Context, repo string, opts LogOptions) ([]model.Commit, error) {
-	args := []string{"log", "--date=iso-strict", "--pretty=format:%H%x1f%an%x1f%ae%x1f%ad%x1f%s%x1f%P%x1e"}
+	args := logArgs([]string{"log", "--date=iso-strict", "--pretty=format:%H%x1f%an%x1f%ae%x1f%ad%x1f%s%x1f%P%x1f%b%x1e"}, opts)
+	var commits []model.Commit
+	var complete bool
+	err := streamGit(ctx, repo, args, func(r io.Reader) error {
+		var err error
+		commits, complete, err = parseLog(r)
+		return err
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			return nil, err
+		}
+		if !complete && len(commits) > 0 {
+			// The last record was cut off before its separator.
+			commits = commits[:len(commits)-1]
+		}
+		return commits, err
+	}
+	return commits, nil
+}
+
+// logArgs appends the filters in opts to a git log command line.
+func logArgs(args []string, opts LogOptions) []string {
 	if opts.Since != "" {
 		args = append(args, "--since", opts.Since)
 	}
@@ -38,43 +64,37 @@ func LogCommits(ctx context.Context, repo string, opts LogOptions) ([]model.Comm
 	if opts.MaxCommits > 0 {
 		args = append(args, "-n", intToString(opts.MaxCommits))
 	}
-	cmd, cancel, err := runGitStreaming(ctx, repo, args)
-	if err != nil {
-		return nil, err
-	}
-	defer cancel()
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	stderr, err := cmd.StderrPipe()
-	if err != nil {
-		return nil, err
-	}
-	if err := cmd.Start(); err != nil {
-		return nil, err
+	if len(opts.Revisions) > 0 {
+		args = append(args, opts.Revisions...)
+		args = append(args, "--")
 	}
-	commits, parseErr := ParseLog(stdout)
-	// drain stderr for git errors
-	_, _ = io.ReadAll(stderr)
-	if err := cmd.Wait(); err != nil {
-		return nil, err
-	}
-	if parseErr != nil {
-		return nil, parseErr
-	}
-	return commits, nil
+	return args
 }
 
-// ParseLog parses commits from git log output.
+// ParseLog parses commits from git log output. Records have no size limit:
+// commit bodies can be arbitrarily large. On a read error or a malformed
+// date it returns the commits parsed before it together with the error.
 func ParseLog(r io.Reader) ([]model.Commit, error) {
-	scanner := bufio.NewScanner(r)
-	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
-	scanner.Split(splitOnRecordSep)
+	commits, _, err := parseLog(r)
+	return commits, err
+}
+
+// parseLog is ParseLog, also telling whether the last commit returned was
+// ended by a record separator rather than by the end of the input.
+func parseLog(r io.Reader) ([]model.Commit, bool, error) {
+	br := bufio.NewReaderSize(r, 64*1024)
 	commits := make([]model.Commit, 0, 256)
-	for scanner.Scan() {
-		rec := strings.TrimSpace(scanner.Text())
+	complete := true
+	for {
+		raw, readErr := br.ReadString(recordSep)
+		if readErr != nil && readErr != io.EOF {
+			return commits, complete, readErr
+		}
+		rec := strings.TrimSpace(strings.TrimSuffix(raw, string(recordSep)))
 		if rec == "" {
+			if readErr == io.EOF {
+				break
+			}
 			continue
 		}
 		fields := splitFields(rec, unitSep)
@@ -83,7 +103,7 @@ func ParseLog(r io.Reader) ([]model.Commit, error) {
 		}
 		date, err := time.Parse(time.RFC3339, fields[3])
 		if err != nil {
-			return nil, err
+			return commits, complete, err
 		}
 		parents := []string{}
 		if strings.TrimSpace(fields[5]) != "" {
@@ -97,24 +117,16 @@ func ParseLog(r io.Reader) ([]model.Commit, error) {
 			Subject:     fields[4],
 			Parents:     parents,
 		}
+		if len(fields) > 6 {
+			commit.Body = strings.TrimSpace(fields[6])
+		}
 		commits = append(commits, commit)
-	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-	return commits, nil
-}
-
-func splitOnRecordSep(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	for i, b := range data {
-		if b == recordSep {
-			return i + 1, data[:i], nil
+		complete = readErr == nil
+		if readErr == io.EOF {
+			break
 		}
 	}
-	if atEOF && len(data) > 0 {
-		return len(data), data, nil
-	}
-	return 0, nil, nil
+	return commits, complete, nil
 }
 
 func splitFields(s string, sep byte) []string {
@@ -1,9 +1,13 @@
 package git
 
 import (
+	"context"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseLogFromFixture(t *testing.T) {
@@ -23,4 +27,77 @@ func TestParseLogFromFixture(t *testing.T) {
 	if commits[0].SHA == "" || commits[1].AuthorEmail == "" {
 		t.Fatalf("expected fields populated")
 	}
+	if commits[0].Body != "Explain the bug.\n\nRoastgit-Ignore: binary" || commits[1].Body != "" {
+		t.Fatalf("unexpected bodies: %q, %q", commits[0].Body, commits[1].Body)
+	}
+}
+
+func TestParseLogLargeBody(t *testing.T) {
+	body := strings.Repeat("A very long stack trace line.\n", 45000) // over 1 MB
+	text := "abc" + string(unitSep) + "Jane" + string(unitSep) + "jane@example.com" + string(unitSep) +
+		"2024-02-01T10:00:00+01:00" + string(unitSep) + "Paste the crash log" + string(unitSep) + string(unitSep) +
+		body + string(recordSep) + "\n" +
+		"def" + string(unitSep) + "Jane" + string(unitSep) + "jane@example.com" + string(unitSep) +
+		"2024-02-01T09:00:00+01:00" + string(unitSep) + "Initial commit" + string(unitSep) + string(unitSep) + string(recordSep)
+	commits, err := ParseLog(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("parse log: %v", err)
+	}
+	if len(commits) != 2 || len(commits[0].Body) != len(body)-1 || commits[1].Subject != "Initial commit" {
+		t.Fatalf("unexpected commits: %d, body of %d bytes", len(commits), len(commits[0].Body))
+	}
+}
+
+func TestLogCommitsLargeBody(t *testing.T) {
+	if err := EnsureGit(); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	msg := filepath.Join(t.TempDir(), "msg")
+	if err := os.WriteFile(msg, []byte("Paste the crash log\n\n"+strings.Repeat("A very long stack trace line.\n", 45000)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"commit", "-q", "--allow-empty", "-F", msg}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane", "GIT_AUTHOR_EMAIL=jane@example.com",
+			"GIT_COMMITTER_NAME=Jane", "GIT_COMMITTER_EMAIL=jane@example.com", "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	commits, err := LogCommits(ctx, dir, LogOptions{})
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if len(commits) != 1 || len(commits[0].Body) < 1024*1024 {
+		t.Fatalf("expected one commit with its full body, got %d", len(commits))
+	}
+}
+
+func logRecord(sha, date, subject string) string {
+	return sha + string(unitSep) + "Jane" + string(unitSep) + "jane@example.com" + string(unitSep) +
+		date + string(unitSep) + subject + string(unitSep) + string(unitSep)
+}
+
+func TestParseLogCutOff(t *testing.T) {
+	whole := logRecord("abc", "2024-02-01T10:00:00+01:00", "Add parser") + string(recordSep) + "\n"
+	commits, complete, err := parseLog(strings.NewReader(whole))
+	if err != nil || len(commits) != 1 || !complete {
+		t.Fatalf("whole record: %d commits, complete %v, err %v", len(commits), complete, err)
+	}
+
+	cut := whole + logRecord("def", "2024-02-01T09:00:00+01:00", "Initial") + "half a bo"
+	commits, complete, err = parseLog(strings.NewReader(cut))
+	if err != nil || len(commits) != 2 || complete {
+		t.Fatalf("cut record: %d commits, complete %v, err %v", len(commits), complete, err)
+	}
+
+	badDate := whole + logRecord("def", "2024-02-0", "Initial")
+	commits, err = ParseLog(strings.NewReader(badDate))
+	if err == nil || len(commits) != 1 || commits[0].SHA != "abc" {
+		t.Fatalf("bad date: %d commits, err %v", len(commits), err)
+	}
 }
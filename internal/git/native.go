@@ -0,0 +1,784 @@
+package git
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"roastgit/internal/gitobj"
+	"roastgit/internal/model"
+)
+
+// nativeBackend reads the object database without a git binary. It supports
+// the revision syntax roastgit itself generates: names, abbreviated SHAs,
+// ~N/^N suffixes, A..B, ^X, --not, --all, --branches, --tags and
+// --remotes[=name]. Date filters compare committer dates and accept
+// YYYY-MM-DD (at the current time of day, as git does), "YYYY-MM-DD HH:MM:SS -0700" and RFC 3339.
+// Renames are only detected when the content is unchanged.
+type nativeBackend struct {
+	repo string
+	db   *gitobj.Repo
+}
+
+func openNative(repo string) (*nativeBackend, error) {
+	gitDir, err := findGitDir(repo)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gitobj.Open(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	return &nativeBackend{repo: repo, db: db}, nil
+}
+
+// findGitDir locates the git directory of a repository: GIT_DIR when set,
+// repo itself when it is bare, or the .git of a work tree, following the
+// "gitdir:" file of linked worktrees and submodules.
+func findGitDir(repo string) (string, error) {
+	if dir := os.Getenv("GIT_DIR"); dir != "" {
+		return filepath.Abs(dir)
+	}
+	if IsGitDir(repo) {
+		return repo, nil
+	}
+	dotGit := filepath.Join(repo, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", ErrNotRepo
+	}
+	if info.IsDir() {
+		return dotGit, nil
+	}
+	data, err := os.ReadFile(dotGit)
+	if err != nil {
+		return "", err
+	}
+	dir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", ErrNotRepo
+	}
+	dir = strings.TrimSpace(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(repo, dir)
+	}
+	return filepath.Clean(dir), nil
+}
+
+func (b *nativeBackend) IsRepo(ctx context.Context) bool {
+	return b.db != nil
+}
+
+func (b *nativeBackend) HeadSHA(ctx context.Context) (string, error) {
+	h, err := b.db.Head()
+	if err != nil {
+		return "", err
+	}
+	return h.String(), nil
+}
+
+func (b *nativeBackend) GitDir(ctx context.Context) (string, error) {
+	return filepath.Abs(b.db.CommonDir())
+}
+
+func (b *nativeBackend) ShallowCommits(ctx context.Context) ([]string, error) {
+	hashes := b.db.Shallow()
+	if hashes == nil {
+		return nil, nil
+	}
+	shas := make([]string, len(hashes))
+	for i, h := range hashes {
+		shas[i] = h.String()
+	}
+	return shas, nil
+}
+
+func (b *nativeBackend) Branches(ctx context.Context) ([]string, error) {
+	_, names, err := b.db.Refs("refs/heads/")
+	if err != nil {
+		return nil, err
+	}
+	branches := make([]string, 0, len(names))
+	for _, name := range names {
+		branches = append(branches, strings.TrimPrefix(name, "refs/heads/"))
+	}
+	return branches, nil
+}
+
+func (b *nativeBackend) ResolveCommit(ctx context.Context, rev string) (string, error) {
+	h, err := b.resolve(rev)
+	if err != nil {
+		return "", fmt.Errorf("%w %q", ErrBadRevision, rev)
+	}
+	c, err := b.db.Commit(h)
+	if err != nil {
+		return "", fmt.Errorf("%w %q", ErrBadRevision, rev)
+	}
+	return c.Hash.String(), nil
+}
+
+func (b *nativeBackend) LogCommits(ctx context.Context, opts LogOptions) ([]model.Commit, error) {
+	commits := []model.Commit{}
+	err := b.walk(ctx, opts, func(c *gitobj.Commit) error {
+		commits = append(commits, toModelCommit(c))
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, err
+	}
+	// Like the exec backend, an ended context returns what was read.
+	return commits, err
+}
+
+func (b *nativeBackend) NumstatForLog(ctx context.Context, opts LogOptions) (map[string]model.CommitSize, error) {
+	result := map[string]model.CommitSize{}
+	err := b.walk(ctx, opts, func(c *gitobj.Commit) error {
+		// Like git log --numstat, merges get no diff.
+		if len(c.Parents) > 1 {
+			result[c.Hash.String()] = model.CommitSize{}
+			return nil
+		}
+		files, err := b.commitFiles(c)
+		if err != nil {
+			return err
+		}
+		result[c.Hash.String()] = sizeOfFiles(files)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (b *nativeBackend) NumstatForCommits(ctx context.Context, shas []string, jobs int) (map[string]model.CommitSize, error) {
+	sizes := make([]model.CommitSize, len(shas))
+	done := make([]bool, len(shas))
+	batches := (len(shas) + numstatBatchSize - 1) / numstatBatchSize
+	err := runBatches(ctx, batches, jobs, func(ctx context.Context, i int) error {
+		start, end := batchBounds(i, numstatBatchSize, len(shas))
+		for j := start; j < end; j++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			files, err := b.FileStats(ctx, shas[j])
+			if err != nil {
+				return err
+			}
+			sizes[j], done[j] = sizeOfFiles(files), true
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, err
+	}
+	result := make(map[string]model.CommitSize, len(shas))
+	for i, sha := range shas {
+		if done[i] {
+			result[sha] = sizes[i]
+		}
+	}
+	return result, err
+}
+
+func (b *nativeBackend) FileStats(ctx context.Context, sha string) ([]model.FileStat, error) {
+	h, err := b.resolve(sha)
+	if err != nil {
+		return nil, err
+	}
+	c, err := b.db.Commit(h)
+	if err != nil {
+		return nil, err
+	}
+	// git show reports merge stats against the first parent too.
+	return b.commitFiles(c)
+}
+
+func (b *nativeBackend) Close() error {
+	return b.db.Close()
+}
+
+func toModelCommit(c *gitobj.Commit) model.Commit {
+	parents := make([]string, 0, len(c.Parents))
+	for _, p := range c.Parents {
+		parents = append(parents, p.String())
+	}
+	return model.Commit{
+		SHA:         c.Hash.String(),
+		AuthorName:  c.Author.Name,
+		AuthorEmail: c.Author.Email,
+		Date:        c.Author.When,
+		Subject:     c.Subject(),
+		Body:        c.Body(),
+		Parents:     parents,
+	}
+}
+
+func sizeOfFiles(files []model.FileStat) model.CommitSize {
+	size := model.CommitSize{}
+	for _, f := range files {
+		if f.Submodule {
+			size.Submodules++
+			continue
+		}
+		size.Files++
+		if f.Binary {
+			size.BinaryFiles++
+			continue
+		}
+		size.Added += f.Added
+		size.Deleted += f.Deleted
+	}
+	return size
+}
+
+// walk visits the commits selected by opts newest first, in the order git
+// log lists them: by committer date, walking parents as they are reached.
+func (b *nativeBackend) walk(ctx context.Context, opts LogOptions, visit func(*gitobj.Commit) error) error {
+	revs := opts.Revisions
+	if len(revs) == 0 {
+		revs = []string{"HEAD"}
+	}
+	include, exclude, err := b.parseRevisions(revs)
+	if err != nil {
+		return err
+	}
+	var since, until time.Time
+	if opts.Since != "" {
+		if since, err = parseDateFilter(opts.Since); err != nil {
+			return err
+		}
+	}
+	if opts.Until != "" {
+		if until, err = parseDateFilter(opts.Until); err != nil {
+			return err
+		}
+	}
+	var author *regexp.Regexp
+	if opts.Author != "" {
+		if author, err = regexp.Compile(opts.Author); err != nil {
+			author = regexp.MustCompile(regexp.QuoteMeta(opts.Author))
+		}
+	}
+
+	excluded, err := b.ancestors(ctx, exclude)
+	if err != nil {
+		return err
+	}
+	queue := &commitQueue{}
+	seen := map[gitobj.Hash]bool{}
+	push := func(h gitobj.Hash) error {
+		if seen[h] || excluded[h] {
+			return nil
+		}
+		seen[h] = true
+		c, err := b.db.Commit(h)
+		if err != nil {
+			return err
+		}
+		queue.seq++
+		heap.Push(queue, queuedCommit{commit: c, seq: queue.seq})
+		return nil
+	}
+	for _, h := range include {
+		if err := push(h); err != nil {
+			return err
+		}
+	}
+	shown := 0
+	for queue.Len() > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c := heap.Pop(queue).(queuedCommit).commit
+		if !since.IsZero() && c.Committer.When.Before(since) {
+			// git log stops walking past commits older than --since.
+			continue
+		}
+		for _, p := range c.Parents {
+			if err := push(p); err != nil {
+				return err
+			}
+		}
+		if !until.IsZero() && c.Committer.When.After(until) {
+			continue
+		}
+		if author != nil && !author.MatchString(c.Author.Name+" <"+c.Author.Email+">") {
+			continue
+		}
+		if err := visit(c); err != nil {
+			return err
+		}
+		shown++
+		if opts.MaxCommits > 0 && shown >= opts.MaxCommits {
+			break
+		}
+	}
+	return nil
+}
+
+// ancestors returns every commit reachable from tips, tips included.
+func (b *nativeBackend) ancestors(ctx context.Context, tips []gitobj.Hash) (map[gitobj.Hash]bool, error) {
+	reached := map[gitobj.Hash]bool{}
+	stack := append([]gitobj.Hash(nil), tips...)
+	for len(stack) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if reached[h] {
+			continue
+		}
+		reached[h] = true
+		c, err := b.db.Commit(h)
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, c.Parents...)
+	}
+	return reached, nil
+}
+
+// parseRevisions splits git log revision arguments into included and
+// excluded commits.
+func (b *nativeBackend) parseRevisions(revs []string) ([]gitobj.Hash, []gitobj.Hash, error) {
+	var include, exclude []gitobj.Hash
+	not := false
+	add := func(h gitobj.Hash, negated bool) {
+		if negated != not {
+			exclude = append(exclude, h)
+		} else {
+			include = append(include, h)
+		}
+	}
+	addRefs := func(prefix string) error {
+		refs, names, err := b.db.Refs(prefix)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if h, err := b.peelCommit(refs[name]); err == nil {
+				add(h, false)
+			}
+		}
+		return nil
+	}
+	for _, rev := range revs {
+		var err error
+		switch {
+		case rev == "--":
+			continue
+		case rev == "--not":
+			not = !not
+		case rev == "--all":
+			err = addRefs("refs/")
+			if h, headErr := b.db.Head(); headErr == nil {
+				add(h, false)
+			}
+		case rev == "--branches":
+			err = addRefs("refs/heads/")
+		case rev == "--tags":
+			err = addRefs("refs/tags/")
+		case rev == "--remotes":
+			err = addRefs("refs/remotes/")
+		case strings.HasPrefix(rev, "--remotes="):
+			err = addRefs("refs/remotes/" + strings.TrimPrefix(rev, "--remotes=") + "/")
+		case strings.HasPrefix(rev, "-"), strings.Contains(rev, "..."):
+			return nil, nil, fmt.Errorf("native backend: unsupported revision %q", rev)
+		case strings.Contains(rev, ".."):
+			from, to, _ := strings.Cut(rev, "..")
+			var fromHash, toHash gitobj.Hash
+			if fromHash, err = b.resolveCommit(orHead(from)); err != nil {
+				break
+			}
+			if toHash, err = b.resolveCommit(orHead(to)); err != nil {
+				break
+			}
+			add(fromHash, true)
+			add(toHash, false)
+		case strings.HasPrefix(rev, "^"):
+			var h gitobj.Hash
+			if h, err = b.resolveCommit(rev[1:]); err == nil {
+				add(h, true)
+			}
+		default:
+			var h gitobj.Hash
+			if h, err = b.resolveCommit(rev); err == nil {
+				add(h, false)
+			}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return include, exclude, nil
+}
+
+func orHead(rev string) string {
+	if rev == "" {
+		return "HEAD"
+	}
+	return rev
+}
+
+func (b *nativeBackend) resolveCommit(rev string) (gitobj.Hash, error) {
+	h, err := b.resolve(rev)
+	if err != nil {
+		return h, err
+	}
+	return b.peelCommit(h)
+}
+
+func (b *nativeBackend) peelCommit(h gitobj.Hash) (gitobj.Hash, error) {
+	c, err := b.db.Commit(h)
+	if err != nil {
+		return h, err
+	}
+	return c.Hash, nil
+}
+
+// resolve turns a revision such as "main~2", "v1.0^{commit}" or "a1b2c3d"
+// into an object name.
+func (b *nativeBackend) resolve(rev string) (gitobj.Hash, error) {
+	name, ops := rev, ""
+	if i := strings.IndexAny(rev, "~^"); i >= 0 {
+		name, ops = rev[:i], rev[i:]
+	}
+	h, err := b.resolveName(name)
+	if err != nil {
+		return h, fmt.Errorf("%w %q", ErrBadRevision, rev)
+	}
+	for ops != "" {
+		op := ops[0]
+		ops = ops[1:]
+		if op == '^' && strings.HasPrefix(ops, "{") {
+			end := strings.IndexByte(ops, '}')
+			if end < 0 {
+				return h, fmt.Errorf("%w %q", ErrBadRevision, rev)
+			}
+			kind := ops[1:end]
+			ops = ops[end+1:]
+			switch kind {
+			case "", "commit":
+				if h, err = b.peelCommit(h); err != nil {
+					return h, err
+				}
+			default:
+				return h, fmt.Errorf("native backend: unsupported revision %q", rev)
+			}
+			continue
+		}
+		digits := 0
+		for digits < len(ops) && ops[digits] >= '0' && ops[digits] <= '9' {
+			digits++
+		}
+		n := 1
+		if digits > 0 {
+			n, _ = strconv.Atoi(ops[:digits])
+			ops = ops[digits:]
+		}
+		c, err := b.db.Commit(h)
+		if err != nil {
+			return h, err
+		}
+		switch {
+		case op == '^' && n == 0:
+			h = c.Hash
+		case op == '^':
+			if n > len(c.Parents) {
+				return h, fmt.Errorf("%w %q", ErrBadRevision, rev)
+			}
+			h = c.Parents[n-1]
+		default:
+			for i := 0; i < n; i++ {
+				if len(c.Parents) == 0 {
+					return h, fmt.Errorf("%w %q", ErrBadRevision, rev)
+				}
+				if c, err = b.db.Commit(c.Parents[0]); err != nil {
+					return h, err
+				}
+			}
+			h = c.Hash
+		}
+	}
+	return h, nil
+}
+
+// resolveName looks a name up the way git does: full SHAs, HEAD, refs in
+// the usual namespaces, then abbreviated SHAs.
+func (b *nativeBackend) resolveName(name string) (gitobj.Hash, error) {
+	if name == "" || name == "@" {
+		name = "HEAD"
+	}
+	if h, err := gitobj.ParseHash(name); err == nil {
+		return h, nil
+	}
+	for _, candidate := range []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"} {
+		if candidate != "HEAD" && !strings.HasPrefix(candidate, "refs/") {
+			continue
+		}
+		h, ok, err := b.db.Ref(candidate)
+		if err != nil {
+			return h, err
+		}
+		if ok {
+			return h, nil
+		}
+	}
+	return b.db.FindPrefix(name)
+}
+
+// parseDateFilter parses --since/--until values. Like git, a bare date keeps
+// the current time of day.
+func parseDateFilter(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		now := time.Now()
+		return time.Date(t.Year(), t.Month(), t.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.Local), nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05 -0700", time.RFC3339} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("native backend: unsupported date %q", value)
+}
+
+type queuedCommit struct {
+	commit *gitobj.Commit
+	seq    int
+}
+
+// commitQueue pops the newest commit first, and commits with equal dates in
+// the order they were queued.
+type commitQueue struct {
+	items []queuedCommit
+	seq   int
+}
+
+func (q *commitQueue) Len() int { return len(q.items) }
+
+func (q *commitQueue) Less(i, j int) bool {
+	ti, tj := q.items[i].commit.Committer.When, q.items[j].commit.Committer.When
+	if !ti.Equal(tj) {
+		return ti.After(tj)
+	}
+	return q.items[i].seq < q.items[j].seq
+}
+
+func (q *commitQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+func (q *commitQueue) Push(x any) { q.items = append(q.items, x.(queuedCommit)) }
+
+func (q *commitQueue) Pop() any {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
+
+// fileChange is one path that differs between two trees.
+type fileChange struct {
+	path             string
+	oldHash, newHash gitobj.Hash
+	oldMode, newMode uint32
+}
+
+// commitFiles diffs a commit against its first parent, or against the empty
+// tree for root commits.
+func (b *nativeBackend) commitFiles(c *gitobj.Commit) ([]model.FileStat, error) {
+	var parentTree gitobj.Hash
+	if len(c.Parents) > 0 {
+		parent, err := b.db.Commit(c.Parents[0])
+		if err != nil {
+			return nil, err
+		}
+		parentTree = parent.Tree
+	}
+	changes := []fileChange{}
+	if err := b.diffTrees(parentTree, c.Tree, "", &changes); err != nil {
+		return nil, err
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+	changes = pairRenames(changes)
+	files := make([]model.FileStat, 0, len(changes))
+	for _, ch := range changes {
+		stat, err := b.fileStat(ch)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, stat)
+	}
+	return files, nil
+}
+
+func (b *nativeBackend) treeEntries(h gitobj.Hash) (map[string]gitobj.TreeEntry, error) {
+	entries := map[string]gitobj.TreeEntry{}
+	if h.IsZero() {
+		return entries, nil
+	}
+	list, err := b.db.Tree(h)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range list {
+		entries[e.Name] = e
+	}
+	return entries, nil
+}
+
+func (b *nativeBackend) diffTrees(oldTree, newTree gitobj.Hash, prefix string, out *[]fileChange) error {
+	if oldTree == newTree {
+		return nil
+	}
+	oldEntries, err := b.treeEntries(oldTree)
+	if err != nil {
+		return err
+	}
+	newEntries, err := b.treeEntries(newTree)
+	if err != nil {
+		return err
+	}
+	names := map[string]struct{}{}
+	for name := range oldEntries {
+		names[name] = struct{}{}
+	}
+	for name := range newEntries {
+		names[name] = struct{}{}
+	}
+	for name := range names {
+		o, hasOld := oldEntries[name]
+		n, hasNew := newEntries[name]
+		if hasOld && hasNew && o.Hash == n.Hash && o.Mode == n.Mode {
+			continue
+		}
+		path := prefix + name
+		oldDir := hasOld && o.IsDir()
+		newDir := hasNew && n.IsDir()
+		if oldDir || newDir {
+			var oldSub, newSub gitobj.Hash
+			if oldDir {
+				oldSub = o.Hash
+			}
+			if newDir {
+				newSub = n.Hash
+			}
+			if err := b.diffTrees(oldSub, newSub, path+"/", out); err != nil {
+				return err
+			}
+		}
+		ch := fileChange{path: path}
+		if hasOld && !oldDir {
+			ch.oldHash, ch.oldMode = o.Hash, o.Mode
+		}
+		if hasNew && !newDir {
+			ch.newHash, ch.newMode = n.Hash, n.Mode
+		}
+		if ch.oldMode != 0 || ch.newMode != 0 {
+			*out = append(*out, ch)
+		}
+	}
+	return nil
+}
+
+// pairRenames folds a deletion and an addition of identical content into a
+// single rename, as git's default rename detection would.
+func pairRenames(changes []fileChange) []fileChange {
+	deleted := map[gitobj.Hash][]int{}
+	for i, ch := range changes {
+		if ch.newMode == 0 {
+			deleted[ch.oldHash] = append(deleted[ch.oldHash], i)
+		}
+	}
+	drop := map[int]bool{}
+	for i := range changes {
+		ch := &changes[i]
+		if ch.oldMode != 0 || ch.newMode == gitobj.ModeGitlink {
+			continue
+		}
+		candidates := deleted[ch.newHash]
+		if len(candidates) == 0 {
+			continue
+		}
+		from := changes[candidates[0]]
+		deleted[ch.newHash] = candidates[1:]
+		drop[candidates[0]] = true
+		ch.oldHash, ch.oldMode = from.oldHash, from.oldMode
+		ch.path = renamePath(from.path, ch.path)
+	}
+	kept := changes[:0]
+	for i, ch := range changes {
+		if !drop[i] {
+			kept = append(kept, ch)
+		}
+	}
+	return kept
+}
+
+// renamePath formats a rename the way numstat does, factoring out the
+// common directory prefix and suffix: "src/{old.go => new.go}".
+func renamePath(from, to string) string {
+	pfx := 0
+	for i := 0; i < len(from) && i < len(to) && from[i] == to[i]; i++ {
+		if from[i] == '/' {
+			pfx = i + 1
+		}
+	}
+	sfx := 0
+	for i := 1; i <= len(from)-pfx && i <= len(to)-pfx && from[len(from)-i] == to[len(to)-i]; i++ {
+		if from[len(from)-i] == '/' {
+			sfx = i
+		}
+	}
+	if pfx == 0 && sfx == 0 {
+		return from + " => " + to
+	}
+	return from[:pfx] + "{" + from[pfx:len(from)-sfx] + " => " + to[pfx:len(to)-sfx] + "}" + from[len(from)-sfx:]
+}
+
+func (b *nativeBackend) fileStat(ch fileChange) (model.FileStat, error) {
+	stat := model.FileStat{Path: ch.path, Submodule: ch.oldMode == gitobj.ModeGitlink || ch.newMode == gitobj.ModeGitlink}
+	if ch.oldHash == ch.newHash {
+		return stat, nil
+	}
+	oldData, err := b.blob(ch.oldHash, ch.oldMode)
+	if err != nil {
+		return stat, err
+	}
+	newData, err := b.blob(ch.newHash, ch.newMode)
+	if err != nil {
+		return stat, err
+	}
+	if isBinary(oldData) || isBinary(newData) {
+		stat.Binary = true
+		return stat, nil
+	}
+	stat.Added, stat.Deleted = lineChanges(oldData, newData)
+	return stat, nil
+}
+
+// blob returns file content for diffing. Submodules diff as the one-line
+// "Subproject commit" text git uses.
+func (b *nativeBackend) blob(h gitobj.Hash, mode uint32) ([]byte, error) {
+	switch {
+	case mode == 0:
+		return nil, nil
+	case mode == gitobj.ModeGitlink:
+		return []byte("Subproject commit " + h.String() + "\n"), nil
+	}
+	typ, data, err := b.db.Read(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != gitobj.TypeBlob {
+		return nil, errors.New("expected blob " + h.String())
+	}
+	return data, nil
+}
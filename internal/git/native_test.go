@@ -0,0 +1,254 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// buildTestRepo creates a small history with edits, an exact rename, a
+// binary file, a mode change, a merge and a tag, then packs part of it so
+// both loose and delta-compressed objects are read.
+func buildTestRepo(t *testing.T) string {
+	t.Helper()
+	if err := EnsureGit(); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	step := 0
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		date := fmt.Sprintf("2024-02-%02dT%02d:00:00+01:00", 1+step/4, 8+step%4*3)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=Jane", "GIT_AUTHOR_EMAIL=jane@example.com",
+			"GIT_COMMITTER_NAME=Jane", "GIT_COMMITTER_EMAIL=jane@example.com",
+			"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date,
+			"GIT_CONFIG_NOSYSTEM=1", "HOME="+dir,
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	commit := func(msg string) {
+		t.Helper()
+		run("add", "-A")
+		run("commit", "-q", "-m", msg)
+		step++
+	}
+	lines := func(from, to int) string {
+		b := &strings.Builder{}
+		for i := from; i < to; i++ {
+			fmt.Fprintf(b, "line %d\n", i)
+		}
+		return b.String()
+	}
+
+	run("init", "-q", "-b", "main")
+	write("main.go", lines(0, 50))
+	write("docs/readme.md", "# Title\n\nSome text")
+	commit("Initial import")
+	write("main.go", lines(0, 20)+"changed\n"+lines(25, 60))
+	write("docs/readme.md", "# Title\n\nSome text\n")
+	commit("Rework main\n\nLonger body explaining\nthe change.\n\nRoastgit-Ignore: binary")
+	run("mv", "docs/readme.md", "docs/guide.md")
+	write("logo.png", "\x89PNG\x00\x01\x02")
+	commit("fix")
+	run("tag", "-a", "v1.0", "-m", "release")
+	run("pack-refs", "--all")
+	run("gc", "-q")
+	run("checkout", "-q", "-b", "feature")
+	write("feature.txt", lines(0, 10))
+	if err := os.Chmod(filepath.Join(dir, "main.go"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	commit("Add feature")
+	run("checkout", "-q", "main")
+	write("main.go", "header\n"+lines(0, 20)+"changed\n"+lines(25, 60))
+	commit("wip")
+	run("merge", "-q", "--no-ff", "-m", "Merge feature", "feature")
+	step++
+	run("rm", "-q", "logo.png")
+	commit("Remove logo")
+	// A submodule pointer without a checkout, added alongside a file and
+	// then bumped on its own.
+	write("NOTICE", "vendored\n")
+	run("add", "NOTICE")
+	run("update-index", "--add", "--cacheinfo", "160000,"+strings.Repeat("1", 40)+",vendor/lib")
+	run("commit", "-q", "-m", "Vendor lib as a submodule")
+	step++
+	run("update-index", "--cacheinfo", "160000,"+strings.Repeat("2", 40)+",vendor/lib")
+	run("commit", "-q", "-m", "Bump lib")
+	return dir
+}
+
+func TestNativeBackendMatchesExec(t *testing.T) {
+	dir := buildTestRepo(t)
+	ctx := context.Background()
+	execRepo, err := Open(BackendExec, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	native, err := Open(BackendNative, dir)
+	if err != nil {
+		t.Fatalf("open native: %v", err)
+	}
+	defer native.Close()
+
+	head, err := execRepo.HeadSHA(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := native.HeadSHA(ctx); err != nil || got != head {
+		t.Fatalf("native HEAD = %q, %v; want %q", got, err, head)
+	}
+	wantDir, err := execRepo.GitDir(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := native.GitDir(ctx); err != nil || got != wantDir {
+		t.Fatalf("native git dir = %q, %v; want %q", got, err, wantDir)
+	}
+	wantBranches, _ := execRepo.Branches(ctx)
+	if got, _ := native.Branches(ctx); !reflect.DeepEqual(got, wantBranches) {
+		t.Fatalf("branches = %v, want %v", got, wantBranches)
+	}
+	for _, rev := range []string{"HEAD", "HEAD~2", "HEAD~3^2", "v1.0", "main~1^{commit}", head[:8]} {
+		want, err := execRepo.ResolveCommit(ctx, rev)
+		if err != nil {
+			t.Fatalf("exec resolve %s: %v", rev, err)
+		}
+		if got, err := native.ResolveCommit(ctx, rev); err != nil || got != want {
+			t.Fatalf("native resolve %s = %q, %v; want %q", rev, got, err, want)
+		}
+	}
+
+	optsList := []LogOptions{
+		{},
+		{MaxCommits: 3},
+		{Revisions: []string{"v1.0..main"}},
+		{Revisions: []string{"main", "^feature"}},
+		{Since: "2024-02-02 00:00:00 +0100"},
+		{Until: "2024-02-01 12:00:00 +0100"},
+		{Author: "jane@"},
+	}
+	for _, opts := range optsList {
+		want, err := execRepo.LogCommits(ctx, opts)
+		if err != nil {
+			t.Fatalf("exec log %+v: %v", opts, err)
+		}
+		got, err := native.LogCommits(ctx, opts)
+		if err != nil {
+			t.Fatalf("native log %+v: %v", opts, err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("log %+v: got %d commits, want %d", opts, len(got), len(want))
+		}
+		for i := range want {
+			w, g := want[i], got[i]
+			if g.SHA != w.SHA || g.Subject != w.Subject || g.Body != w.Body || g.AuthorEmail != w.AuthorEmail ||
+				!g.Date.Equal(w.Date) || !reflect.DeepEqual(g.Parents, w.Parents) {
+				t.Fatalf("log %+v commit %d:\n got %+v\nwant %+v", opts, i, g, w)
+			}
+		}
+		wantSizes, err := execRepo.NumstatForLog(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gotSizes, err := native.NumstatForLog(ctx, opts); err != nil || !reflect.DeepEqual(gotSizes, wantSizes) {
+			t.Fatalf("numstat %+v:\n got %v, %v\nwant %v", opts, gotSizes, err, wantSizes)
+		}
+	}
+
+	commits, _ := execRepo.LogCommits(ctx, LogOptions{})
+	shas := []string{}
+	for _, c := range commits {
+		shas = append(shas, c.SHA)
+		want, err := execRepo.FileStats(ctx, c.SHA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, err := native.FileStats(ctx, c.SHA); err != nil || !reflect.DeepEqual(got, want) {
+			t.Fatalf("file stats %s (%s):\n got %+v, %v\nwant %+v", c.SHA, c.Subject, got, err, want)
+		}
+	}
+	want, _ := execRepo.NumstatForCommits(ctx, shas, 1)
+	if got, err := native.NumstatForCommits(ctx, shas, 4); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("numstat for commits:\n got %v, %v\nwant %v", got, err, want)
+	}
+	if bump := want[head]; !bump.SubmoduleBump() {
+		t.Fatalf("submodule bump size = %+v, want only a submodule", bump)
+	}
+	if vendored := want[commits[1].SHA]; vendored.Files != 1 || vendored.Submodules != 1 || vendored.Added != 1 {
+		t.Fatalf("vendoring commit size = %+v, want one file and one submodule", vendored)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	dir := buildTestRepo(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, name := range []string{BackendExec, BackendNative} {
+		repo, err := Open(name, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.LogCommits(ctx, LogOptions{}); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s log: err = %v, want context.Canceled", name, err)
+		}
+		if _, err := repo.NumstatForCommits(ctx, []string{"HEAD"}, 1); !errors.Is(err, context.Canceled) {
+			t.Fatalf("%s numstat: err = %v, want context.Canceled", name, err)
+		}
+		repo.Close()
+	}
+}
+
+func TestLineChanges(t *testing.T) {
+	cases := []struct {
+		old, new       string
+		added, deleted int
+	}{
+		{"a\nb\nc\n", "a\nb\nc\n", 0, 0},
+		{"", "a\nb\n", 2, 0},
+		{"a\nb\n", "", 0, 2},
+		{"a\nb\nc\n", "a\nx\nc\n", 1, 1},
+		{"a\nb", "a\nb\n", 1, 1},
+		{"a\nb\nc\nd\n", "b\nc\nd\ne\n", 1, 1},
+	}
+	for _, c := range cases {
+		added, deleted := lineChanges([]byte(c.old), []byte(c.new))
+		if added != c.added || deleted != c.deleted {
+			t.Fatalf("lineChanges(%q, %q) = +%d -%d, want +%d -%d", c.old, c.new, added, deleted, c.added, c.deleted)
+		}
+	}
+}
+
+func TestRenamePath(t *testing.T) {
+	cases := map[[2]string]string{
+		{"docs/readme.md", "docs/guide.md"}:    "docs/{readme.md => guide.md}",
+		{"a.txt", "b.txt"}:                     "a.txt => b.txt",
+		{"src/old/main.go", "src/new/main.go"}: "src/{old => new}/main.go",
+	}
+	for in, want := range cases {
+		if got := renamePath(in[0], in[1]); got != want {
+			t.Fatalf("renamePath(%q, %q) = %q, want %q", in[0], in[1], got, want)
+		}
+	}
+}
@@ -10,99 +10,112 @@ import (
 	"roastgit/internal/model"
 )
 
-// NumstatForCommits returns numstat data for a list of commits.
-func NumstatForCommits(ctx context.Context, repo string, shas []string) (map[string]model.CommitSize, error) {
+// NumstatForCommits returns numstat data for a list of commits. The SHAs
+// are split into batches that run as up to jobs concurrent git processes
+// (see Jobs); the first failure cancels the rest. When ctx ends first, the
+// sizes of the batches that completed are returned with the error.
+func NumstatForCommits(ctx context.Context, repo string, shas []string, jobs int) (map[string]model.CommitSize, error) {
 	if len(shas) == 0 {
 		return map[string]model.CommitSize{}, nil
 	}
+	batches := (len(shas) + numstatBatchSize - 1) / numstatBatchSize
+	parsed := make([]map[string]model.CommitSize, batches)
+	err := runBatches(ctx, batches, jobs, func(ctx context.Context, i int) error {
+		start, end := batchBounds(i, numstatBatchSize, len(shas))
+		sizes, err := numstatBatch(ctx, repo, shas[start:end])
+		parsed[i] = sizes
+		return err
+	})
+	if err != nil && ctx.Err() == nil {
+		return nil, err
+	}
 	result := make(map[string]model.CommitSize, len(shas))
-	batchSize := 200
-	for i := 0; i < len(shas); i += batchSize {
-		end := i + batchSize
-		if end > len(shas) {
-			end = len(shas)
-		}
-		args := []string{"show", "--numstat", "--format=%H%x1f"}
-		args = append(args, shas[i:end]...)
-		cmd, cancel, err := runGitStreaming(ctx, repo, args)
-		if err != nil {
-			return nil, err
-		}
-		defer cancel()
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return nil, err
-		}
-		stderr, err := cmd.StderrPipe()
-		if err != nil {
-			return nil, err
-		}
-		if err := cmd.Start(); err != nil {
-			return nil, err
-		}
-		parsed, parseErr := ParseNumstat(stdout)
-		_, _ = io.ReadAll(stderr)
-		if err := cmd.Wait(); err != nil {
-			return nil, err
-		}
-		if parseErr != nil {
-			return nil, parseErr
-		}
-		for k, v := range parsed {
+	for _, sizes := range parsed {
+		for k, v := range sizes {
 			result[k] = v
 		}
 	}
-	return result, nil
+	return result, err
+}
+
+func numstatBatch(ctx context.Context, repo string, shas []string) (map[string]model.CommitSize, error) {
+	args := []string{"show", "--raw", "--numstat", "--format=%H%x1f"}
+	args = append(args, shas...)
+	return streamNumstat(ctx, repo, args)
 }
 
 // NumstatForLog returns numstat data for git log with filters.
 func NumstatForLog(ctx context.Context, repo string, opts LogOptions) (map[string]model.CommitSize, error) {
-	args := []string{"log", "--numstat", "--pretty=format:%H%x1f"}
-	if opts.Since != "" {
-		args = append(args, "--since", opts.Since)
-	}
-	if opts.Until != "" {
-		args = append(args, "--until", opts.Until)
-	}
-	if opts.Author != "" {
-		args = append(args, "--author", opts.Author)
-	}
-	if opts.MaxCommits > 0 {
-		args = append(args, "-n", intToString(opts.MaxCommits))
-	}
-	cmd, cancel, err := runGitStreaming(ctx, repo, args)
-	if err != nil {
-		return nil, err
-	}
-	defer cancel()
-	stdout, err := cmd.StdoutPipe()
+	args := logArgs([]string{"log", "--raw", "--numstat", "--pretty=format:%H%x1f"}, opts)
+	return streamNumstat(ctx, repo, args)
+}
+
+// streamNumstat runs a git command printing --raw --numstat records and
+// parses its output as it streams.
+func streamNumstat(ctx context.Context, repo string, args []string) (map[string]model.CommitSize, error) {
+	var parsed map[string]model.CommitSize
+	err := streamGit(ctx, repo, args, func(r io.Reader) error {
+		var err error
+		parsed, err = ParseNumstat(r)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	stderr, err := cmd.StderrPipe()
+	return parsed, nil
+}
+
+// FileStats returns the per-file numstat of one commit, in git's order.
+func FileStats(ctx context.Context, repo, sha string) ([]model.FileStat, error) {
+	out, err := runGit(ctx, repo, []string{"show", "--raw", "--numstat", "--format=", sha, "--"})
 	if err != nil {
 		return nil, err
 	}
-	if err := cmd.Start(); err != nil {
-		return nil, err
+	return ParseFileStats(strings.NewReader(out))
+}
+
+// ParseFileStats parses "added<TAB>deleted<TAB>path" numstat lines. Binary
+// files, which git lists as "-", are flagged instead of counted. Submodules
+// are flagged from the --raw lines git prints before the numstat.
+func ParseFileStats(r io.Reader) ([]model.FileStat, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	files := []model.FileStat{}
+	gitlinks := map[string]bool{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, ":") {
+			addGitlinks(gitlinks, line)
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		stat := model.FileStat{Path: fields[2], Submodule: gitlinks[fields[2]]}
+		if fields[0] == "-" || fields[1] == "-" {
+			stat.Binary = true
+		} else {
+			stat.Added, _ = strconv.Atoi(fields[0])
+			stat.Deleted, _ = strconv.Atoi(fields[1])
+		}
+		files = append(files, stat)
 	}
-	parsed, parseErr := ParseNumstat(stdout)
-	_, _ = io.ReadAll(stderr)
-	if err := cmd.Wait(); err != nil {
+	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
-	if parseErr != nil {
-		return nil, parseErr
-	}
-	return parsed, nil
+	return files, nil
 }
 
-// ParseNumstat parses numstat output into commit sizes.
+// ParseNumstat parses numstat output into commit sizes. With --raw lines
+// before each commit's numstat, submodule pointer changes are counted apart
+// from files.
 func ParseNumstat(r io.Reader) (map[string]model.CommitSize, error) {
 	scanner := bufio.NewScanner(r)
 	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 	result := map[string]model.CommitSize{}
 	current := ""
+	gitlinks := map[string]bool{}
 	for scanner.Scan() {
 		line := scanner.Text()
 		if strings.ContainsRune(line, rune(unitSep)) {
@@ -113,16 +126,26 @@ func ParseNumstat(r io.Reader) (map[string]model.CommitSize, error) {
 					result[current] = model.CommitSize{}
 				}
 			}
+			clear(gitlinks)
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			addGitlinks(gitlinks, line)
 			continue
 		}
 		if strings.TrimSpace(line) == "" {
 			continue
 		}
-		fields := strings.Split(line, "\t")
+		fields := strings.SplitN(line, "\t", 3)
 		if len(fields) < 3 || current == "" {
 			continue
 		}
 		size := result[current]
+		if gitlinks[fields[2]] {
+			size.Submodules++
+			result[current] = size
+			continue
+		}
 		size.Files++
 		if fields[0] == "-" || fields[1] == "-" {
 			size.BinaryFiles++
@@ -144,3 +167,21 @@ func ParseNumstat(r io.Reader) (map[string]model.CommitSize, error) {
 	}
 	return result, nil
 }
+
+// addGitlinks records the paths of a --raw line that changes a submodule
+// pointer, i.e. has mode 160000 on either side:
+// ":160000 160000 b7b1b7e ffad72a M<TAB>libs/sub".
+func addGitlinks(gitlinks map[string]bool, line string) {
+	meta, paths, ok := strings.Cut(line, "\t")
+	if !ok {
+		return
+	}
+	for _, field := range strings.Fields(strings.TrimLeft(meta, ":")) {
+		if field == "160000" {
+			for _, path := range strings.Split(paths, "\t") {
+				gitlinks[path] = true
+			}
+			return
+		}
+	}
+}
@@ -4,8 +4,30 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"roastgit/internal/model"
 )
 
+func TestParseFileStats(t *testing.T) {
+	input := "10\t2\tmain.go\n-\t-\tlogo.png\n\n3\t0\tdocs/{old.md => new.md}\n"
+	files, err := ParseFileStats(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse file stats: %v", err)
+	}
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files, got %d", len(files))
+	}
+	if files[0].Path != "main.go" || files[0].Added != 10 || files[0].Deleted != 2 {
+		t.Fatalf("unexpected first file: %+v", files[0])
+	}
+	if !files[1].Binary || files[1].Added != 0 {
+		t.Fatalf("expected binary file: %+v", files[1])
+	}
+	if files[2].Path != "docs/{old.md => new.md}" {
+		t.Fatalf("unexpected rename path: %q", files[2].Path)
+	}
+}
+
 func TestParseNumstatFromFixture(t *testing.T) {
 	data, err := os.ReadFile("fixtures/numstat_basic.txt")
 	if err != nil {
@@ -24,3 +46,30 @@ func TestParseNumstatFromFixture(t *testing.T) {
 		t.Fatalf("unexpected stats: %+v", first)
 	}
 }
+
+func TestParseNumstatSubmodules(t *testing.T) {
+	us := string([]byte{unitSep})
+	input := "aaa" + us + "\n\n" +
+		":160000 160000 b7b1b7e ffad72a M\tlibs/sub\n" +
+		":100644 100644 587be6b b77b4eb M\tx\n" +
+		"1\t1\tlibs/sub\n1\t0\tx\n" +
+		"bbb" + us + "\n\n" +
+		":160000 160000 ffad72a b7b1b7e M\tlibs/sub\n" +
+		"1\t1\tlibs/sub\n" +
+		"ccc" + us + "\n\n" +
+		"2\t0\tlibs/sub\n"
+	stats, err := ParseNumstat(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse numstat: %v", err)
+	}
+	if got := stats["aaa"]; got != (model.CommitSize{Files: 1, Added: 1, Submodules: 1}) {
+		t.Fatalf("mixed commit = %+v", got)
+	}
+	if got := stats["bbb"]; !got.SubmoduleBump() {
+		t.Fatalf("bump commit = %+v", got)
+	}
+	// Without a raw line saying so, a path is an ordinary file.
+	if got := stats["ccc"]; got != (model.CommitSize{Files: 1, Added: 2}) {
+		t.Fatalf("plain commit = %+v", got)
+	}
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// numstatBatchSize is the number of commits handed to one git show.
+const numstatBatchSize = 200
+
+// Jobs returns the worker count to use for a --jobs value; zero or less
+// means one worker per CPU.
+func Jobs(n int) int {
+	if n <= 0 {
+		return runtime.NumCPU()
+	}
+	return n
+}
+
+// runBatches calls fn for batches 0..n-1 on at most jobs goroutines. The
+// first error cancels the context the other calls see, stops new batches
+// from starting and is returned once every running call has finished.
+func runBatches(ctx context.Context, n, jobs int, fn func(ctx context.Context, batch int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs = Jobs(jobs)
+	if jobs > n {
+		jobs = n
+	}
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	batches := make(chan int)
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				if err := fn(ctx, batch); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case batches <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(batches)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// batchBounds returns the [start, end) slice bounds of batch i of total items.
+func batchBounds(i, size, total int) (int, int) {
+	start := i * size
+	end := start + size
+	if end > total {
+		end = total
+	}
+	return start, end
+}
@@ -0,0 +1,55 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunBatches(t *testing.T) {
+	ctx := context.Background()
+	seen := make([]int32, 25)
+	err := runBatches(ctx, len(seen), 4, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&seen[i], 1)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, n := range seen {
+		if n != 1 {
+			t.Fatalf("batch %d ran %d times", i, n)
+		}
+	}
+
+	boom := errors.New("boom")
+	var started, cancelled int32
+	err = runBatches(ctx, 1000, 3, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&started, 1)
+		if i == 2 {
+			return boom
+		}
+		<-ctx.Done()
+		atomic.AddInt32(&cancelled, 1)
+		return ctx.Err()
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want the first failure", err)
+	}
+	if started == 1000 || cancelled == 0 {
+		t.Fatalf("started %d batches, %d saw cancellation; want the failure to stop the pool", started, cancelled)
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := runBatches(cancelledCtx, 5, 2, func(context.Context, int) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestBatchBounds(t *testing.T) {
+	if s, e := batchBounds(2, 200, 450); s != 400 || e != 450 {
+		t.Fatalf("batchBounds = %d, %d", s, e)
+	}
+}
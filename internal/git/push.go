@@ -0,0 +1,97 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// zeroSHA is what git sends for a missing side of a ref update.
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// PushUpdate is one line of pre-push hook input.
+type PushUpdate struct {
+	LocalRef  string
+	LocalSHA  string
+	RemoteRef string
+	RemoteSHA string
+}
+
+// Deletes reports whether the update deletes the remote ref.
+func (u PushUpdate) Deletes() bool {
+	return strings.Trim(u.LocalSHA, "0") == ""
+}
+
+// Creates reports whether the update creates a new remote ref.
+func (u PushUpdate) Creates() bool {
+	return strings.Trim(u.RemoteSHA, "0") == ""
+}
+
+// ParsePushUpdates reads "<local ref> <local sha> <remote ref> <remote sha>"
+// lines as git writes them to the pre-push hook's stdin.
+func ParsePushUpdates(r io.Reader) ([]PushUpdate, error) {
+	updates := []PushUpdate{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("malformed pre-push line %q", line)
+		}
+		updates = append(updates, PushUpdate{LocalRef: fields[0], LocalSHA: fields[1], RemoteRef: fields[2], RemoteSHA: fields[3]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// PushRevisions returns git log revisions selecting the commits a push sends:
+// everything reachable from the pushed tips but not from the old remote tips.
+// New refs also exclude everything already on the remote. exists reports
+// whether an object is available locally; unknown remote tips are skipped.
+func PushRevisions(updates []PushUpdate, remote string, exists func(sha string) bool) []string {
+	include := []string{}
+	exclude := []string{}
+	creates := false
+	for _, u := range updates {
+		if u.Deletes() {
+			continue
+		}
+		include = append(include, u.LocalSHA)
+		switch {
+		case u.Creates():
+			creates = true
+		case exists(u.RemoteSHA):
+			exclude = append(exclude, "^"+u.RemoteSHA)
+		default:
+			creates = true
+		}
+	}
+	if len(include) == 0 {
+		return nil
+	}
+	revs := append(include, exclude...)
+	if creates {
+		if remote != "" && !strings.Contains(remote, "/") && !strings.Contains(remote, ":") {
+			revs = append(revs, "--not", "--remotes="+remote)
+		} else {
+			revs = append(revs, "--not", "--remotes")
+		}
+	}
+	return revs
+}
+
+// CommitExists reports whether sha names a commit in the repository.
+func CommitExists(ctx context.Context, repo, sha string) bool {
+	if sha == "" || sha == zeroSHA {
+		return false
+	}
+	_, err := runGit(ctx, repo, []string{"cat-file", "-e", sha + "^{commit}"})
+	return err == nil
+}
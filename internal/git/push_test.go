@@ -0,0 +1,32 @@
+package git
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPushRevisions(t *testing.T) {
+	input := strings.Join([]string{
+		"refs/heads/main 1111111111111111111111111111111111111111 refs/heads/main 2222222222222222222222222222222222222222",
+		"refs/heads/topic 3333333333333333333333333333333333333333 refs/heads/topic 0000000000000000000000000000000000000000",
+		"(delete) 0000000000000000000000000000000000000000 refs/heads/old 4444444444444444444444444444444444444444",
+	}, "\n")
+	updates, err := ParsePushUpdates(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(updates) != 3 || !updates[2].Deletes() || !updates[1].Creates() {
+		t.Fatalf("unexpected updates: %+v", updates)
+	}
+	revs := PushRevisions(updates, "origin", func(string) bool { return true })
+	want := "1111111111111111111111111111111111111111 3333333333333333333333333333333333333333 ^2222222222222222222222222222222222222222 --not --remotes=origin"
+	if strings.Join(revs, " ") != want {
+		t.Fatalf("unexpected revisions: %v", revs)
+	}
+	if revs := PushRevisions(updates[2:], "origin", func(string) bool { return true }); revs != nil {
+		t.Fatalf("expected no revisions for a delete-only push, got %v", revs)
+	}
+	if _, err := ParsePushUpdates(strings.NewReader("bad line")); err == nil {
+		t.Fatalf("expected error for malformed input")
+	}
+}
@@ -0,0 +1,64 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Submodules returns the paths of the submodules declared in the
+// .gitmodules file of the work tree at repo, sorted. A repository without
+// the file, such as a bare one, has none.
+func Submodules(repo string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(repo, ".gitmodules"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseGitmodules(string(data)), nil
+}
+
+// parseGitmodules returns the path of every [submodule "name"] section.
+// Paths that are absolute or climb out with "..", which git itself refuses,
+// are skipped so a crafted .gitmodules cannot point the analysis outside
+// the work tree.
+func parseGitmodules(data string) []string {
+	paths := []string{}
+	inSubmodule := false
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section := strings.Trim(line, "[] \t")
+			inSubmodule = strings.HasPrefix(strings.ToLower(section), "submodule ")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && inSubmodule && strings.EqualFold(strings.TrimSpace(key), "path") {
+			if path := strings.Trim(strings.TrimSpace(value), `"`); path != "" && insideWorkTree(path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// insideWorkTree reports whether a submodule path stays below the work tree.
+func insideWorkTree(path string) bool {
+	if strings.HasPrefix(path, "/") || strings.HasPrefix(path, `\`) || filepath.IsAbs(path) || filepath.VolumeName(path) != "" {
+		return false
+	}
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if part == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package git
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RefsState fingerprints the files git rewrites when a commit is made or a
+// branch moves: HEAD, logs/HEAD, packed-refs and everything under refs/, for
+// the common directory gitDir and each linked worktree in it. The result
+// changes whenever one of them does, so polling it finds new commits without
+// running git.
+func RefsState(gitDir string) string {
+	paths := []string{"HEAD", filepath.Join("logs", "HEAD"), "packed-refs"}
+	if worktrees, err := os.ReadDir(filepath.Join(gitDir, "worktrees")); err == nil {
+		for _, wt := range worktrees {
+			paths = append(paths, filepath.Join("worktrees", wt.Name(), "HEAD"), filepath.Join("worktrees", wt.Name(), "logs", "HEAD"))
+		}
+	}
+	filepath.WalkDir(filepath.Join(gitDir, "refs"), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if rel, err := filepath.Rel(gitDir, path); err == nil {
+				paths = append(paths, rel)
+			}
+		}
+		return nil
+	})
+	sort.Strings(paths)
+
+	b := &strings.Builder{}
+	for _, path := range paths {
+		info, err := os.Stat(filepath.Join(gitDir, path))
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(b, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+	}
+	return b.String()
+}
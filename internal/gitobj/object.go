@@ -0,0 +1,260 @@
+// Package gitobj reads a git object database directly: loose objects,
+// packfiles and refs. It covers what roastgit needs to walk history and diff
+// trees without a git binary, and nothing more. Only SHA-1 repositories are
+// supported.
+package gitobj
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Hash is a SHA-1 object name.
+type Hash [20]byte
+
+// ZeroHash is the all-zero object name.
+var ZeroHash Hash
+
+// ParseHash parses a 40-character hex object name.
+func ParseHash(s string) (Hash, error) {
+	var h Hash
+	if len(s) != 40 {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	if _, err := hex.Decode(h[:], []byte(s)); err != nil {
+		return h, fmt.Errorf("invalid object name %q", s)
+	}
+	return h, nil
+}
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// IsZero reports whether h is the all-zero name.
+func (h Hash) IsZero() bool {
+	return h == ZeroHash
+}
+
+// Type is a git object type as stored in packfiles.
+type Type int
+
+const (
+	TypeCommit Type = 1
+	TypeTree   Type = 2
+	TypeBlob   Type = 3
+	TypeTag    Type = 4
+)
+
+func (t Type) String() string {
+	switch t {
+	case TypeCommit:
+		return "commit"
+	case TypeTree:
+		return "tree"
+	case TypeBlob:
+		return "blob"
+	case TypeTag:
+		return "tag"
+	}
+	return "unknown"
+}
+
+func parseType(s string) (Type, error) {
+	switch s {
+	case "commit":
+		return TypeCommit, nil
+	case "tree":
+		return TypeTree, nil
+	case "blob":
+		return TypeBlob, nil
+	case "tag":
+		return TypeTag, nil
+	}
+	return 0, fmt.Errorf("unknown object type %q", s)
+}
+
+// ErrNotFound is returned for objects missing from the database.
+var ErrNotFound = errors.New("object not found")
+
+// Signature is an author or committer line.
+type Signature struct {
+	Name  string
+	Email string
+	When  time.Time
+}
+
+// Commit is a parsed commit object.
+type Commit struct {
+	Hash      Hash
+	Tree      Hash
+	Parents   []Hash
+	Author    Signature
+	Committer Signature
+	Message   string
+}
+
+// Subject returns the first paragraph of the message joined into one line,
+// as git log's %s does.
+func (c *Commit) Subject() string {
+	subject, _ := splitMessage(c.Message)
+	return subject
+}
+
+// Body returns the message after the subject paragraph, as git log's %b
+// does, without surrounding whitespace.
+func (c *Commit) Body() string {
+	_, body := splitMessage(c.Message)
+	return body
+}
+
+func splitMessage(msg string) (string, string) {
+	lines := strings.Split(msg, "\n")
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	subject := []string{}
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		subject = append(subject, strings.TrimRight(lines[i], " \t\r"))
+		i++
+	}
+	return strings.Join(subject, " "), strings.TrimSpace(strings.Join(lines[i:], "\n"))
+}
+
+// ParseCommit parses the body of a commit object.
+func ParseCommit(h Hash, data []byte) (*Commit, error) {
+	c := &Commit{Hash: h}
+	rest := data
+	for len(rest) > 0 {
+		nl := bytes.IndexByte(rest, '\n')
+		if nl < 0 {
+			nl = len(rest)
+		}
+		line := string(rest[:nl])
+		if nl < len(rest) {
+			rest = rest[nl+1:]
+		} else {
+			rest = nil
+		}
+		if line == "" {
+			c.Message = string(rest)
+			break
+		}
+		if strings.HasPrefix(line, " ") {
+			// Continuation of a multi-line header such as gpgsig.
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		var err error
+		switch key {
+		case "tree":
+			c.Tree, err = ParseHash(value)
+		case "parent":
+			var p Hash
+			p, err = ParseHash(value)
+			c.Parents = append(c.Parents, p)
+		case "author":
+			c.Author, err = parseSignature(value)
+		case "committer":
+			c.Committer, err = parseSignature(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("commit %s: %w", h, err)
+		}
+	}
+	return c, nil
+}
+
+// parseSignature parses "Name <email> 1700000000 +0100".
+func parseSignature(s string) (Signature, error) {
+	open := strings.IndexByte(s, '<')
+	closeIdx := strings.LastIndexByte(s, '>')
+	if open < 0 || closeIdx < open {
+		return Signature{}, fmt.Errorf("malformed signature %q", s)
+	}
+	sig := Signature{
+		Name:  strings.TrimSpace(s[:open]),
+		Email: s[open+1 : closeIdx],
+	}
+	fields := strings.Fields(s[closeIdx+1:])
+	if len(fields) == 0 {
+		return sig, nil
+	}
+	secs, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Signature{}, fmt.Errorf("malformed signature time %q", s)
+	}
+	loc := time.UTC
+	if len(fields) > 1 && len(fields[1]) == 5 {
+		hours, errH := strconv.Atoi(fields[1][1:3])
+		mins, errM := strconv.Atoi(fields[1][3:5])
+		if errH == nil && errM == nil {
+			offset := hours*3600 + mins*60
+			if fields[1][0] == '-' {
+				offset = -offset
+			}
+			loc = time.FixedZone("", offset)
+		}
+	}
+	sig.When = time.Unix(secs, 0).In(loc)
+	return sig, nil
+}
+
+// Tree entry modes.
+const (
+	ModeDir     = 0o040000
+	ModeGitlink = 0o160000
+)
+
+// TreeEntry is one entry of a tree object.
+type TreeEntry struct {
+	Mode uint32
+	Name string
+	Hash Hash
+}
+
+// IsDir reports whether the entry is a subtree.
+func (e TreeEntry) IsDir() bool {
+	return e.Mode == ModeDir
+}
+
+// ParseTree parses the body of a tree object.
+func ParseTree(data []byte) ([]TreeEntry, error) {
+	entries := []TreeEntry{}
+	for len(data) > 0 {
+		sp := bytes.IndexByte(data, ' ')
+		if sp < 0 {
+			return nil, errors.New("malformed tree entry")
+		}
+		mode, err := strconv.ParseUint(string(data[:sp]), 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("malformed tree mode %q", data[:sp])
+		}
+		data = data[sp+1:]
+		nul := bytes.IndexByte(data, 0)
+		if nul < 0 || len(data) < nul+1+20 {
+			return nil, errors.New("malformed tree entry")
+		}
+		e := TreeEntry{Mode: uint32(mode), Name: string(data[:nul])}
+		copy(e.Hash[:], data[nul+1:nul+21])
+		entries = append(entries, e)
+		data = data[nul+21:]
+	}
+	return entries, nil
+}
+
+// parseTagTarget returns the object an annotated tag points at.
+func parseTagTarget(data []byte) (Hash, error) {
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	value, ok := strings.CutPrefix(string(line), "object ")
+	if !ok {
+		return Hash{}, errors.New("malformed tag object")
+	}
+	return ParseHash(value)
+}
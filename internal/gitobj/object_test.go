@@ -0,0 +1,63 @@
+package gitobj
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCommit(t *testing.T) {
+	data := strings.Join([]string{
+		"tree 4b825dc642cb6eb9a060e54bf8d69288fbee4904",
+		"parent 1111111111111111111111111111111111111111",
+		"parent 2222222222222222222222222222222222222222",
+		"author Jane Doe <jane@example.com> 1706774400 +0130",
+		"committer Bot <bot@example.com> 1706778000 -0700",
+		"gpgsig -----BEGIN PGP SIGNATURE-----",
+		" abc",
+		" -----END PGP SIGNATURE-----",
+		"",
+		"Fix the parser",
+		"for real this time",
+		"",
+		"Body line one.",
+		"",
+		"Signed-off-by: Jane Doe <jane@example.com>",
+		"",
+	}, "\n")
+	c, err := ParseCommit(Hash{}, []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Parents) != 2 || c.Parents[1].String() != strings.Repeat("2", 40) {
+		t.Fatalf("parents = %v", c.Parents)
+	}
+	if c.Author.Name != "Jane Doe" || c.Author.Email != "jane@example.com" {
+		t.Fatalf("author = %+v", c.Author)
+	}
+	if _, offset := c.Author.When.Zone(); offset != 90*60 || !c.Author.When.Equal(time.Unix(1706774400, 0)) {
+		t.Fatalf("author time = %v", c.Author.When)
+	}
+	if got := c.Subject(); got != "Fix the parser for real this time" {
+		t.Fatalf("subject = %q", got)
+	}
+	if got := c.Body(); got != "Body line one.\n\nSigned-off-by: Jane Doe <jane@example.com>" {
+		t.Fatalf("body = %q", got)
+	}
+}
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("hello, world\n")
+	// Sizes 13 -> 12, copy 5 bytes from offset 0, insert " gopher".
+	delta := []byte{13, 12, 0x90, 5, 7, ' ', 'g', 'o', 'p', 'h', 'e', 'r'}
+	got, err := applyDelta(base, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello gopher" {
+		t.Fatalf("applyDelta = %q", got)
+	}
+	if _, err := applyDelta(base[:4], delta); err == nil {
+		t.Fatal("expected an error for a base of the wrong size")
+	}
+}
@@ -0,0 +1,239 @@
+package gitobj
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// Packfile object types beyond the four base types.
+const (
+	typeOfsDelta = 6
+	typeRefDelta = 7
+)
+
+// pack is an open packfile with its version 2 index.
+type pack struct {
+	path    string
+	file    *os.File
+	fanout  [256]uint32
+	names   []byte
+	offsets []byte
+	large   []byte
+}
+
+func openPack(idxPath, packPath string) (*pack, error) {
+	idx, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(idx) < 8+256*4 || !bytes.Equal(idx[:4], []byte{0xff, 't', 'O', 'c'}) || binary.BigEndian.Uint32(idx[4:8]) != 2 {
+		return nil, fmt.Errorf("%s: unsupported pack index version", idxPath)
+	}
+	p := &pack{path: packPath}
+	for i := 0; i < 256; i++ {
+		p.fanout[i] = binary.BigEndian.Uint32(idx[8+i*4:])
+	}
+	n := int(p.fanout[255])
+	pos := 8 + 256*4
+	if len(idx) < pos+n*(20+4+4) {
+		return nil, fmt.Errorf("%s: truncated pack index", idxPath)
+	}
+	p.names = idx[pos : pos+n*20]
+	pos += n * 20
+	pos += n * 4 // CRC32 table
+	p.offsets = idx[pos : pos+n*4]
+	pos += n * 4
+	p.large = idx[pos:]
+	f, err := os.Open(packPath)
+	if err != nil {
+		return nil, err
+	}
+	p.file = f
+	return p, nil
+}
+
+func (p *pack) close() error {
+	return p.file.Close()
+}
+
+func (p *pack) count() int {
+	return int(p.fanout[255])
+}
+
+func (p *pack) name(i int) Hash {
+	var h Hash
+	copy(h[:], p.names[i*20:])
+	return h
+}
+
+// find returns the packfile offset of h.
+func (p *pack) find(h Hash) (int64, bool) {
+	lo := 0
+	if h[0] > 0 {
+		lo = int(p.fanout[h[0]-1])
+	}
+	hi := int(p.fanout[h[0]])
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(p.names[(lo+i)*20:(lo+i)*20+20], h[:]) >= 0
+	})
+	if i >= hi || !bytes.Equal(p.names[i*20:i*20+20], h[:]) {
+		return 0, false
+	}
+	off := binary.BigEndian.Uint32(p.offsets[i*4:])
+	if off&0x80000000 == 0 {
+		return int64(off), true
+	}
+	j := int(off & 0x7fffffff)
+	if len(p.large) < (j+1)*8 {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(p.large[j*8:])), true
+}
+
+// packEntry is the header of one packfile object.
+type packEntry struct {
+	typ  int
+	size int64
+	// base is set for ref deltas, baseOffset for offset deltas.
+	base       Hash
+	baseOffset int64
+	// data reads the compressed payload after the header.
+	data *bufio.Reader
+}
+
+func (p *pack) entry(offset int64) (packEntry, error) {
+	r := bufio.NewReader(io.NewSectionReader(p.file, offset, 1<<62))
+	b, err := r.ReadByte()
+	if err != nil {
+		return packEntry{}, err
+	}
+	e := packEntry{typ: int(b>>4) & 7, size: int64(b & 0x0f)}
+	shift := 4
+	for b&0x80 != 0 {
+		if b, err = r.ReadByte(); err != nil {
+			return packEntry{}, err
+		}
+		e.size |= int64(b&0x7f) << shift
+		shift += 7
+	}
+	switch e.typ {
+	case typeOfsDelta:
+		b, err = r.ReadByte()
+		if err != nil {
+			return packEntry{}, err
+		}
+		rel := int64(b & 0x7f)
+		for b&0x80 != 0 {
+			if b, err = r.ReadByte(); err != nil {
+				return packEntry{}, err
+			}
+			rel = ((rel + 1) << 7) | int64(b&0x7f)
+		}
+		e.baseOffset = offset - rel
+	case typeRefDelta:
+		if _, err := io.ReadFull(r, e.base[:]); err != nil {
+			return packEntry{}, err
+		}
+	}
+	e.data = r
+	return e, nil
+}
+
+// inflate decompresses exactly size bytes from r.
+func inflate(r io.Reader, size int64) ([]byte, error) {
+	zr, err := zlib.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out := make([]byte, size)
+	if _, err := io.ReadFull(zr, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var errDelta = errors.New("corrupt delta")
+
+// applyDelta rebuilds an object from its base and a git delta.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	readSize := func() (int, error) {
+		size, shift := 0, 0
+		for {
+			if len(delta) == 0 {
+				return 0, errDelta
+			}
+			b := delta[0]
+			delta = delta[1:]
+			size |= int(b&0x7f) << shift
+			shift += 7
+			if b&0x80 == 0 {
+				return size, nil
+			}
+		}
+	}
+	srcSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+	if srcSize != len(base) {
+		return nil, errDelta
+	}
+	dstSize, err := readSize()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+		switch {
+		case op&0x80 != 0:
+			var offset, size int
+			for i := 0; i < 4; i++ {
+				if op&(1<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errDelta
+					}
+					offset |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			for i := 0; i < 3; i++ {
+				if op&(0x10<<i) != 0 {
+					if len(delta) == 0 {
+						return nil, errDelta
+					}
+					size |= int(delta[0]) << (8 * i)
+					delta = delta[1:]
+				}
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > len(base) {
+				return nil, errDelta
+			}
+			out = append(out, base[offset:offset+size]...)
+		case op != 0:
+			if int(op) > len(delta) {
+				return nil, errDelta
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+		default:
+			return nil, errDelta
+		}
+	}
+	if len(out) != dstSize {
+		return nil, errDelta
+	}
+	return out, nil
+}
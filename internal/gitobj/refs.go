@@ -0,0 +1,145 @@
+package gitobj
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ErrUnbornHead is returned when HEAD names a branch with no commits yet.
+var ErrUnbornHead = errors.New("HEAD does not point to a commit yet")
+
+// Head resolves HEAD to a commit-ish object name.
+func (r *Repo) Head() (Hash, error) {
+	h, ok, err := r.Ref("HEAD")
+	if err != nil {
+		return Hash{}, err
+	}
+	if !ok {
+		return Hash{}, ErrUnbornHead
+	}
+	return h, nil
+}
+
+// Ref resolves a full ref name such as "HEAD" or "refs/heads/main",
+// following symbolic refs. ok is false when the ref does not exist.
+func (r *Repo) Ref(name string) (Hash, bool, error) {
+	for depth := 0; depth < 8; depth++ {
+		data, err := os.ReadFile(r.refPath(name))
+		if err != nil {
+			// Missing, or a directory such as refs/heads: only packed-refs
+			// can still have it.
+			packed, err := r.packedRefs()
+			if err != nil {
+				return Hash{}, false, err
+			}
+			h, ok := packed[name]
+			return h, ok, nil
+		}
+		value := strings.TrimSpace(string(data))
+		if target, ok := strings.CutPrefix(value, "ref: "); ok {
+			name = strings.TrimSpace(target)
+			continue
+		}
+		h, err := ParseHash(value)
+		if err != nil {
+			return Hash{}, false, fmt.Errorf("ref %s: %w", name, err)
+		}
+		return h, true, nil
+	}
+	return Hash{}, false, fmt.Errorf("ref %s: symbolic ref loop", name)
+}
+
+// Refs lists the refs under prefix (e.g. "refs/heads/") with their object
+// names, sorted by name. Loose refs take precedence over packed ones.
+func (r *Repo) Refs(prefix string) (map[string]Hash, []string, error) {
+	refs := map[string]Hash{}
+	packed, err := r.packedRefs()
+	if err != nil {
+		return nil, nil, err
+	}
+	for name, h := range packed {
+		if strings.HasPrefix(name, prefix) {
+			refs[name] = h
+		}
+	}
+	root := filepath.Join(r.commonDir, filepath.FromSlash(strings.TrimSuffix(prefix, "/")))
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(r.commonDir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		h, ok, err := r.Ref(name)
+		if err != nil || !ok {
+			// Skip lock files and other debris.
+			return nil
+		}
+		refs[name] = h
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return refs, names, nil
+}
+
+// refPath places per-worktree refs (HEAD and friends) in the git directory
+// and shared refs in the common directory.
+func (r *Repo) refPath(name string) string {
+	if !strings.HasPrefix(name, "refs/") || strings.HasPrefix(name, "refs/bisect/") || strings.HasPrefix(name, "refs/worktree/") {
+		return filepath.Join(r.gitDir, filepath.FromSlash(name))
+	}
+	return filepath.Join(r.commonDir, filepath.FromSlash(name))
+}
+
+func (r *Repo) packedRefs() (map[string]Hash, error) {
+	refs := map[string]Hash{}
+	f, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		value, name, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		h, err := ParseHash(value)
+		if err != nil {
+			return nil, fmt.Errorf("packed-refs: %w", err)
+		}
+		refs[name] = h
+	}
+	return refs, scanner.Err()
+}
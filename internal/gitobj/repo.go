@@ -0,0 +1,374 @@
+package gitobj
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// cacheLimit bounds the bytes of inflated pack objects kept for delta bases.
+const cacheLimit = 64 << 20
+
+// Repo is an open object database and ref store.
+type Repo struct {
+	gitDir    string
+	commonDir string
+	objDirs   []string
+	packs     []*pack
+	// shallow holds the boundary commits of a shallow clone, whose
+	// parents were not fetched.
+	shallow map[Hash]bool
+
+	mu         sync.Mutex
+	cache      map[cacheKey]cachedObject
+	cacheOrder []cacheKey
+	cacheBytes int
+}
+
+type cacheKey struct {
+	pack   *pack
+	offset int64
+}
+
+type cachedObject struct {
+	typ  Type
+	data []byte
+}
+
+// Open opens the repository whose git directory is gitDir. Linked worktrees
+// are followed to their common directory for objects and shared refs, and
+// alternates are honored.
+func Open(gitDir string) (*Repo, error) {
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+		return nil, fmt.Errorf("%s: not a git directory", gitDir)
+	}
+	r := &Repo{gitDir: gitDir, commonDir: gitDir, cache: map[cacheKey]cachedObject{}}
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		dir := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(gitDir, dir)
+		}
+		r.commonDir = filepath.Clean(dir)
+	}
+	if cfg, err := os.ReadFile(filepath.Join(r.commonDir, "config")); err == nil {
+		if strings.Contains(strings.ToLower(string(cfg)), "objectformat = sha256") {
+			return nil, errors.New("sha256 repositories are not supported")
+		}
+	}
+	if err := r.addObjectDir(filepath.Join(r.commonDir, "objects"), 0); err != nil {
+		r.Close()
+		return nil, err
+	}
+	if data, err := os.ReadFile(filepath.Join(r.commonDir, "shallow")); err == nil {
+		r.shallow = map[Hash]bool{}
+		for _, line := range strings.Fields(string(data)) {
+			if h, err := ParseHash(line); err == nil {
+				r.shallow[h] = true
+			}
+		}
+	}
+	return r, nil
+}
+
+// addObjectDir registers an objects directory, its packs and its alternates.
+func (r *Repo) addObjectDir(dir string, depth int) error {
+	if depth > 5 {
+		return nil
+	}
+	for _, known := range r.objDirs {
+		if known == dir {
+			return nil
+		}
+	}
+	r.objDirs = append(r.objDirs, dir)
+	idxs, _ := filepath.Glob(filepath.Join(dir, "pack", "*.idx"))
+	for _, idx := range idxs {
+		p, err := openPack(idx, strings.TrimSuffix(idx, ".idx")+".pack")
+		if err != nil {
+			return err
+		}
+		r.packs = append(r.packs, p)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "info", "alternates"))
+	if err != nil {
+		return nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(dir, line)
+		}
+		if err := r.addObjectDir(filepath.Clean(line), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CommonDir returns the directory holding objects and shared refs.
+func (r *Repo) CommonDir() string {
+	return r.commonDir
+}
+
+// Shallow returns the boundary commits of a shallow clone, or nil for a
+// full one.
+func (r *Repo) Shallow() []Hash {
+	if len(r.shallow) == 0 {
+		return nil
+	}
+	out := make([]Hash, 0, len(r.shallow))
+	for h := range r.shallow {
+		out = append(out, h)
+	}
+	sort.Slice(out, func(i, j int) bool { return bytes.Compare(out[i][:], out[j][:]) < 0 })
+	return out
+}
+
+// Close releases the open packfiles.
+func (r *Repo) Close() error {
+	var first error
+	for _, p := range r.packs {
+		if err := p.close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Has reports whether the object exists.
+func (r *Repo) Has(h Hash) bool {
+	for _, p := range r.packs {
+		if _, ok := p.find(h); ok {
+			return true
+		}
+	}
+	for _, dir := range r.objDirs {
+		if _, err := os.Stat(looseObjectPath(dir, h)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Read returns the type and content of an object. The content may be shared
+// with the delta cache and must not be modified.
+func (r *Repo) Read(h Hash) (Type, []byte, error) {
+	for _, p := range r.packs {
+		if off, ok := p.find(h); ok {
+			return r.readPacked(p, off, 0)
+		}
+	}
+	for _, dir := range r.objDirs {
+		typ, data, err := readLoose(looseObjectPath(dir, h))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return 0, nil, fmt.Errorf("object %s: %w", h, err)
+		}
+		return typ, data, nil
+	}
+	return 0, nil, fmt.Errorf("object %s: %w", h, ErrNotFound)
+}
+
+// Commit reads and parses a commit, peeling annotated tags.
+func (r *Repo) Commit(h Hash) (*Commit, error) {
+	h, err := r.Peel(h)
+	if err != nil {
+		return nil, err
+	}
+	typ, data, err := r.Read(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TypeCommit {
+		return nil, fmt.Errorf("object %s is a %s, not a commit", h, typ)
+	}
+	c, err := ParseCommit(h, data)
+	if err == nil && r.shallow[h] {
+		// As in git, a boundary commit of a shallow clone has no parents.
+		c.Parents = nil
+	}
+	return c, err
+}
+
+// Tree reads and parses a tree.
+func (r *Repo) Tree(h Hash) ([]TreeEntry, error) {
+	typ, data, err := r.Read(h)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TypeTree {
+		return nil, fmt.Errorf("object %s is a %s, not a tree", h, typ)
+	}
+	return ParseTree(data)
+}
+
+// Peel follows annotated tags to the object they point at.
+func (r *Repo) Peel(h Hash) (Hash, error) {
+	for i := 0; i < 16; i++ {
+		typ, data, err := r.Read(h)
+		if err != nil {
+			return h, err
+		}
+		if typ != TypeTag {
+			return h, nil
+		}
+		if h, err = parseTagTarget(data); err != nil {
+			return h, err
+		}
+	}
+	return h, errors.New("tag chain too deep")
+}
+
+// FindPrefix resolves an abbreviated hex object name. It fails when the
+// prefix is ambiguous or matches nothing.
+func (r *Repo) FindPrefix(prefix string) (Hash, error) {
+	prefix = strings.ToLower(prefix)
+	if len(prefix) < 4 || len(prefix) > 40 || strings.Trim(prefix, "0123456789abcdef") != "" {
+		return Hash{}, fmt.Errorf("invalid object name %q", prefix)
+	}
+	if len(prefix) == 40 {
+		return ParseHash(prefix)
+	}
+	matches := map[Hash]struct{}{}
+	for _, p := range r.packs {
+		for i := 0; i < p.count(); i++ {
+			if h := p.name(i); strings.HasPrefix(h.String(), prefix) {
+				matches[h] = struct{}{}
+			}
+		}
+	}
+	for _, dir := range r.objDirs {
+		entries, _ := os.ReadDir(filepath.Join(dir, prefix[:2]))
+		for _, e := range entries {
+			name := prefix[:2] + e.Name()
+			if strings.HasPrefix(name, prefix) {
+				if h, err := ParseHash(name); err == nil {
+					matches[h] = struct{}{}
+				}
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Hash{}, fmt.Errorf("object %s: %w", prefix, ErrNotFound)
+	case 1:
+		for h := range matches {
+			return h, nil
+		}
+	}
+	return Hash{}, fmt.Errorf("short object name %s is ambiguous", prefix)
+}
+
+func (r *Repo) readPacked(p *pack, offset int64, depth int) (Type, []byte, error) {
+	if depth > 64 {
+		return 0, nil, errors.New("delta chain too deep")
+	}
+	key := cacheKey{p, offset}
+	r.mu.Lock()
+	if obj, ok := r.cache[key]; ok {
+		r.mu.Unlock()
+		return obj.typ, obj.data, nil
+	}
+	r.mu.Unlock()
+
+	e, err := p.entry(offset)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", p.path, err)
+	}
+	payload, err := inflate(e.data, e.size)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s at %d: %w", p.path, offset, err)
+	}
+	var typ Type
+	var data []byte
+	switch e.typ {
+	case typeOfsDelta, typeRefDelta:
+		var base []byte
+		if e.typ == typeOfsDelta {
+			typ, base, err = r.readPacked(p, e.baseOffset, depth+1)
+		} else {
+			typ, base, err = r.Read(e.base)
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		if data, err = applyDelta(base, payload); err != nil {
+			return 0, nil, fmt.Errorf("%s at %d: %w", p.path, offset, err)
+		}
+	default:
+		typ, data = Type(e.typ), payload
+	}
+	r.remember(key, cachedObject{typ: typ, data: data})
+	return typ, data, nil
+}
+
+// remember caches an inflated object, evicting the oldest entries once the
+// cache is over its byte limit. Very large objects are not cached.
+func (r *Repo) remember(key cacheKey, obj cachedObject) {
+	if len(obj.data) > cacheLimit/8 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.cache[key]; ok {
+		return
+	}
+	r.cache[key] = obj
+	r.cacheOrder = append(r.cacheOrder, key)
+	r.cacheBytes += len(obj.data)
+	for r.cacheBytes > cacheLimit && len(r.cacheOrder) > 0 {
+		old := r.cacheOrder[0]
+		r.cacheOrder = r.cacheOrder[1:]
+		r.cacheBytes -= len(r.cache[old].data)
+		delete(r.cache, old)
+	}
+}
+
+func looseObjectPath(dir string, h Hash) string {
+	s := h.String()
+	return filepath.Join(dir, s[:2], s[2:])
+}
+
+func readLoose(path string) (Type, []byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return 0, nil, err
+	}
+	nul := bytes.IndexByte(raw, 0)
+	if nul < 0 {
+		return 0, nil, errors.New("malformed loose object")
+	}
+	kind, sizeText, _ := strings.Cut(string(raw[:nul]), " ")
+	typ, err := parseType(kind)
+	if err != nil {
+		return 0, nil, err
+	}
+	size, err := strconv.Atoi(sizeText)
+	if err != nil || size != len(raw)-nul-1 {
+		return 0, nil, errors.New("malformed loose object size")
+	}
+	return typ, raw[nul+1:], nil
+}
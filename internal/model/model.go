@@ -2,9 +2,15 @@ package model
 
 import "time"
 
+// SchemaVersion is the version of the JSON report shape. Bump it whenever a
+// field is added, renamed or removed, and record the change in render's
+// compat table so older shapes can still be produced.
+const SchemaVersion = 11
+
 // Config controls analysis and rendering behavior.
 type Config struct {
 	Path       string
+	ConfigFile string
 	Since      string
 	Until      string
 	Author     string
@@ -17,6 +23,45 @@ type Config struct {
 	MaxCommits int
 	TZ         string
 	Explain    bool
+	JSONCompat int
+	Trend      string
+	Range      string
+	// CompareRange holds the two revision ranges for --compare-range.
+	CompareRange []string
+	// Revisions selects commits with raw git log revisions, as the pre-push
+	// hook does; it takes precedence over Range.
+	Revisions   []string
+	Baseline    string
+	NoBaseline  bool
+	FailOn      []string
+	Top         int
+	ExcludeBots bool
+	// Backend selects how git data is read: "exec" or "native".
+	Backend string
+	// Jobs bounds concurrent numstat workers; 0 means one per CPU.
+	Jobs int
+	// NoCache skips the on-disk commit cache.
+	NoCache bool
+	// Incremental adds the commits made since the previous incremental run
+	// to the totals it saved. It implies Deep.
+	Incremental bool
+	// Timeout bounds the whole run; 0 means no limit.
+	Timeout time.Duration
+	// GitTimeout bounds each short git command; 0 means no limit.
+	GitTimeout time.Duration
+	// RefuseShallow fails a --fail-on gate instead of evaluating it when a
+	// shallow clone cut off the analyzed history.
+	RefuseShallow bool
+	// Paths, ReposFile and Scan select several repositories for a combined
+	// report; Path is the first of Paths.
+	Paths     []string
+	ReposFile string
+	Scan      string
+	// Quiet suppresses progress output on stderr.
+	Quiet bool
+	// RecurseSubmodules nests a report of each checked-out submodule,
+	// over the same date window.
+	RecurseSubmodules bool
 }
 
 // RepoInfo describes the repository under analysis.
@@ -29,12 +74,14 @@ type RepoInfo struct {
 
 // Filters capture applied filters.
 type Filters struct {
-	Since      string `json:"since,omitempty"`
-	Until      string `json:"until,omitempty"`
-	Author     string `json:"author,omitempty"`
-	MaxCommits int    `json:"max_commits,omitempty"`
-	TZ         string `json:"tz"`
-	Deep       bool   `json:"deep"`
+	Since       string `json:"since,omitempty"`
+	Until       string `json:"until,omitempty"`
+	Author      string `json:"author,omitempty"`
+	MaxCommits  int    `json:"max_commits,omitempty"`
+	Range       string `json:"range,omitempty"`
+	ExcludeBots bool   `json:"exclude_bots,omitempty"`
+	TZ          string `json:"tz"`
+	Deep        bool   `json:"deep"`
 }
 
 // Commit represents a single commit.
@@ -44,16 +91,24 @@ type Commit struct {
 	AuthorEmail string
 	Date        time.Time
 	Subject     string
+	Body        string
 	Parents     []string
 	Size        *CommitSize
 }
 
-// CommitSize summarizes file/line changes.
+// CommitSize summarizes file/line changes. Submodule pointer (gitlink)
+// changes are counted in Submodules, not as files or lines.
 type CommitSize struct {
 	Files       int
 	Added       int
 	Deleted     int
 	BinaryFiles int
+	Submodules  int
+}
+
+// SubmoduleBump reports whether the commit only moved submodule pointers.
+func (s CommitSize) SubmoduleBump() bool {
+	return s.Submodules > 0 && s.Files == 0
 }
 
 // Metrics groups computed metrics.
@@ -62,6 +117,23 @@ type Metrics struct {
 	Time    TimeMetrics    `json:"time"`
 	Hygiene HygieneMetrics `json:"hygiene"`
 	Size    SizeMetrics    `json:"size"`
+	// Exemptions counts checks skipped by Roastgit-Ignore trailers and
+	// config allowlists.
+	Exemptions ExemptionMetrics `json:"exemptions"`
+	Submodules SubmoduleMetrics `json:"submodules"`
+}
+
+// SubmoduleMetrics counts sized commits that moved submodule pointers.
+// Bumps changed nothing else and are left out of size metrics.
+type SubmoduleMetrics struct {
+	Commits int `json:"commits"`
+	Bumps   int `json:"bumps"`
+}
+
+// ExemptionMetrics counts applied exemptions.
+type ExemptionMetrics struct {
+	Commits int `json:"commits"`
+	Checks  int `json:"checks"`
 }
 
 // MessageMetrics captures commit message stats.
@@ -71,6 +143,7 @@ type MessageMetrics struct {
 	EmojiOnly       int      `json:"emoji_only"`
 	TooLong         int      `json:"too_long"`
 	TooShort        int      `json:"too_short"`
+	Fixup           int      `json:"fixup"`
 	Lying           int      `json:"lying"`
 	Panic           int      `json:"panic"`
 	LowQuality      int      `json:"low_quality"`
@@ -124,6 +197,67 @@ type Score struct {
 	Explain   map[string]string `json:"explain,omitempty"`
 }
 
+// Trend is a per-period score time series.
+type Trend struct {
+	Period string       `json:"period"`
+	Points []TrendPoint `json:"points"`
+}
+
+// TrendPoint is the score of the commits in one period.
+type TrendPoint struct {
+	Label     string         `json:"label"`
+	Start     string         `json:"start"`
+	Commits   int            `json:"commits"`
+	Overall   int            `json:"overall"`
+	Breakdown ScoreBreakdown `json:"breakdown"`
+}
+
+// BaselineInfo describes the baseline applied to the offenders.
+type BaselineInfo struct {
+	Path       string `json:"path"`
+	Entries    int    `json:"entries"`
+	Suppressed int    `json:"suppressed"`
+}
+
+// GateResult is the outcome of a --fail-on gate.
+type GateResult struct {
+	FailOn     []string `json:"fail_on"`
+	Violations int      `json:"violations"`
+	Failed     bool     `json:"failed"`
+}
+
+// PartialInfo marks a report cut short by an interrupt or --timeout. Its
+// metrics cover only the commits and sizes read before the run stopped.
+type PartialInfo struct {
+	Reason string `json:"reason"` // "interrupted" or "timeout"
+	Stage  string `json:"stage"`  // "log" or "numstat"
+}
+
+// ShallowInfo warns that a shallow clone cut off the analyzed history, so
+// streaks, averages and totals only cover the fetched commits.
+type ShallowInfo struct {
+	// Boundary lists the analyzed commits whose parents were not fetched.
+	// Their sizes would count the whole tree and are left out.
+	Boundary []string `json:"boundary"`
+}
+
+// AuthorStats summarizes one author's commits.
+type AuthorStats struct {
+	Name           string  `json:"name"`
+	Email          string  `json:"email"`
+	Commits        int     `json:"commits"`
+	LowQuality     int     `json:"low_quality"`
+	AverageQuality float64 `json:"average_quality"`
+}
+
+// AutomationSummary describes bot commits, kept apart from human stats.
+type AutomationSummary struct {
+	Commits  int           `json:"commits"`
+	Ratio    float64       `json:"ratio"`
+	Excluded bool          `json:"excluded"`
+	Bots     []AuthorStats `json:"bots"`
+}
+
 // Offender marks roastable commits.
 type Offender struct {
 	SHA     string   `json:"sha"`
@@ -131,6 +265,12 @@ type Offender struct {
 	Date    string   `json:"date"`
 	Reasons []string `json:"reasons"`
 	Score   int      `json:"-"`
+	// Author is the commit's author name, for filtering in the TUI.
+	Author string `json:"-"`
+	// Checks and Weights hold the rule ID and score behind each reason,
+	// when the offender comes from an analysis rather than a JSON report.
+	Checks  []string `json:"-"`
+	Weights []int    `json:"-"`
 }
 
 // RoastOutput captures generated roast text.
@@ -142,10 +282,173 @@ type RoastOutput struct {
 
 // Report is the full analysis output.
 type Report struct {
-	Repo      RepoInfo    `json:"repo"`
-	Filters   Filters     `json:"filters"`
-	Score     Score       `json:"score"`
-	Metrics   Metrics     `json:"metrics"`
-	Offenders []Offender  `json:"offenders"`
-	Roasts    RoastOutput `json:"roasts"`
+	SchemaVersion int               `json:"schema_version"`
+	Repo          RepoInfo          `json:"repo"`
+	Filters       Filters           `json:"filters"`
+	Score         Score             `json:"score"`
+	Metrics       Metrics           `json:"metrics"`
+	Offenders     []Offender        `json:"offenders"`
+	Roasts        RoastOutput       `json:"roasts"`
+	Trend         *Trend            `json:"trend,omitempty"`
+	Baseline      *BaselineInfo     `json:"baseline,omitempty"`
+	Gate          *GateResult       `json:"gate,omitempty"`
+	Authors       []AuthorStats     `json:"authors"`
+	Automation    AutomationSummary `json:"automation"`
+	Partial       *PartialInfo      `json:"partial,omitempty"`
+	Shallow       *ShallowInfo      `json:"shallow,omitempty"`
+	// Submodules holds a report per submodule with --recurse-submodules.
+	Submodules []SubmoduleReport `json:"submodules,omitempty"`
+}
+
+// SubmoduleReport is the nested report of one submodule, or why it could
+// not be analyzed.
+type SubmoduleReport struct {
+	Path   string  `json:"path"`
+	Report *Report `json:"report,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// MessageReport is the verdict of check-msg on a pending commit message.
+type MessageReport struct {
+	Subject  string   `json:"subject"`
+	Score    int      `json:"score"`
+	Reasons  []string `json:"reasons"`
+	Exempted []string `json:"exempted"`
+	Roast    string   `json:"roast"`
+	Tips     []string `json:"tips"`
+	MinScore int      `json:"min_score"`
+	Rejected bool     `json:"rejected"`
+}
+
+// FileStat is the numstat line for one file in a commit.
+type FileStat struct {
+	Path    string `json:"path"`
+	Added   int    `json:"added"`
+	Deleted int    `json:"deleted"`
+	Binary  bool   `json:"binary"`
+	// Submodule marks a submodule pointer (gitlink) change, which git
+	// counts as one "Subproject commit" line.
+	Submodule bool `json:"submodule,omitempty"`
+}
+
+// CommitDetail is the in-depth verdict of "roastgit show" on one commit.
+type CommitDetail struct {
+	SHA     string              `json:"sha"`
+	Author  string              `json:"author"`
+	Email   string              `json:"email"`
+	Date    string              `json:"date"`
+	Subject string              `json:"subject"`
+	Body    string              `json:"body,omitempty"`
+	Bot     bool                `json:"bot"`
+	Merge   bool                `json:"merge"`
+	Message CommitMessageDetail `json:"message"`
+	Size    CommitSizeDetail    `json:"size"`
+	Time    CommitTimeDetail    `json:"time"`
+	// PanicWindow is the burst of shaky commits this one belongs to, if any.
+	PanicWindow *PanicWindow `json:"panic_window"`
+	// FixChain lists the commit and the quick fixes around it, oldest first.
+	// It is empty unless at least one follow-up fix was found.
+	FixChain []ChainCommit `json:"fix_chain"`
+	Reasons  []string      `json:"reasons"`
+	Exempted []string      `json:"exempted"`
+	Roast    string        `json:"roast"`
+	Tips     []string      `json:"tips"`
+}
+
+// CommitMessageDetail holds the raw message checks, before exemptions.
+type CommitMessageDetail struct {
+	Score     int  `json:"score"`
+	Generic   bool `json:"generic"`
+	EmojiOnly bool `json:"emoji_only"`
+	TooLong   bool `json:"too_long"`
+	TooShort  bool `json:"too_short"`
+	Fixup     bool `json:"fixup"`
+	Lying     bool `json:"lying"`
+}
+
+// CommitSizeDetail is the size of one commit with its per-file numstat.
+type CommitSizeDetail struct {
+	Files       int        `json:"files"`
+	Added       int        `json:"added"`
+	Deleted     int        `json:"deleted"`
+	BinaryFiles int        `json:"binary_files"`
+	Submodules  int        `json:"submodules"`
+	Huge        bool       `json:"huge"`
+	PerFile     []FileStat `json:"per_file"`
+}
+
+// CommitTimeDetail places a commit in the day and week.
+type CommitTimeDetail struct {
+	Local    string `json:"local"`
+	Hour     int    `json:"hour"`
+	Weekday  string `json:"weekday"`
+	Midnight bool   `json:"midnight"`
+	Deadline bool   `json:"deadline"`
+}
+
+// PanicWindow is a burst of commits with mostly low-quality messages.
+type PanicWindow struct {
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	Commits    int    `json:"commits"`
+	LowQuality int    `json:"low_quality"`
+}
+
+// ChainCommit is one link of a fix chain.
+type ChainCommit struct {
+	SHA     string `json:"sha"`
+	Subject string `json:"subject"`
+	Date    string `json:"date"`
+}
+
+// Comparison is the difference between two reports.
+type Comparison struct {
+	Base                  ComparisonSide `json:"base"`
+	Head                  ComparisonSide `json:"head"`
+	Score                 []Delta        `json:"score"`
+	Metrics               []Delta        `json:"metrics"`
+	OffenderTypes         []Delta        `json:"offender_types"`
+	NewOffenderTypes      []string       `json:"new_offender_types"`
+	ResolvedOffenderTypes []string       `json:"resolved_offender_types"`
+}
+
+// ComparisonSide identifies one of the compared reports.
+type ComparisonSide struct {
+	Label       string `json:"label"`
+	Head        string `json:"head"`
+	CommitCount int    `json:"commit_count"`
+	Overall     int    `json:"overall"`
+}
+
+// Delta is the change of a single named value between two reports.
+type Delta struct {
+	Name   string  `json:"name"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Change float64 `json:"change"`
+}
+
+// MultiReport combines the reports of several repositories.
+type MultiReport struct {
+	// SchemaVersion is the version of each report in Repos.
+	SchemaVersion int `json:"schema_version"`
+	// Score is the commit-weighted mean of the repositories' scores.
+	Score       Score         `json:"score"`
+	CommitCount int           `json:"commit_count"`
+	Repos       []Report      `json:"repos"`
+	Authors     []OrgAuthor   `json:"authors"`
+	Failures    []RepoFailure `json:"failures"`
+}
+
+// OrgAuthor is one author's stats summed over every repository, matched by
+// email.
+type OrgAuthor struct {
+	AuthorStats
+	Repos []string `json:"repos"`
+}
+
+// RepoFailure is a repository that could not be analyzed.
+type RepoFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
 }
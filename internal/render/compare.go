@@ -0,0 +1,105 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"roastgit/internal/model"
+	"roastgit/internal/util"
+)
+
+// CompareJSON renders a comparison as pretty JSON.
+func CompareJSON(cmp model.Comparison) (string, error) {
+	b, err := json.MarshalIndent(cmp, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// CompareText renders a comparison with arrows showing the direction of change.
+func CompareText(cmp model.Comparison, cfg TextConfig) string {
+	b := &strings.Builder{}
+	color := func(s, code string) string {
+		if cfg.NoColor {
+			return s
+		}
+		return code + s + "\x1b[0m"
+	}
+	palette := pickPalette()
+	label := func(s string) string { return color(s, palette.Label) }
+	muted := func(s string) string { return color(s, palette.Muted) }
+	body := func(s string) string { return color(s, palette.Body) }
+	bulletPrefix := color("- ", palette.Bullet)
+
+	fmt.Fprintf(b, "%s\n", color("Roastgit Comparison", palette.Header))
+	for _, s := range []struct {
+		name string
+		side model.ComparisonSide
+	}{{"Base:", cmp.Base}, {"Head:", cmp.Head}} {
+		fmt.Fprintf(b, "%s %s %s\n", label(s.name), body(s.side.Label),
+			muted(fmt.Sprintf("(HEAD %s, %d commits, score %d)", util.ShortSHA(s.side.Head), s.side.CommitCount, s.side.Overall)))
+	}
+
+	// Higher scores are better, so score arrows are colored by direction.
+	fmt.Fprintf(b, "\n%s\n", color("Score", palette.Header))
+	for _, d := range cmp.Score {
+		arrowColor := palette.Muted
+		if d.Change > 0 {
+			arrowColor = palette.ScoreGood
+		} else if d.Change < 0 {
+			arrowColor = palette.ScoreBad
+		}
+		fmt.Fprintf(b, "%s%s %s\n", bulletPrefix, body(fmt.Sprintf("%-16s %s -> %s", d.Name, formatNumber(d.Before), formatNumber(d.After))), color(arrowWithChange(d.Change), arrowColor))
+	}
+
+	changed := changedDeltas(cmp.Metrics)
+	fmt.Fprintf(b, "\n%s\n", color("Metric Changes", palette.Header))
+	if len(changed) == 0 {
+		fmt.Fprintf(b, "%s%s\n", bulletPrefix, body("No metric changed."))
+	}
+	for _, d := range changed {
+		fmt.Fprintf(b, "%s%s %s\n", bulletPrefix, body(fmt.Sprintf("%-28s %s -> %s", d.Name, formatNumber(d.Before), formatNumber(d.After))), color(arrowWithChange(d.Change), palette.Accent))
+	}
+
+	fmt.Fprintf(b, "\n%s\n", color("Offender Types", palette.Header))
+	if len(cmp.NewOffenderTypes) > 0 {
+		fmt.Fprintf(b, "%s%s %s\n", bulletPrefix, label("New:"), color(strings.Join(cmp.NewOffenderTypes, ", "), palette.ScoreBad))
+	}
+	if len(cmp.ResolvedOffenderTypes) > 0 {
+		fmt.Fprintf(b, "%s%s %s\n", bulletPrefix, label("Resolved:"), color(strings.Join(cmp.ResolvedOffenderTypes, ", "), palette.ScoreGood))
+	}
+	for _, d := range changedDeltas(cmp.OffenderTypes) {
+		fmt.Fprintf(b, "%s%s %s\n", bulletPrefix, body(fmt.Sprintf("%-20s %s -> %s", d.Name, formatNumber(d.Before), formatNumber(d.After))), color(arrowWithChange(d.Change), palette.Accent))
+	}
+	return b.String()
+}
+
+func changedDeltas(deltas []model.Delta) []model.Delta {
+	out := []model.Delta{}
+	for _, d := range deltas {
+		if d.Change != 0 {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func arrowWithChange(change float64) string {
+	switch {
+	case change > 0:
+		return "↑ +" + formatNumber(change)
+	case change < 0:
+		return "↓ " + formatNumber(change)
+	}
+	return "="
+}
+
+func formatNumber(v float64) string {
+	if v == float64(int64(v)) {
+		return strconv.FormatInt(int64(v), 10)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"roastgit/internal/model"
+)
+
+// fieldsAddedIn lists the JSON paths introduced by each schema version.
+// Producing an older shape removes every path added after it. A path segment
+// of "[]" applies the rest of the path to each element of an array.
+var fieldsAddedIn = map[int][]string{
+	2:  {"schema_version"},
+	3:  {"trend"},
+	4:  {"filters.range"},
+	5:  {"baseline", "gate"},
+	6:  {"metrics.exemptions"},
+	7:  {"authors", "automation", "filters.exclude_bots"},
+	8:  {"metrics.message.fixup"},
+	9:  {"partial"},
+	10: {"shallow"},
+	11: {"submodules", "metrics.submodules"},
+}
+
+// JSONCompat renders the report in the shape of an older schema version.
+// Version 1 is the unversioned shape emitted before schema_version existed.
+func JSONCompat(report model.Report, version int) (string, error) {
+	if version == model.SchemaVersion {
+		return JSON(report)
+	}
+	if version < 1 || version > model.SchemaVersion {
+		return "", fmt.Errorf("unsupported schema version %d (supported: 1-%d)", version, model.SchemaVersion)
+	}
+	current, err := JSON(report)
+	if err != nil {
+		return "", err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(current), &doc); err != nil {
+		return "", err
+	}
+	for v := version + 1; v <= model.SchemaVersion; v++ {
+		for _, path := range fieldsAddedIn[v] {
+			removePath(doc, strings.Split(path, "."))
+		}
+	}
+	if version >= 2 {
+		doc["schema_version"] = version
+	}
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func removePath(node any, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	switch n := node.(type) {
+	case map[string]any:
+		if len(path) == 1 {
+			delete(n, path[0])
+			return
+		}
+		removePath(n[path[0]], path[1:])
+	case []any:
+		if path[0] != "[]" {
+			return
+		}
+		for _, item := range n {
+			removePath(item, path[1:])
+		}
+	}
+}
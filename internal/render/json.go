@@ -8,9 +8,36 @@ import (
 
 // JSON renders the report as pretty JSON.
 func JSON(report model.Report) (string, error) {
-	b, err := json.MarshalIndent(report, "", "  ")
+	b, err := json.MarshalIndent(normalize(report), "", "  ")
 	if err != nil {
 		return "", err
 	}
 	return string(b), nil
 }
+
+// normalize stamps the schema version and turns nil lists into empty ones,
+// as the schema requires, in the report and its nested submodule reports.
+func normalize(report model.Report) model.Report {
+	report.SchemaVersion = model.SchemaVersion
+	if report.Offenders == nil {
+		report.Offenders = []model.Offender{}
+	}
+	if report.Authors == nil {
+		report.Authors = []model.AuthorStats{}
+	}
+	if report.Automation.Bots == nil {
+		report.Automation.Bots = []model.AuthorStats{}
+	}
+	if report.Submodules != nil {
+		subs := make([]model.SubmoduleReport, len(report.Submodules))
+		for i, sub := range report.Submodules {
+			if sub.Report != nil {
+				nested := normalize(*sub.Report)
+				sub.Report = &nested
+			}
+			subs[i] = sub
+		}
+		report.Submodules = subs
+	}
+	return report
+}
@@ -0,0 +1,43 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"roastgit/internal/model"
+)
+
+// MessageJSON renders a check-msg verdict as pretty JSON.
+func MessageJSON(report model.MessageReport) (string, error) {
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// MessageText renders a check-msg verdict for the terminal.
+func MessageText(report model.MessageReport, cfg TextConfig) string {
+	b := &strings.Builder{}
+	color := func(s, code string) string {
+		if cfg.NoColor {
+			return s
+		}
+		return code + s + "\x1b[0m"
+	}
+	palette := pickPalette()
+	bulletPrefix := color("- ", palette.Bullet)
+
+	fmt.Fprintf(b, "%s %s\n", color(fmt.Sprintf("Commit message score: %d/100", report.Score), scoreColor(report.Score, palette)), color(report.Roast, palette.Accent))
+	if len(report.Reasons) > 0 {
+		fmt.Fprintf(b, "%s%s\n", bulletPrefix, color(strings.Join(report.Reasons, ", "), palette.Label))
+	}
+	if len(report.Exempted) > 0 {
+		fmt.Fprintf(b, "%s%s\n", bulletPrefix, color("ignored via trailer: "+strings.Join(report.Exempted, ", "), palette.Muted))
+	}
+	for _, tip := range report.Tips {
+		fmt.Fprintf(b, "%s%s\n", bulletPrefix, color(tip, palette.Body))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
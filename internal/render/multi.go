@@ -0,0 +1,128 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"roastgit/internal/model"
+)
+
+// MultiJSON renders a multi-repository report as pretty JSON. Each entry
+// of repos has the shape of a single report.
+func MultiJSON(multi model.MultiReport) (string, error) {
+	multi.SchemaVersion = model.SchemaVersion
+	repos := make([]model.Report, len(multi.Repos))
+	for i, r := range multi.Repos {
+		repos[i] = normalize(r)
+	}
+	multi.Repos = repos
+	if multi.Authors == nil {
+		multi.Authors = []model.OrgAuthor{}
+	}
+	if multi.Failures == nil {
+		multi.Failures = []model.RepoFailure{}
+	}
+	b, err := json.MarshalIndent(multi, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// MultiText renders the org-wide score, a line per repository and the
+// busiest authors across repositories.
+func MultiText(multi model.MultiReport, cfg TextConfig) string {
+	b := &strings.Builder{}
+	color := func(s, code string) string {
+		if cfg.NoColor {
+			return s
+		}
+		return code + s + "\x1b[0m"
+	}
+	palette := pickPalette()
+	label := func(s string) string { return color(s, palette.Label) }
+	muted := func(s string) string { return color(s, palette.Muted) }
+	accent := func(s string) string { return color(s, palette.Accent) }
+	body := func(s string) string { return color(s, palette.Body) }
+	bulletPrefix := color("- ", palette.Bullet)
+
+	fmt.Fprintf(b, "%s\n", color("Roastgit Org Report", palette.Header))
+	repos := fmt.Sprintf("%d", len(multi.Repos))
+	if len(multi.Failures) > 0 {
+		repos += fmt.Sprintf(" (%d failed)", len(multi.Failures))
+	}
+	fmt.Fprintf(b, "%s %s\n", label("Repos analyzed:"), body(repos))
+	fmt.Fprintf(b, "%s %s\n", label("Commits analyzed:"), body(fmt.Sprintf("%d", multi.CommitCount)))
+	fmt.Fprintf(b, "\n%s\n", color(fmt.Sprintf("Org Score: %d/100", multi.Score.Overall), scoreColor(multi.Score.Overall, palette)))
+	fmt.Fprintf(b, "%s %s\n", muted("Score breakdown:"), body(fmt.Sprintf("message %d/30, hygiene %d/30, cadence %d/20, size %d/20",
+		multi.Score.Breakdown.MessageQuality,
+		multi.Score.Breakdown.Hygiene,
+		multi.Score.Breakdown.Cadence,
+		multi.Score.Breakdown.SizeDiscipline,
+	)))
+	if cfg.Explain {
+		fmt.Fprintf(b, "%s %s\n", muted("Explain:"), body(multi.Score.Explain["overall"]))
+	}
+
+	width := 0
+	for _, r := range multi.Repos {
+		if n := len([]rune(r.Repo.Name)); n > width {
+			width = n
+		}
+	}
+	fmt.Fprintf(b, "\n%s\n", color("Repositories", palette.Header))
+	for _, r := range multi.Repos {
+		notes := []string{}
+		if r.Repo.Head == "" {
+			notes = append(notes, "no commits yet")
+		}
+		if r.Partial != nil {
+			notes = append(notes, "partial")
+		}
+		if r.Shallow != nil {
+			notes = append(notes, "shallow")
+		}
+		if r.Gate != nil && r.Gate.Failed {
+			notes = append(notes, "gate FAILED")
+		}
+		line := fmt.Sprintf("%s%s %s %s", bulletPrefix,
+			body(fmt.Sprintf("%-*s", width, r.Repo.Name)),
+			color(fmt.Sprintf("%3d/100", r.Score.Overall), scoreColor(r.Score.Overall, palette)),
+			muted(fmt.Sprintf("%6d commits", r.Repo.CommitCount)))
+		if len(notes) > 0 {
+			line += " " + accent("("+strings.Join(notes, ", ")+")")
+		}
+		if r.Roasts.Headline != "" {
+			line += " " + muted(truncate(r.Roasts.Headline, 60))
+		}
+		fmt.Fprintln(b, line)
+	}
+	if len(multi.Failures) > 0 {
+		fmt.Fprintf(b, "\n%s\n", color("Failed", palette.Header))
+		for _, f := range multi.Failures {
+			fmt.Fprintf(b, "%s%s %s\n", bulletPrefix, body(f.Path), color(f.Error, palette.ScoreBad))
+		}
+	}
+
+	if len(multi.Authors) > 0 {
+		fmt.Fprintf(b, "\n%s\n", color("Authors Across Repos", palette.Header))
+		authors := multi.Authors
+		if len(authors) > 10 {
+			authors = authors[:10]
+		}
+		for _, a := range authors {
+			in := fmt.Sprintf("%d repos", len(a.Repos))
+			if len(a.Repos) == 1 {
+				in = a.Repos[0]
+			}
+			fmt.Fprintf(b, "%s%s %s\n", bulletPrefix, body(a.Name),
+				muted(fmt.Sprintf("%d commits in %s, %d low-quality messages, average message score %.0f",
+					a.Commits, in, a.LowQuality, a.AverageQuality)))
+		}
+		if len(multi.Authors) > len(authors) {
+			fmt.Fprintf(b, "%s\n", muted(fmt.Sprintf("...and %d more (see --json)", len(multi.Authors)-len(authors))))
+		}
+	}
+	return b.String()
+}
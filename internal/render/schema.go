@@ -0,0 +1,11 @@
+package render
+
+import _ "embed"
+
+//go:embed schema/report.schema.json
+var reportSchema string
+
+// Schema returns the JSON Schema describing the current report shape.
+func Schema() string {
+	return reportSchema
+}
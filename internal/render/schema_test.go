@@ -0,0 +1,235 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"roastgit/internal/aggregate"
+	"roastgit/internal/analyze"
+	"roastgit/internal/model"
+	"roastgit/internal/roast"
+)
+
+func TestJSONMatchesSchema(t *testing.T) {
+	out, err := JSON(sampleReport())
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(Schema()), &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	var doc any
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if errs := validateSchema(schema, schema, doc, "$"); len(errs) > 0 {
+		t.Fatalf("report does not match schema:\n%s", strings.Join(errs, "\n"))
+	}
+}
+
+func TestMultiJSONMatchesSchema(t *testing.T) {
+	failed := model.RepoFailure{Path: "/tmp/broken", Error: "not a git repository"}
+	out, err := MultiJSON(aggregate.Reports([]model.Report{sampleReport()}, []model.RepoFailure{failed}))
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(Schema()), &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	multi, err := resolveRef(schema, "#/$defs/multiReport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc any
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if errs := validateSchema(schema, multi, doc, "$"); len(errs) > 0 {
+		t.Fatalf("multi-repo report does not match schema:\n%s", strings.Join(errs, "\n"))
+	}
+}
+
+func TestJSONCompatV1(t *testing.T) {
+	out, err := JSONCompat(sampleReport(), 1)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if _, ok := decoded["schema_version"]; ok {
+		t.Fatalf("expected no schema_version in v1 output")
+	}
+	if decoded["metrics"] == nil {
+		t.Fatalf("expected metrics in v1 output")
+	}
+	if _, err := JSONCompat(sampleReport(), model.SchemaVersion+1); err == nil {
+		t.Fatalf("expected error for unknown version")
+	}
+}
+
+func sampleReport() model.Report {
+	base := time.Date(2024, 3, 1, 1, 30, 0, 0, time.UTC)
+	commits := []model.Commit{
+		{SHA: "c3", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: base.Add(2 * time.Hour), Subject: "minor tweak", Body: "Roastgit-Ignore: binary", Parents: []string{"c2"}},
+		{SHA: "c2", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: base.Add(time.Hour), Subject: "fix", Parents: []string{"c1", "x"}},
+		{SHA: "c1", AuthorName: "John", AuthorEmail: "john@example.com", Date: base, Subject: "Add parser for numstat output"},
+		{SHA: "c0", AuthorName: "dependabot[bot]", AuthorEmail: "dependabot[bot]@users.noreply.github.com", Date: base.Add(-time.Hour), Subject: "Bump x from 1 to 2"},
+	}
+	bots := analyze.NewBotMatcher(nil, true)
+	sizes := map[string]model.CommitSize{
+		"c3": {Files: 12, Added: 700, Deleted: 200, BinaryFiles: 1},
+		"c1": {Files: 1, Added: 10},
+		"c0": {Submodules: 1},
+	}
+	analyzeCfg := analyze.AnalyzeConfig{TZ: "commit", Bots: bots}
+	metrics, offenders := analyze.Analyze(commits, sizes, []string{"main", "wip"}, analyzeCfg)
+	score := analyze.Score(metrics)
+	humans, _ := analyze.Authors(commits, analyzeCfg)
+	trend, _ := analyze.BuildTrend(commits, sizes, nil, analyze.AnalyzeConfig{TZ: "commit"}, analyze.PeriodWeek)
+	return model.Report{
+		Repo:       model.RepoInfo{Path: "/tmp/repo", Name: "repo", Head: "c3", CommitCount: len(commits)},
+		Filters:    model.Filters{Since: "2024-01-01", Range: "v1.0..HEAD", TZ: "commit", Deep: true},
+		Score:      score,
+		Metrics:    metrics,
+		Offenders:  offenders,
+		Roasts:     roast.GenerateRoasts(metrics, score, 3, false, false, "seed"),
+		Trend:      &trend,
+		Authors:    humans,
+		Automation: analyze.Automation(commits, analyzeCfg, false),
+		Baseline:   &model.BaselineInfo{Path: "/tmp/repo/.roastgit-baseline.json", Entries: 1, Suppressed: 1},
+		Gate:       &model.GateResult{FailOn: []string{"huge-commit"}, Violations: 1, Failed: true},
+		Partial:    &model.PartialInfo{Reason: "timeout", Stage: "numstat"},
+		Shallow:    &model.ShallowInfo{Boundary: []string{"0123456789abcdef0123456789abcdef01234567"}},
+		Submodules: []model.SubmoduleReport{
+			{Path: "libs/core", Report: &model.Report{
+				Repo:    model.RepoInfo{Path: "/tmp/repo/libs/core", Name: "core"},
+				Filters: model.Filters{TZ: "commit"},
+				Roasts:  model.RoastOutput{Headline: "No commits yet.", Sections: map[string]string{}, Tips: []string{}},
+			}},
+			{Path: "libs/ui", Error: "not checked out"},
+		},
+	}
+}
+
+// validateSchema checks doc against the subset of JSON Schema used by the
+// report schema: type, enum, minimum/maximum, properties, required,
+// additionalProperties, items and local $ref.
+func validateSchema(root, schema map[string]any, doc any, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		resolved, err := resolveRef(root, ref)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", path, err)}
+		}
+		return validateSchema(root, resolved, doc, path)
+	}
+	errs := []string{}
+	if typ, ok := schema["type"].(string); ok && !matchesType(typ, doc) {
+		return []string{fmt.Sprintf("%s: expected %s, got %T", path, typ, doc)}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, v := range enum {
+			if v == doc {
+				found = true
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("%s: %v not in enum %v", path, doc, enum))
+		}
+	}
+	if n, ok := doc.(float64); ok {
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			errs = append(errs, fmt.Sprintf("%s: %v below minimum %v", path, n, min))
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			errs = append(errs, fmt.Sprintf("%s: %v above maximum %v", path, n, max))
+		}
+	}
+	switch v := doc.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				if _, ok := v[r.(string)]; !ok {
+					errs = append(errs, fmt.Sprintf("%s: missing required %q", path, r))
+				}
+			}
+		}
+		for key, val := range v {
+			if sub, ok := props[key].(map[string]any); ok {
+				errs = append(errs, validateSchema(root, sub, val, path+"."+key)...)
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					errs = append(errs, fmt.Sprintf("%s: unexpected property %q", path, key))
+				}
+			case map[string]any:
+				errs = append(errs, validateSchema(root, extra, val, path+"."+key)...)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validateSchema(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func resolveRef(root map[string]any, ref string) (map[string]any, error) {
+	if ref == "#" {
+		return root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	var node any = root
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("bad $ref %q", ref)
+		}
+		node = m[part]
+	}
+	resolved, ok := node.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("bad $ref %q", ref)
+	}
+	return resolved, nil
+}
+
+func matchesType(typ string, doc any) bool {
+	switch typ {
+	case "object":
+		_, ok := doc.(map[string]any)
+		return ok
+	case "array":
+		_, ok := doc.([]any)
+		return ok
+	case "string":
+		_, ok := doc.(string)
+		return ok
+	case "boolean":
+		_, ok := doc.(bool)
+		return ok
+	case "number":
+		_, ok := doc.(float64)
+		return ok
+	case "integer":
+		n, ok := doc.(float64)
+		return ok && n == float64(int64(n))
+	case "null":
+		return doc == nil
+	}
+	return false
+}
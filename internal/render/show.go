@@ -0,0 +1,166 @@
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"roastgit/internal/model"
+	"roastgit/internal/util"
+)
+
+// maxShownFiles caps the per-file numstat lines in text output.
+const maxShownFiles = 10
+
+// CommitJSON renders a show verdict as pretty JSON.
+func CommitJSON(detail model.CommitDetail) (string, error) {
+	if detail.Size.PerFile == nil {
+		detail.Size.PerFile = []model.FileStat{}
+	}
+	b, err := json.MarshalIndent(detail, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// CommitText renders a show verdict for the terminal.
+func CommitText(detail model.CommitDetail, cfg TextConfig) string {
+	b := &strings.Builder{}
+	color := func(s, code string) string {
+		if cfg.NoColor {
+			return s
+		}
+		return code + s + "\x1b[0m"
+	}
+	palette := pickPalette()
+	label := func(s string) string { return color(s, palette.Label) }
+	muted := func(s string) string { return color(s, palette.Muted) }
+	accent := func(s string) string { return color(s, palette.Accent) }
+	body := func(s string) string { return color(s, palette.Body) }
+	bulletPrefix := color("- ", palette.Bullet)
+
+	fmt.Fprintf(b, "%s %s\n", color("Commit", palette.Header), accent(util.ShortSHA(detail.SHA)))
+	author := fmt.Sprintf("%s <%s>", detail.Author, detail.Email)
+	if detail.Bot {
+		author += " (bot)"
+	}
+	fmt.Fprintf(b, "%s %s\n", label("Author:"), body(author))
+	fmt.Fprintf(b, "%s %s\n", label("Date:"), body(detail.Time.Local))
+	fmt.Fprintf(b, "%s %s\n", label("Subject:"), body(detail.Subject))
+	if len(detail.Exempted) > 0 {
+		fmt.Fprintf(b, "%s %s\n", label("Exempted:"), muted(strings.Join(detail.Exempted, ", ")))
+	}
+
+	if !detail.Bot {
+		fmt.Fprintf(b, "\n%s %s\n", color(fmt.Sprintf("Message Score: %d/100", detail.Message.Score), scoreColor(detail.Message.Score, palette)), accent(detail.Roast))
+	} else {
+		fmt.Fprintf(b, "\n%s\n", accent(detail.Roast))
+	}
+	writeSection(b, color("Message", palette.Header), commitMessageBullets(detail), "", color, bulletPrefix, palette.Body, palette.Accent)
+	writeSection(b, color("Size", palette.Header), commitSizeBullets(detail.Size), "", color, bulletPrefix, palette.Body, palette.Accent)
+	writeSection(b, color("Time & Cadence", palette.Header), commitTimeBullets(detail), "", color, bulletPrefix, palette.Body, palette.Accent)
+
+	if len(detail.Reasons) > 0 {
+		fmt.Fprintf(b, "\n%s %s\n", label("Flagged:"), label(strings.Join(detail.Reasons, ", ")))
+	}
+	if len(detail.Tips) > 0 {
+		fmt.Fprintf(b, "\n%s\n", color("Tips", palette.Header))
+		for _, tip := range detail.Tips {
+			fmt.Fprintf(b, "%s%s\n", bulletPrefix, accent(tip))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func commitMessageBullets(detail model.CommitDetail) []string {
+	if detail.Bot {
+		return []string{"Automation commit; message checks skipped"}
+	}
+	m := detail.Message
+	checks := []struct {
+		hit  bool
+		text string
+	}{
+		{m.Generic, "Generic message"},
+		{m.EmojiOnly, "Emoji-only message"},
+		{m.TooLong, fmt.Sprintf("Subject is %d characters (max 72)", len([]rune(detail.Subject)))},
+		{m.TooShort, "Subject is too short to mean anything"},
+		{m.Fixup, "Fixup commit that was never autosquashed"},
+		{m.Lying, "Claims to be minor but is not"},
+	}
+	bullets := []string{}
+	for _, c := range checks {
+		if c.hit {
+			bullets = append(bullets, c.text)
+		}
+	}
+	if len(bullets) == 0 {
+		bullets = append(bullets, "No message problems found")
+	}
+	return bullets
+}
+
+func commitSizeBullets(size model.CommitSizeDetail) []string {
+	summary := fmt.Sprintf("%d files, +%d/-%d lines", size.Files, size.Added, size.Deleted)
+	if size.BinaryFiles > 0 {
+		summary += fmt.Sprintf(", %d binary", size.BinaryFiles)
+	}
+	if size.Submodules > 0 {
+		summary += fmt.Sprintf(", %d submodule pointer(s)", size.Submodules)
+		if size.Files == 0 {
+			summary += " (submodule bump)"
+		}
+	}
+	if size.Huge {
+		summary += " (huge)"
+	}
+	bullets := []string{summary}
+	files := append([]model.FileStat(nil), size.PerFile...)
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].Added+files[i].Deleted > files[j].Added+files[j].Deleted
+	})
+	for i, f := range files {
+		if i == maxShownFiles {
+			bullets = append(bullets, fmt.Sprintf("... and %d more files", len(files)-maxShownFiles))
+			break
+		}
+		if f.Submodule {
+			bullets = append(bullets, fmt.Sprintf("  %s (submodule)", f.Path))
+			continue
+		}
+		if f.Binary {
+			bullets = append(bullets, fmt.Sprintf("  %s (binary)", f.Path))
+			continue
+		}
+		bullets = append(bullets, fmt.Sprintf("  %s +%d/-%d", f.Path, f.Added, f.Deleted))
+	}
+	return bullets
+}
+
+func commitTimeBullets(detail model.CommitDetail) []string {
+	t := detail.Time
+	bullets := []string{fmt.Sprintf("%s at %02d:00", t.Weekday, t.Hour)}
+	if t.Midnight {
+		bullets = append(bullets, "Committed between midnight and 5am")
+	}
+	if t.Deadline {
+		bullets = append(bullets, "Committed in a deadline window")
+	}
+	if w := detail.PanicWindow; w != nil {
+		bullets = append(bullets, fmt.Sprintf("Part of a panic window: %d commits (%d low quality) from %s to %s", w.Commits, w.LowQuality, w.Start, w.End))
+	}
+	if len(detail.FixChain) > 0 {
+		links := make([]string, 0, len(detail.FixChain))
+		for _, link := range detail.FixChain {
+			text := fmt.Sprintf("%s %q", util.ShortSHA(link.SHA), truncate(link.Subject, 30))
+			if link.SHA == detail.SHA {
+				text = "[" + text + "]"
+			}
+			links = append(links, text)
+		}
+		bullets = append(bullets, "Fix chain: "+strings.Join(links, " -> "))
+	}
+	return bullets
+}
@@ -41,6 +41,14 @@ func Text(report model.Report, cfg TextConfig) string {
 
 	fmt.Fprintf(b, "%s\n", color("Roastgit Report", headerColor))
 	fmt.Fprintf(b, "%s %s (%s)\n", label("Repo:"), report.Repo.Name, muted(report.Repo.Path))
+	if report.Repo.Head == "" {
+		fmt.Fprintf(b, "%s %s\n", label("HEAD:"), muted("no commits yet"))
+		fmt.Fprintf(b, "\n%s\n", headline)
+		for _, tip := range report.Roasts.Tips {
+			fmt.Fprintf(b, "%s%s\n", bulletPrefix, body(tip))
+		}
+		return b.String()
+	}
 	fmt.Fprintf(b, "%s %s\n", label("HEAD:"), accent(util.ShortSHA(report.Repo.Head)))
 	fmt.Fprintf(b, "%s %s\n", label("Commits analyzed:"), body(fmt.Sprintf("%d", report.Repo.CommitCount)))
 	if report.Filters.Since != "" || report.Filters.Until != "" {
@@ -49,6 +57,17 @@ func Text(report model.Report, cfg TextConfig) string {
 	if report.Filters.Author != "" {
 		fmt.Fprintf(b, "%s %s\n", label("Author filter:"), body(report.Filters.Author))
 	}
+	if ex := report.Metrics.Exemptions; ex.Checks > 0 {
+		fmt.Fprintf(b, "%s %s\n", label("Exemptions:"), body(fmt.Sprintf("%d checks skipped on %d commits", ex.Checks, ex.Commits)))
+	}
+	if p := report.Partial; p != nil {
+		fmt.Fprintf(b, "%s %s\n", label("Partial:"), accent(partialNote(*p)))
+	}
+	if sh := report.Shallow; sh != nil {
+		fmt.Fprintf(b, "%s %s\n", label("Shallow history:"), accent(fmt.Sprintf(
+			"cut off at %d boundary commit(s); streaks, averages and totals only cover fetched commits. Run git fetch --unshallow for the full roast.",
+			len(sh.Boundary))))
+	}
 	fmt.Fprintf(b, "\n%s %s\n", color(fmt.Sprintf("Overall Score: %d/100", report.Score.Overall), scoreColor), headline)
 	if cfg.Explain && len(report.Score.Explain) > 0 {
 		fmt.Fprintf(b, "%s %s\n", muted("Score breakdown:"), body(fmt.Sprintf("message %d/30, hygiene %d/30, cadence %d/20, size %d/20",
@@ -60,13 +79,30 @@ func Text(report model.Report, cfg TextConfig) string {
 		fmt.Fprintf(b, "%s %s\n", muted("Explain:"), body(report.Score.Explain["overall"]))
 	}
 
-	writeSection(b, color("Commit Message Crimes", headerColor), messageBullets(report.Metrics), report.Roasts.Sections["commit_messages"], color, bulletPrefix, palette.Body, palette.Accent)
-	writeSection(b, color("Time & Cadence", headerColor), timeBullets(report.Metrics), report.Roasts.Sections["time_cadence"], color, bulletPrefix, palette.Body, palette.Accent)
-	writeSection(b, color("Repo Hygiene", headerColor), hygieneBullets(report.Metrics), report.Roasts.Sections["repo_hygiene"], color, bulletPrefix, palette.Body, palette.Accent)
-	writeSection(b, color("Chunkiness", headerColor), sizeBullets(report.Metrics), report.Roasts.Sections["chunkiness"], color, bulletPrefix, palette.Body, palette.Accent)
+	for _, sec := range Sections(report, 3) {
+		writeSection(b, color(sec.Title, headerColor), trimBullets(sec.Bullets, 6), sec.Roast, color, bulletPrefix, palette.Body, palette.Accent)
+	}
 
-	if len(report.Offenders) > 0 {
+	if report.Automation.Commits > 0 {
+		writeSection(b, color("Automation", headerColor), trimBullets(automationBullets(report.Automation, 3), 6), "", color, bulletPrefix, palette.Body, palette.Accent)
+	}
+	if report.Metrics.Submodules.Commits > 0 || len(report.Submodules) > 0 {
+		writeSection(b, color("Submodules", headerColor), submoduleBullets(report), "", color, bulletPrefix, palette.Body, palette.Accent)
+	}
+	if report.Trend != nil && len(report.Trend.Points) > 0 {
+		title := color(fmt.Sprintf("Trend (per %s)", report.Trend.Period), headerColor)
+		writeSection(b, title, trendBullets(*report.Trend), trendSummary(*report.Trend), color, bulletPrefix, palette.Body, palette.Muted)
+	}
+
+	hidden := 0
+	if report.Baseline != nil {
+		hidden = report.Baseline.Suppressed
+	}
+	if len(report.Offenders) > 0 || hidden > 0 {
 		fmt.Fprintf(b, "\n%s\n", color("Top Offenders", headerColor))
+		if hidden > 0 {
+			fmt.Fprintf(b, "%s\n", muted(fmt.Sprintf("%d known offenders hidden by baseline", hidden)))
+		}
 		for _, off := range report.Offenders {
 			subject := truncate(off.Subject, 60)
 			date := off.Date[:10]
@@ -88,6 +124,15 @@ func Text(report model.Report, cfg TextConfig) string {
 		}
 	}
 
+	if report.Gate != nil {
+		status := color("passed", palette.ScoreGood)
+		if report.Gate.Failed {
+			status = color("FAILED", palette.ScoreBad)
+		}
+		fmt.Fprintf(b, "\n%s %s %s\n", label("Gate:"), status,
+			muted(fmt.Sprintf("(%d new offenders match %s)", report.Gate.Violations, strings.Join(report.Gate.FailOn, ", "))))
+	}
+
 	hint := fmt.Sprintf("try %s for full size analysis, %s for kinder output, or %s for machine use.",
 		accent("--deep"),
 		accent("--wholesome"),
@@ -107,6 +152,44 @@ func writeSection(b *strings.Builder, title string, bullets []string, summary st
 	}
 }
 
+// Section is one scored category of the report: its metrics as bullets
+// and the roast of it.
+type Section struct {
+	// Key names the category in roasts.sections.
+	Key     string
+	Title   string
+	Bullets []string
+	Roast   string
+}
+
+// Sections returns the four scored categories in report order, with every
+// bullet. Lists of names within a bullet, such as bad branches, are cut to
+// maxNames; 0 keeps them all.
+func Sections(report model.Report, maxNames int) []Section {
+	m := report.Metrics
+	sections := []Section{
+		{Key: "commit_messages", Title: "Commit Message Crimes", Bullets: messageBullets(m)},
+		{Key: "time_cadence", Title: "Time & Cadence", Bullets: timeBullets(m)},
+		{Key: "repo_hygiene", Title: "Repo Hygiene", Bullets: hygieneBullets(m, maxNames)},
+		{Key: "chunkiness", Title: "Chunkiness", Bullets: sizeBullets(m)},
+	}
+	for i := range sections {
+		sections[i].Roast = report.Roasts.Sections[sections[i].Key]
+	}
+	return sections
+}
+
+// AutomationBullets describes bot commits, listing at most maxBots bots;
+// 0 lists them all.
+func AutomationBullets(automation model.AutomationSummary, maxBots int) []string {
+	return automationBullets(automation, maxBots)
+}
+
+// SubmoduleBullets summarizes submodule bumps and nested submodule reports.
+func SubmoduleBullets(report model.Report) []string {
+	return submoduleBullets(report)
+}
+
 func messageBullets(metrics model.Metrics) []string {
 	bullets := []string{}
 	if metrics.Message.Total == 0 {
@@ -114,6 +197,9 @@ func messageBullets(metrics model.Metrics) []string {
 	}
 	bullets = append(bullets, fmt.Sprintf("Generic messages: %d (%.0f%%)", metrics.Message.Generic, percent(metrics.Message.Generic, metrics.Message.Total)))
 	bullets = append(bullets, fmt.Sprintf("Emoji-only: %d, too short: %d, too long: %d", metrics.Message.EmojiOnly, metrics.Message.TooShort, metrics.Message.TooLong))
+	if metrics.Message.Fixup > 0 {
+		bullets = append(bullets, fmt.Sprintf("Fixup commits: %d", metrics.Message.Fixup))
+	}
 	if metrics.Message.Lying > 0 {
 		bullets = append(bullets, fmt.Sprintf("Lying messages: %d", metrics.Message.Lying))
 	}
@@ -123,7 +209,7 @@ func messageBullets(metrics model.Metrics) []string {
 	if len(metrics.Message.TopGenericWords) > 0 {
 		bullets = append(bullets, fmt.Sprintf("Top generic words: %s", strings.Join(metrics.Message.TopGenericWords, ", ")))
 	}
-	return trimBullets(bullets, 6)
+	return bullets
 }
 
 func timeBullets(metrics model.Metrics) []string {
@@ -133,18 +219,18 @@ func timeBullets(metrics model.Metrics) []string {
 	bullets = append(bullets, fmt.Sprintf("Midnight commits: %.0f%%", metrics.Time.MidnightRatio*100))
 	bullets = append(bullets, fmt.Sprintf("Deadline window commits: %.0f%%", metrics.Time.DeadlineRatio*100))
 	bullets = append(bullets, fmt.Sprintf("Longest streak: %d days", metrics.Time.LongestStreakDays))
-	return trimBullets(bullets, 6)
+	return bullets
 }
 
-func hygieneBullets(metrics model.Metrics) []string {
+func hygieneBullets(metrics model.Metrics, maxBranches int) []string {
 	bullets := []string{}
 	bullets = append(bullets, fmt.Sprintf("Merge ratio: %.0f%%", metrics.Hygiene.MergeRatio*100))
 	bullets = append(bullets, fmt.Sprintf("Linear ratio: %.0f%%", metrics.Hygiene.LinearRatio*100))
 	bullets = append(bullets, fmt.Sprintf("Branches: %d (bad: %d)", metrics.Hygiene.BranchCount, metrics.Hygiene.BadBranchCount))
 	if len(metrics.Hygiene.BadBranches) > 0 {
-		bullets = append(bullets, fmt.Sprintf("Bad branches: %s", strings.Join(trimStrings(metrics.Hygiene.BadBranches, 3), ", ")))
+		bullets = append(bullets, fmt.Sprintf("Bad branches: %s", strings.Join(trimStrings(metrics.Hygiene.BadBranches, maxBranches), ", ")))
 	}
-	return trimBullets(bullets, 6)
+	return bullets
 }
 
 func sizeBullets(metrics model.Metrics) []string {
@@ -160,7 +246,91 @@ func sizeBullets(metrics model.Metrics) []string {
 	bullets = append(bullets, fmt.Sprintf("Binary commits: %d/%d%s", metrics.Size.BinaryCommitCount, metrics.Size.SampleSize, sampleNote))
 	bullets = append(bullets, fmt.Sprintf("Average lines changed: %.0f", metrics.Size.AverageLines))
 	bullets = append(bullets, fmt.Sprintf("Max lines changed: %d", metrics.Size.MaxLines))
-	return trimBullets(bullets, 6)
+	return bullets
+}
+
+func automationBullets(automation model.AutomationSummary, maxBots int) []string {
+	scope := "kept out of message metrics"
+	if automation.Excluded {
+		scope = "excluded from all metrics"
+	}
+	bullets := []string{fmt.Sprintf("Bot commits: %d (%.0f%%), %s", automation.Commits, automation.Ratio*100, scope)}
+	for _, bot := range trimAuthors(automation.Bots, maxBots) {
+		bullets = append(bullets, fmt.Sprintf("%s: %d commits", bot.Name, bot.Commits))
+	}
+	return bullets
+}
+
+// submoduleBullets summarizes submodule bumps and lists the nested
+// submodule reports, one line each.
+func submoduleBullets(report model.Report) []string {
+	bullets := []string{}
+	if sm := report.Metrics.Submodules; sm.Commits > 0 {
+		sampleNote := ""
+		if report.Metrics.Size.Sampled {
+			sampleNote = " (sampled)"
+		}
+		bullets = append(bullets, fmt.Sprintf("Submodule bumps: %d%s, left out of size metrics", sm.Bumps, sampleNote))
+		if mixed := sm.Commits - sm.Bumps; mixed > 0 {
+			bullets = append(bullets, fmt.Sprintf("Commits moving a submodule along with other changes: %d", mixed))
+		}
+	}
+	for _, sub := range report.Submodules {
+		if sub.Report == nil {
+			bullets = append(bullets, fmt.Sprintf("%s: %s", sub.Path, sub.Error))
+			continue
+		}
+		r := sub.Report
+		if r.Repo.Head == "" {
+			bullets = append(bullets, fmt.Sprintf("%s: no commits yet", sub.Path))
+			continue
+		}
+		line := fmt.Sprintf("%s: %d/100 over %d commits", sub.Path, r.Score.Overall, r.Repo.CommitCount)
+		if r.Partial != nil {
+			line += " (partial)"
+		}
+		bullets = append(bullets, line+" -- "+r.Roasts.Headline)
+	}
+	return bullets
+}
+
+func trimAuthors(in []model.AuthorStats, max int) []model.AuthorStats {
+	if max <= 0 || len(in) <= max {
+		return in
+	}
+	return in[:max]
+}
+
+func trendBullets(trend model.Trend) []string {
+	type series struct {
+		name   string
+		max    float64
+		values func(model.TrendPoint) int
+	}
+	rows := []series{
+		{"Overall", 100, func(p model.TrendPoint) int { return p.Overall }},
+		{"Messages", 30, func(p model.TrendPoint) int { return p.Breakdown.MessageQuality }},
+		{"Hygiene", 30, func(p model.TrendPoint) int { return p.Breakdown.Hygiene }},
+		{"Cadence", 20, func(p model.TrendPoint) int { return p.Breakdown.Cadence }},
+		{"Size", 20, func(p model.TrendPoint) int { return p.Breakdown.SizeDiscipline }},
+	}
+	bullets := []string{}
+	for _, row := range rows {
+		values := make([]float64, 0, len(trend.Points))
+		for _, p := range trend.Points {
+			values = append(values, float64(row.values(p)))
+		}
+		first := row.values(trend.Points[0])
+		last := row.values(trend.Points[len(trend.Points)-1])
+		bullets = append(bullets, fmt.Sprintf("%-9s %s %d -> %d", row.name, util.Sparkline(values, 0, row.max), first, last))
+	}
+	return bullets
+}
+
+func trendSummary(trend model.Trend) string {
+	first := trend.Points[0]
+	last := trend.Points[len(trend.Points)-1]
+	return fmt.Sprintf("%s .. %s, %d periods with commits", first.Label, last.Label, len(trend.Points))
 }
 
 func trimBullets(bullets []string, max int) []string {
@@ -314,8 +484,21 @@ func emptyAsAll(s string) string {
 	return s
 }
 
+// partialNote explains which step a partial report was cut short in.
+func partialNote(p model.PartialInfo) string {
+	what := "the commit log"
+	if p.Stage == "numstat" {
+		what = "commit sizes"
+	}
+	cause := "interrupted"
+	if p.Reason == "timeout" {
+		cause = "timed out"
+	}
+	return fmt.Sprintf("%s while reading %s; metrics cover only what was read", cause, what)
+}
+
 func trimStrings(in []string, max int) []string {
-	if len(in) <= max {
+	if max <= 0 || len(in) <= max {
 		return in
 	}
 	return in[:max]
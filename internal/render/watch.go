@@ -0,0 +1,52 @@
+package render
+
+import (
+	"fmt"
+	"strings"
+
+	"roastgit/internal/model"
+	"roastgit/internal/util"
+)
+
+// WatchCommitText renders the short verdict watch mode prints for a new
+// commit: who made it, what it was flagged for, and its roast.
+func WatchCommitText(detail model.CommitDetail, cfg TextConfig) string {
+	b := &strings.Builder{}
+	color := func(s, code string) string {
+		if cfg.NoColor {
+			return s
+		}
+		return code + s + "\x1b[0m"
+	}
+	palette := pickPalette()
+
+	fmt.Fprintf(b, "%s %s %s\n", color(util.ShortSHA(detail.SHA), palette.Accent),
+		color(detail.Author+":", palette.Label), color(truncate(detail.Subject, 72), palette.Body))
+	if len(detail.Reasons) > 0 {
+		fmt.Fprintf(b, "  %s %s\n", color("Flagged:", palette.Label), color(strings.Join(detail.Reasons, ", "), palette.ScoreBad))
+	}
+	fmt.Fprintf(b, "  %s", color(detail.Roast, palette.Accent))
+	return b.String()
+}
+
+// ScoreDeltaText renders the change of the overall score after new commits,
+// colored by direction like a comparison.
+func ScoreDeltaText(before, after model.Score, cfg TextConfig) string {
+	color := func(s, code string) string {
+		if cfg.NoColor {
+			return s
+		}
+		return code + s + "\x1b[0m"
+	}
+	palette := pickPalette()
+	change := after.Overall - before.Overall
+	arrowColor := palette.Muted
+	if change > 0 {
+		arrowColor = palette.ScoreGood
+	} else if change < 0 {
+		arrowColor = palette.ScoreBad
+	}
+	return fmt.Sprintf("%s %s %s", color("Score:", palette.Label),
+		color(fmt.Sprintf("%d -> %d/100", before.Overall, after.Overall), scoreColor(after.Overall, palette)),
+		color(arrowWithChange(float64(change)), arrowColor))
+}
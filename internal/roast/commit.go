@@ -0,0 +1,88 @@
+package roast
+
+import (
+	"strings"
+
+	"roastgit/internal/util"
+)
+
+// reasonLines holds a light and a savage roast per offender reason.
+var reasonLines = map[string][2]string{
+	"generic message":    {"This message could describe any commit ever written.", "A message so generic it could be a fortune cookie."},
+	"emoji-only message": {"Emoji are not a changelog.", "Hieroglyphics were retired for a reason."},
+	"too long":           {"The subject line needs a diet.", "This subject line has chapters."},
+	"too short":          {"A few more words would help.", "Brevity is the soul of wit, but this is just vague."},
+	"fixup commit":       {"A fixup commit escaped autosquash.", "fixup! fixup! fixup! Squash before you push."},
+	"lying message":      {"Calling this small is generous.", "\"Minor\" is doing a lot of heavy lifting here."},
+	"panic streak":       {"Part of a rapid-fire burst of shaky commits.", "Committed mid-panic, and it shows."},
+	"huge commit":        {"This commit is a lot to review.", "Reviewers will need a snack and a sabbatical."},
+	"binary blobs":       {"Binary files snuck into history.", "Git is not a file share, no matter how hard you try."},
+	"midnight gremlin":   {"Committed in the small hours.", "Nothing good is committed after midnight."},
+	"deadline scramble":  {"Committed in a deadline window.", "Smells like a Friday afternoon ship."},
+	"fix chain":          {"It took a few follow-up fixes to land this.", "Commit, fix, fix again: a trilogy nobody asked for."},
+}
+
+var reasonTips = map[string]string{
+	"generic message":    "Say what changed and why, not just that something changed.",
+	"emoji-only message": "Pair emoji with words so the log stays searchable.",
+	"too long":           "Keep the subject under 72 characters; put details in the body.",
+	"too short":          "Add a few words of context to the subject.",
+	"fixup commit":       "Run git rebase -i --autosquash before pushing.",
+	"lying message":      "Describe big changes as big, or split them up.",
+	"panic streak":       "Slow down and squash the flurry before pushing.",
+	"huge commit":        "Split large commits into focused chunks for reviewability.",
+	"binary blobs":       "Avoid committing large binaries; use git-lfs or artifacts.",
+	"midnight gremlin":   "Sleep on late-night changes before committing them.",
+	"deadline scramble":  "Land risky changes earlier than the deadline window.",
+	"fix chain":          "Run the tests before committing, and fold follow-up fixes in with git commit --fixup.",
+}
+
+// CommitRoast produces a roast line and tips for a single commit flagged with
+// the given offender reasons.
+func CommitRoast(reasons []string, intensity int, wholesome bool, censor bool, seed string) (string, []string) {
+	level := util.ClampInt(intensity, 0, 5)
+	line := commitLine(reasons, level, wholesome, seed)
+	tips := []string{}
+	for _, r := range reasons {
+		if tip, ok := reasonTips[r]; ok {
+			tips = append(tips, tip)
+		}
+	}
+	if len(tips) > 3 {
+		tips = tips[:3]
+	}
+	if censor {
+		line = Censor(line)
+		for i := range tips {
+			tips[i] = Censor(tips[i])
+		}
+	}
+	return line, tips
+}
+
+func commitLine(reasons []string, level int, wholesome bool, seed string) string {
+	if len(reasons) == 0 {
+		if wholesome || level <= 2 {
+			return "Clean commit. Nice work."
+		}
+		return util.DeterministicChoice(seed+"clean", []string{
+			"Annoyingly, there is nothing to roast here.",
+			"A respectable commit. Suspicious.",
+		})
+	}
+	if wholesome {
+		return "A couple of small tweaks would make this commit shine: " + strings.Join(reasons, ", ") + "."
+	}
+	if level == 0 {
+		return "Flagged: " + strings.Join(reasons, ", ") + "."
+	}
+	lines, ok := reasonLines[reasons[0]]
+	if !ok {
+		return pickByIntensity(level, "Flagged: ", "Busted: ") + strings.Join(reasons, ", ") + "."
+	}
+	line := pickByIntensity(level, lines[0], lines[1])
+	if level >= 5 && len(reasons) > 1 {
+		line += " Also: " + strings.Join(reasons[1:], ", ") + "."
+	}
+	return line
+}
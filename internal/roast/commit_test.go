@@ -0,0 +1,17 @@
+package roast
+
+import "testing"
+
+func TestCommitRoast(t *testing.T) {
+	line, tips := CommitRoast([]string{"generic message", "too short"}, 1, false, false, "seed")
+	if line != reasonLines["generic message"][0] {
+		t.Fatalf("unexpected light roast %q", line)
+	}
+	if len(tips) != 2 {
+		t.Fatalf("expected a tip per reason, got %v", tips)
+	}
+	line, _ = CommitRoast(nil, 0, false, false, "seed")
+	if line != "Clean commit. Nice work." {
+		t.Fatalf("unexpected clean roast %q", line)
+	}
+}
@@ -0,0 +1,866 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxRange bounds the lists range() builds.
+const maxRange = 100_000
+
+// universe holds the predeclared names every script can use.
+var universe map[string]Value
+
+func init() {
+	universe = map[string]Value{
+		"None":      None,
+		"True":      true,
+		"False":     false,
+		"abs":       &builtin{name: "abs", fn: builtinAbs},
+		"all":       &builtin{name: "all", fn: builtinAll},
+		"any":       &builtin{name: "any", fn: builtinAny},
+		"bool":      &builtin{name: "bool", fn: builtinBool},
+		"dict":      &builtin{name: "dict", fn: builtinDict},
+		"enumerate": &builtin{name: "enumerate", fn: builtinEnumerate},
+		"fail":      &builtin{name: "fail", fn: builtinFail},
+		"float":     &builtin{name: "float", fn: builtinFloat},
+		"getattr":   &builtin{name: "getattr", fn: builtinGetattr},
+		"hasattr":   &builtin{name: "hasattr", fn: builtinHasattr},
+		"int":       &builtin{name: "int", fn: builtinInt},
+		"len":       &builtin{name: "len", fn: builtinLen},
+		"list":      &builtin{name: "list", fn: builtinList},
+		"matches":   &builtin{name: "matches", fn: builtinMatches},
+		"max":       &builtin{name: "max", fn: builtinMinMax},
+		"min":       &builtin{name: "min", fn: builtinMinMax},
+		"range":     &builtin{name: "range", fn: builtinRange},
+		"repr":      &builtin{name: "repr", fn: builtinRepr},
+		"reversed":  &builtin{name: "reversed", fn: builtinReversed},
+		"sorted":    &builtin{name: "sorted", fn: builtinSorted},
+		"str":       &builtin{name: "str", fn: builtinStr},
+		"tuple":     &builtin{name: "tuple", fn: builtinTuple},
+		"type":      &builtin{name: "type", fn: builtinType},
+		"zip":       &builtin{name: "zip", fn: builtinZip},
+	}
+}
+
+// unpackArgs checks that a builtin got between required and max positional
+// arguments and no keyword ones.
+func unpackArgs(b *builtin, args []Value, kwargs []kwarg, required, max int) error {
+	if len(kwargs) > 0 {
+		return fmt.Errorf("%s() got an unexpected keyword argument %s", b.name, kwargs[0].name)
+	}
+	if len(args) < required || len(args) > max {
+		if required == max {
+			return fmt.Errorf("%s() takes %d arguments, got %d", b.name, max, len(args))
+		}
+		return fmt.Errorf("%s() takes %d to %d arguments, got %d", b.name, required, max, len(args))
+	}
+	return nil
+}
+
+// elements returns the elements of an iterable value.
+func elements(name string, v Value) ([]Value, error) {
+	switch v := v.(type) {
+	case Tuple:
+		return v, nil
+	case *List:
+		return v.elems, nil
+	case *Dict:
+		return v.keys, nil
+	}
+	return nil, fmt.Errorf("%s: %s is not iterable", name, typeName(v))
+}
+
+func builtinAbs(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case int64:
+		if v < 0 {
+			return -v, nil
+		}
+		return v, nil
+	case float64:
+		return math.Abs(v), nil
+	}
+	return nil, fmt.Errorf("abs: got %s, want a number", typeName(args[0]))
+}
+
+func builtinAll(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	elems, err := elements(b.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range elems {
+		if !truth(e) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func builtinAny(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	elems, err := elements(b.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range elems {
+		if truth(e) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func builtinBool(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 0, 1); err != nil {
+		return nil, err
+	}
+	return len(args) == 1 && truth(args[0]), nil
+}
+
+func builtinDict(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("dict() takes at most 1 positional argument, got %d", len(args))
+	}
+	d := NewDict()
+	if len(args) == 1 {
+		if src, ok := args[0].(*Dict); ok {
+			for i, k := range src.keys {
+				d.Set(k, src.vals[i])
+			}
+		} else {
+			elems, err := elements(b.name, args[0])
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range elems {
+				pair, err := elements(b.name, e)
+				if err != nil || len(pair) != 2 {
+					return nil, fmt.Errorf("dict: want a sequence of (key, value) pairs")
+				}
+				if err := d.Set(pair[0], pair[1]); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, kw := range kwargs {
+		d.Set(kw.name, kw.value)
+	}
+	return th.charged(d)
+}
+
+func builtinEnumerate(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 2); err != nil {
+		return nil, err
+	}
+	elems, err := elements(b.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	start := int64(0)
+	if len(args) == 2 {
+		s, ok := args[1].(int64)
+		if !ok {
+			return nil, fmt.Errorf("enumerate: start must be an int")
+		}
+		start = s
+	}
+	out := make([]Value, len(elems))
+	for i, e := range elems {
+		out[i] = Tuple{start + int64(i), e}
+	}
+	if err := th.alloc(len(out) * 2 * valueSize); err != nil {
+		return nil, err
+	}
+	return th.charged(NewList(out))
+}
+
+func builtinFail(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = str(a)
+	}
+	return nil, fmt.Errorf("fail: %s", strings.Join(parts, " "))
+}
+
+func builtinFloat(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		return float64(b2i(v)), nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("float: invalid literal %s", repr(v))
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("float: cannot convert %s", typeName(args[0]))
+}
+
+func builtinGetattr(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 2, 3); err != nil {
+		return nil, err
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("getattr: attribute name must be a string")
+	}
+	v, err := th.attr(args[0], name)
+	if err != nil && len(args) == 3 {
+		return args[2], nil
+	}
+	return v, err
+}
+
+func builtinHasattr(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 2, 2); err != nil {
+		return nil, err
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("hasattr: attribute name must be a string")
+	}
+	_, err := th.attr(args[0], name)
+	return err == nil, nil
+}
+
+func builtinInt(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) > math.MaxInt64 {
+			return nil, fmt.Errorf("int: cannot convert %s", formatFloat(v))
+		}
+		return int64(v), nil
+	case bool:
+		return b2i(v), nil
+	case string:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("int: invalid literal %s", repr(v))
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("int: cannot convert %s", typeName(args[0]))
+}
+
+func builtinLen(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case string:
+		return int64(len(v)), nil
+	case Tuple:
+		return int64(len(v)), nil
+	case *List:
+		return int64(len(v.elems)), nil
+	case *Dict:
+		return int64(len(v.keys)), nil
+	}
+	return nil, fmt.Errorf("len: %s has no length", typeName(args[0]))
+}
+
+func builtinList(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 0, 1); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return NewList(nil), nil
+	}
+	elems, err := elements(b.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return th.charged(NewList(append([]Value(nil), elems...)))
+}
+
+// patterns caches the regular expressions matches() compiles; the same few
+// patterns run against every commit.
+var patterns sync.Map
+
+func builtinMatches(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 2, 2); err != nil {
+		return nil, err
+	}
+	pattern, ok := args[0].(string)
+	s, ok2 := args[1].(string)
+	if !ok || !ok2 {
+		return nil, fmt.Errorf("matches: want (pattern, string), got (%s, %s)", typeName(args[0]), typeName(args[1]))
+	}
+	re, ok := patterns.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("matches: %v", err)
+		}
+		re, _ = patterns.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(s), nil
+}
+
+func builtinMinMax(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, math.MaxInt); err != nil {
+		return nil, err
+	}
+	elems := args
+	if len(args) == 1 {
+		var err error
+		if elems, err = elements(b.name, args[0]); err != nil {
+			return nil, err
+		}
+	}
+	if len(elems) == 0 {
+		return nil, fmt.Errorf("%s: empty sequence", b.name)
+	}
+	best := elems[0]
+	for _, e := range elems[1:] {
+		c, err := compare(e, best)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", b.name, err)
+		}
+		if (b.name == "min" && c < 0) || (b.name == "max" && c > 0) {
+			best = e
+		}
+	}
+	return best, nil
+}
+
+func builtinRange(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 3); err != nil {
+		return nil, err
+	}
+	var n [3]int64
+	for i, a := range args {
+		v, ok := a.(int64)
+		if !ok {
+			return nil, fmt.Errorf("range: got %s, want int", typeName(a))
+		}
+		n[i] = v
+	}
+	start, stop, step := int64(0), n[0], int64(1)
+	if len(args) > 1 {
+		start, stop = n[0], n[1]
+	}
+	if len(args) == 3 {
+		step = n[2]
+	}
+	if step == 0 {
+		return nil, fmt.Errorf("range: step must not be zero")
+	}
+	var out []Value
+	for i := start; (step > 0 && i < stop) || (step < 0 && i > stop); i += step {
+		if len(out) == maxRange {
+			return nil, fmt.Errorf("range: more than %d elements", maxRange)
+		}
+		out = append(out, i)
+	}
+	return th.charged(NewList(out))
+}
+
+func builtinRepr(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	return th.charged(repr(args[0]))
+}
+
+func builtinReversed(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	elems, err := elements(b.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Value, len(elems))
+	for i, e := range elems {
+		out[len(elems)-1-i] = e
+	}
+	return th.charged(NewList(out))
+}
+
+func builtinSorted(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("sorted() takes 1 positional argument, got %d", len(args))
+	}
+	var key Value
+	reverse := false
+	for _, kw := range kwargs {
+		switch kw.name {
+		case "key":
+			key = kw.value
+		case "reverse":
+			reverse = truth(kw.value)
+		default:
+			return nil, fmt.Errorf("sorted() got an unexpected keyword argument %s", kw.name)
+		}
+	}
+	elems, err := elements(b.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	out := append([]Value(nil), elems...)
+	keys := out
+	if key != nil && key != None {
+		keys = make([]Value, len(out))
+		for i, e := range out {
+			if keys[i], err = th.call(key, []Value{e}, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := sortValues(out, keys, reverse); err != nil {
+		return nil, fmt.Errorf("sorted: %v", err)
+	}
+	return th.charged(NewList(out))
+}
+
+func builtinStr(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	return th.charged(str(args[0]))
+}
+
+func builtinTuple(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 0, 1); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return Tuple{}, nil
+	}
+	elems, err := elements(b.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	return th.charged(append(Tuple{}, elems...))
+}
+
+func builtinType(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	return typeName(args[0]), nil
+}
+
+func builtinZip(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 0, math.MaxInt); err != nil {
+		return nil, err
+	}
+	seqs := make([][]Value, len(args))
+	n := -1
+	for i, a := range args {
+		elems, err := elements(b.name, a)
+		if err != nil {
+			return nil, err
+		}
+		seqs[i] = elems
+		if n < 0 || len(elems) < n {
+			n = len(elems)
+		}
+	}
+	out := make([]Value, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		t := make(Tuple, len(seqs))
+		for j := range seqs {
+			t[j] = seqs[j][i]
+		}
+		out = append(out, t)
+	}
+	if err := th.alloc(len(out) * len(seqs) * valueSize); err != nil {
+		return nil, err
+	}
+	return th.charged(NewList(out))
+}
+
+// attr returns a struct field or a method bound to v.
+func (th *thread) attr(v Value, name string) (Value, error) {
+	var methods map[string]func(*thread, *builtin, []Value, []kwarg) (Value, error)
+	switch v := v.(type) {
+	case *Struct:
+		if f, ok := v.fields[name]; ok {
+			return f, nil
+		}
+		return nil, th.errorf("%s has no field %s", v.name, name)
+	case string:
+		methods = stringMethods
+	case *List:
+		methods = listMethods
+	case *Dict:
+		methods = dictMethods
+	}
+	if fn, ok := methods[name]; ok {
+		return &builtin{name: name, recv: v, fn: fn}, nil
+	}
+	return nil, th.errorf("%s has no attribute %s", typeName(v), name)
+}
+
+var stringMethods = map[string]func(*thread, *builtin, []Value, []kwarg) (Value, error){
+	"count":      strCount,
+	"endswith":   strAffix,
+	"find":       strFind,
+	"isdigit":    strIs,
+	"islower":    strIs,
+	"isupper":    strIs,
+	"join":       strJoin,
+	"lower":      strCase,
+	"lstrip":     strStrip,
+	"replace":    strReplace,
+	"rstrip":     strStrip,
+	"split":      strSplit,
+	"splitlines": strSplitlines,
+	"startswith": strAffix,
+	"strip":      strStrip,
+	"upper":      strCase,
+}
+
+// stringArgs checks the arguments of a string method that takes strings.
+func stringArgs(b *builtin, args []Value, kwargs []kwarg, required, max int) ([]string, error) {
+	if err := unpackArgs(b, args, kwargs, required, max); err != nil {
+		return nil, err
+	}
+	out := make([]string, len(args))
+	for i, a := range args {
+		s, ok := a.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: got %s, want string", b.name, typeName(a))
+		}
+		out[i] = s
+	}
+	return out, nil
+}
+
+func strCount(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	s, err := stringArgs(b, args, kwargs, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	if s[0] == "" {
+		return int64(len(b.recv.(string)) + 1), nil
+	}
+	return int64(strings.Count(b.recv.(string), s[0])), nil
+}
+
+func strAffix(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	affixes := Tuple{args[0]}
+	if t, ok := args[0].(Tuple); ok {
+		affixes = t
+	}
+	has := strings.HasPrefix
+	if b.name == "endswith" {
+		has = strings.HasSuffix
+	}
+	for _, a := range affixes {
+		s, ok := a.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: got %s, want string or tuple of strings", b.name, typeName(a))
+		}
+		if has(b.recv.(string), s) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func strFind(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	s, err := stringArgs(b, args, kwargs, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	return int64(strings.Index(b.recv.(string), s[0])), nil
+}
+
+func strIs(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 0, 0); err != nil {
+		return nil, err
+	}
+	s := b.recv.(string)
+	switch b.name {
+	case "isdigit":
+		if s == "" {
+			return false, nil
+		}
+		for _, r := range s {
+			if r < '0' || r > '9' {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "islower":
+		return s != strings.ToUpper(s) && s == strings.ToLower(s), nil
+	}
+	return s != strings.ToLower(s) && s == strings.ToUpper(s), nil
+}
+
+func strJoin(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	elems, err := elements(b.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(elems))
+	for i, e := range elems {
+		s, ok := e.(string)
+		if !ok {
+			return nil, fmt.Errorf("join: element %d is %s, not string", i, typeName(e))
+		}
+		parts[i] = s
+	}
+	out := strings.Join(parts, b.recv.(string))
+	if len(out) > maxLen {
+		return nil, fmt.Errorf("join: string too long")
+	}
+	return th.charged(out)
+}
+
+func strCase(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 0, 0); err != nil {
+		return nil, err
+	}
+	if b.name == "lower" {
+		return th.charged(strings.ToLower(b.recv.(string)))
+	}
+	return th.charged(strings.ToUpper(b.recv.(string)))
+}
+
+func strStrip(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	s, err := stringArgs(b, args, kwargs, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	cutset := " \t\n\r\v\f"
+	if len(s) == 1 {
+		cutset = s[0]
+	}
+	switch b.name {
+	case "lstrip":
+		return strings.TrimLeft(b.recv.(string), cutset), nil
+	case "rstrip":
+		return strings.TrimRight(b.recv.(string), cutset), nil
+	}
+	return strings.Trim(b.recv.(string), cutset), nil
+}
+
+func strReplace(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	s, err := stringArgs(b, args, kwargs, 2, 2)
+	if err != nil {
+		return nil, err
+	}
+	out := strings.ReplaceAll(b.recv.(string), s[0], s[1])
+	if len(out) > maxLen {
+		return nil, fmt.Errorf("replace: string too long")
+	}
+	return th.charged(out)
+}
+
+func strSplit(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	s, err := stringArgs(b, args, kwargs, 0, 1)
+	if err != nil {
+		return nil, err
+	}
+	var parts []string
+	if len(s) == 0 {
+		parts = strings.Fields(b.recv.(string))
+	} else {
+		if s[0] == "" {
+			return nil, fmt.Errorf("split: empty separator")
+		}
+		parts = strings.Split(b.recv.(string), s[0])
+	}
+	out := make([]Value, len(parts))
+	for i, p := range parts {
+		out[i] = p
+	}
+	return th.charged(NewList(out))
+}
+
+func strSplitlines(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 0, 0); err != nil {
+		return nil, err
+	}
+	s := strings.ReplaceAll(b.recv.(string), "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	var out []Value
+	if s != "" {
+		for _, line := range strings.Split(s, "\n") {
+			out = append(out, line)
+		}
+	}
+	return th.charged(NewList(out))
+}
+
+var listMethods = map[string]func(*thread, *builtin, []Value, []kwarg) (Value, error){
+	"append": listAppend,
+	"extend": listExtend,
+	"index":  listIndex,
+	"pop":    listPop,
+}
+
+func listAppend(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	l := b.recv.(*List)
+	if err := l.checkMutable(); err != nil {
+		return nil, err
+	}
+	if len(l.elems) >= maxLen {
+		return nil, fmt.Errorf("append: list too long")
+	}
+	if err := th.alloc(valueSize); err != nil {
+		return nil, err
+	}
+	l.elems = append(l.elems, args[0])
+	return None, nil
+}
+
+func listExtend(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	l := b.recv.(*List)
+	if err := l.checkMutable(); err != nil {
+		return nil, err
+	}
+	elems, err := elements(b.name, args[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(l.elems)+len(elems) > maxLen {
+		return nil, fmt.Errorf("extend: list too long")
+	}
+	if err := th.alloc(len(elems) * valueSize); err != nil {
+		return nil, err
+	}
+	l.elems = append(l.elems, elems...)
+	return None, nil
+}
+
+func listIndex(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 1); err != nil {
+		return nil, err
+	}
+	for i, e := range b.recv.(*List).elems {
+		if equal(e, args[0]) {
+			return int64(i), nil
+		}
+	}
+	return nil, fmt.Errorf("index: %s not in list", repr(args[0]))
+}
+
+func listPop(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 0, 1); err != nil {
+		return nil, err
+	}
+	l := b.recv.(*List)
+	if err := l.checkMutable(); err != nil {
+		return nil, err
+	}
+	i := len(l.elems) - 1
+	if len(args) == 1 {
+		var err error
+		if i, err = th.index(args[0], len(l.elems)); err != nil {
+			return nil, err
+		}
+	}
+	if i < 0 {
+		return nil, fmt.Errorf("pop: empty list")
+	}
+	v := l.elems[i]
+	l.elems = append(l.elems[:i], l.elems[i+1:]...)
+	return v, nil
+}
+
+var dictMethods = map[string]func(*thread, *builtin, []Value, []kwarg) (Value, error){
+	"get":    dictGet,
+	"items":  dictItems,
+	"keys":   dictItems,
+	"pop":    dictPop,
+	"values": dictItems,
+}
+
+func dictGet(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 2); err != nil {
+		return nil, err
+	}
+	v, ok, err := b.recv.(*Dict).Get(args[0])
+	switch {
+	case err != nil:
+		return nil, err
+	case ok:
+		return v, nil
+	case len(args) == 2:
+		return args[1], nil
+	}
+	return None, nil
+}
+
+func dictItems(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 0, 0); err != nil {
+		return nil, err
+	}
+	d := b.recv.(*Dict)
+	out := make([]Value, len(d.keys))
+	for i, k := range d.keys {
+		switch b.name {
+		case "keys":
+			out[i] = k
+		case "values":
+			out[i] = d.vals[i]
+		default:
+			out[i] = Tuple{k, d.vals[i]}
+		}
+	}
+	if b.name == "items" {
+		if err := th.alloc(len(out) * 2 * valueSize); err != nil {
+			return nil, err
+		}
+	}
+	return th.charged(NewList(out))
+}
+
+func dictPop(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error) {
+	if err := unpackArgs(b, args, kwargs, 1, 2); err != nil {
+		return nil, err
+	}
+	v, ok, err := b.recv.(*Dict).delete(args[0])
+	switch {
+	case err != nil:
+		return nil, err
+	case ok:
+		return v, nil
+	case len(args) == 2:
+		return args[1], nil
+	}
+	return nil, fmt.Errorf("pop: key %s not in dict", repr(args[0]))
+}
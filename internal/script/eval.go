@@ -0,0 +1,990 @@
+package script
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// maxSteps bounds the statements, loop iterations and calls one run may
+// take, so a script cannot stall an analysis.
+const maxSteps = 1_000_000
+
+// maxLen bounds the strings and sequences a script may build.
+const maxLen = 1 << 20
+
+// maxAlloc bounds the memory one run may allocate for strings, lists,
+// tuples and dicts, charged as they are built: maxLen alone would still let
+// a script keep thousands of values just under it.
+const maxAlloc = 64 << 20
+
+// valueSize is what one element of a list, tuple or dict is charged.
+const valueSize = 16
+
+// env is a scope: a function's locals or a comprehension's variables, with
+// the scope it was created in as parent.
+type env struct {
+	vars   map[string]Value
+	parent *env
+}
+
+func (e *env) lookup(name string) (Value, bool) {
+	for ; e != nil; e = e.parent {
+		if v, ok := e.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// thread is the state of one run: the step budget and the functions being
+// called, since recursion is not allowed.
+type thread struct {
+	steps     int
+	allocated int
+	line      int
+	active    map[*function]bool
+}
+
+// evalError is a runtime error at a line of the script.
+type evalError struct {
+	line int
+	msg  string
+}
+
+func (e *evalError) Error() string { return fmt.Sprintf("line %d: %s", e.line, e.msg) }
+
+func (th *thread) errorf(format string, args ...any) error {
+	return &evalError{line: th.line, msg: fmt.Sprintf(format, args...)}
+}
+
+// wrap attaches the current line to an error raised by Go code.
+func (th *thread) wrap(err error) error {
+	var ee *evalError
+	if err == nil || errors.As(err, &ee) {
+		return err
+	}
+	return &evalError{line: th.line, msg: err.Error()}
+}
+
+func (th *thread) step() error {
+	th.steps++
+	if th.steps > maxSteps {
+		return th.errorf("script exceeded %d steps", maxSteps)
+	}
+	return nil
+}
+
+// alloc charges n bytes to the allocation budget of the run.
+func (th *thread) alloc(n int) error {
+	th.allocated += n
+	if th.allocated > maxAlloc {
+		return th.errorf("script allocated more than %d MiB", maxAlloc>>20)
+	}
+	return nil
+}
+
+// charged returns v, a value just built, after charging its size: the
+// bytes of a string or the elements of a container, not what they share.
+func (th *thread) charged(v Value) (Value, error) {
+	var n int
+	switch v := v.(type) {
+	case string:
+		n = len(v)
+	case Tuple:
+		n = len(v) * valueSize
+	case *List:
+		n = len(v.elems) * valueSize
+	case *Dict:
+		n = len(v.keys) * 2 * valueSize
+	}
+	if err := th.alloc(n); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// flow is how a statement ended.
+type flow int
+
+const (
+	flowNormal flow = iota
+	flowReturn
+	flowBreak
+	flowContinue
+)
+
+func (th *thread) execBlock(e *env, stmts []stmt) (flow, Value, error) {
+	for _, s := range stmts {
+		f, v, err := th.exec(e, s)
+		if err != nil || f != flowNormal {
+			return f, v, err
+		}
+	}
+	return flowNormal, nil, nil
+}
+
+func (th *thread) exec(e *env, s stmt) (flow, Value, error) {
+	th.line = s.stmtLine()
+	if err := th.step(); err != nil {
+		return flowNormal, nil, err
+	}
+	switch s := s.(type) {
+	case *exprStmt:
+		_, err := th.eval(e, s.x)
+		return flowNormal, nil, err
+	case *assignStmt:
+		v, err := th.eval(e, s.value)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		if s.op != "" {
+			old, err := th.eval(e, s.target)
+			if err != nil {
+				return flowNormal, nil, err
+			}
+			th.line = s.line
+			if v, err = th.binary(s.op, old, v); err != nil {
+				return flowNormal, nil, err
+			}
+		}
+		return flowNormal, nil, th.assign(e, s.target, v)
+	case *ifStmt:
+		cond, err := th.eval(e, s.cond)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		if truth(cond) {
+			return th.execBlock(e, s.then)
+		}
+		return th.execBlock(e, s.else_)
+	case *forStmt:
+		iter, err := th.eval(e, s.iter)
+		if err != nil {
+			return flowNormal, nil, err
+		}
+		th.line = s.line
+		var result Value
+		ret := false
+		err = th.iterate(iter, func(x Value) (bool, error) {
+			if err := th.step(); err != nil {
+				return false, err
+			}
+			if err := th.assign(e, s.vars, x); err != nil {
+				return false, err
+			}
+			f, v, err := th.execBlock(e, s.body)
+			switch {
+			case err != nil:
+				return false, err
+			case f == flowReturn:
+				result, ret = v, true
+				return false, nil
+			case f == flowBreak:
+				return false, nil
+			}
+			return true, nil
+		})
+		if ret {
+			return flowReturn, result, err
+		}
+		return flowNormal, nil, err
+	case *defStmt:
+		fn := &function{name: s.name, params: s.params, body: s.body, env: e}
+		for _, p := range s.params {
+			var d Value
+			if p.def != nil {
+				v, err := th.eval(e, p.def)
+				if err != nil {
+					return flowNormal, nil, err
+				}
+				d = v
+			}
+			fn.defaults = append(fn.defaults, d)
+		}
+		e.vars[s.name] = fn
+		return flowNormal, nil, nil
+	case *returnStmt:
+		if s.value == nil {
+			return flowReturn, None, nil
+		}
+		v, err := th.eval(e, s.value)
+		return flowReturn, v, err
+	case *branchStmt:
+		switch s.kind {
+		case "break":
+			return flowBreak, nil, nil
+		case "continue":
+			return flowContinue, nil, nil
+		}
+		return flowNormal, nil, nil
+	}
+	return flowNormal, nil, th.errorf("unknown statement %T", s)
+}
+
+// iterate calls fn with each element of a list, a tuple or the keys of a
+// dict, until fn returns false. Strings are not iterable, as in Starlark.
+func (th *thread) iterate(x Value, fn func(Value) (bool, error)) error {
+	var elems []Value
+	switch x := x.(type) {
+	case Tuple:
+		elems = x
+	case *List:
+		x.iterating++
+		defer func() { x.iterating-- }()
+		elems = x.elems
+	case *Dict:
+		x.iterating++
+		defer func() { x.iterating-- }()
+		elems = x.keys
+	case string:
+		return th.errorf("a string is not iterable; use .split() or .splitlines()")
+	default:
+		return th.errorf("%s is not iterable", typeName(x))
+	}
+	for _, v := range elems {
+		more, err := fn(v)
+		if err != nil || !more {
+			return err
+		}
+	}
+	return nil
+}
+
+func (th *thread) assign(e *env, target expr, v Value) error {
+	switch t := target.(type) {
+	case *identExpr:
+		e.vars[t.name] = v
+		return nil
+	case *indexExpr:
+		x, err := th.eval(e, t.x)
+		if err != nil {
+			return err
+		}
+		k, err := th.eval(e, t.index)
+		if err != nil {
+			return err
+		}
+		th.line = t.line
+		switch x := x.(type) {
+		case *List:
+			if err := x.checkMutable(); err != nil {
+				return th.wrap(err)
+			}
+			i, err := th.index(k, len(x.elems))
+			if err != nil {
+				return err
+			}
+			x.elems[i] = v
+			return nil
+		case *Dict:
+			if err := th.alloc(2 * valueSize); err != nil {
+				return err
+			}
+			return th.wrap(x.Set(k, v))
+		}
+		return th.errorf("%s does not support item assignment", typeName(x))
+	case *tupleExpr:
+		return th.unpack(e, t.elems, v)
+	case *listExpr:
+		return th.unpack(e, t.elems, v)
+	}
+	return th.errorf("cannot assign to this expression")
+}
+
+func (th *thread) unpack(e *env, targets []expr, v Value) error {
+	var elems []Value
+	switch v := v.(type) {
+	case Tuple:
+		elems = v
+	case *List:
+		elems = v.elems
+	default:
+		return th.errorf("cannot unpack %s", typeName(v))
+	}
+	if len(elems) != len(targets) {
+		return th.errorf("cannot unpack %d values into %d variables", len(elems), len(targets))
+	}
+	for i, t := range targets {
+		if err := th.assign(e, t, elems[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// index resolves a possibly negative index into a sequence of length n.
+func (th *thread) index(k Value, n int) (int, error) {
+	i, ok := k.(int64)
+	if !ok {
+		return 0, th.errorf("index must be an int, not %s", typeName(k))
+	}
+	if i < 0 {
+		i += int64(n)
+	}
+	if i < 0 || i >= int64(n) {
+		return 0, th.errorf("index %d out of range for length %d", k, n)
+	}
+	return int(i), nil
+}
+
+func (th *thread) eval(e *env, x expr) (Value, error) {
+	switch x := x.(type) {
+	case *literalExpr:
+		return x.value, nil
+	case *identExpr:
+		if v, ok := e.lookup(x.name); ok {
+			return v, nil
+		}
+		if b, ok := universe[x.name]; ok {
+			return b, nil
+		}
+		th.line = x.line
+		return nil, th.errorf("undefined: %s", x.name)
+	case *listExpr:
+		elems, err := th.evalAll(e, x.elems)
+		if err != nil {
+			return nil, err
+		}
+		return th.charged(NewList(elems))
+	case *tupleExpr:
+		elems, err := th.evalAll(e, x.elems)
+		if err != nil {
+			return nil, err
+		}
+		return th.charged(Tuple(elems))
+	case *dictExpr:
+		d := NewDict()
+		for i := range x.keys {
+			k, err := th.eval(e, x.keys[i])
+			if err != nil {
+				return nil, err
+			}
+			v, err := th.eval(e, x.values[i])
+			if err != nil {
+				return nil, err
+			}
+			th.line = x.line
+			if err := d.Set(k, v); err != nil {
+				return nil, th.wrap(err)
+			}
+		}
+		return th.charged(d)
+	case *unaryExpr:
+		v, err := th.eval(e, x.x)
+		if err != nil {
+			return nil, err
+		}
+		th.line = x.line
+		switch x.op {
+		case "not":
+			return !truth(v), nil
+		case "-":
+			switch v := v.(type) {
+			case int64:
+				return -v, nil
+			case float64:
+				return -v, nil
+			}
+		case "+":
+			switch v.(type) {
+			case int64, float64:
+				return v, nil
+			}
+		}
+		return nil, th.errorf("bad operand type for unary %s: %s", x.op, typeName(v))
+	case *binaryExpr:
+		l, err := th.eval(e, x.x)
+		if err != nil {
+			return nil, err
+		}
+		switch x.op {
+		case "and":
+			if !truth(l) {
+				return l, nil
+			}
+			return th.eval(e, x.y)
+		case "or":
+			if truth(l) {
+				return l, nil
+			}
+			return th.eval(e, x.y)
+		}
+		r, err := th.eval(e, x.y)
+		if err != nil {
+			return nil, err
+		}
+		th.line = x.line
+		return th.binary(x.op, l, r)
+	case *condExpr:
+		cond, err := th.eval(e, x.cond)
+		if err != nil {
+			return nil, err
+		}
+		if truth(cond) {
+			return th.eval(e, x.then)
+		}
+		return th.eval(e, x.else_)
+	case *callExpr:
+		return th.evalCall(e, x)
+	case *attrExpr:
+		v, err := th.eval(e, x.x)
+		if err != nil {
+			return nil, err
+		}
+		th.line = x.line
+		return th.attr(v, x.name)
+	case *indexExpr:
+		v, err := th.eval(e, x.x)
+		if err != nil {
+			return nil, err
+		}
+		k, err := th.eval(e, x.index)
+		if err != nil {
+			return nil, err
+		}
+		th.line = x.line
+		return th.getIndex(v, k)
+	case *sliceExpr:
+		return th.evalSlice(e, x)
+	case *compExpr:
+		return th.evalComp(e, x)
+	}
+	return nil, th.errorf("unknown expression %T", x)
+}
+
+func (th *thread) evalAll(e *env, xs []expr) ([]Value, error) {
+	out := make([]Value, 0, len(xs))
+	for _, x := range xs {
+		v, err := th.eval(e, x)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func (th *thread) getIndex(v, k Value) (Value, error) {
+	switch v := v.(type) {
+	case Tuple:
+		i, err := th.index(k, len(v))
+		if err != nil {
+			return nil, err
+		}
+		return v[i], nil
+	case *List:
+		i, err := th.index(k, len(v.elems))
+		if err != nil {
+			return nil, err
+		}
+		return v.elems[i], nil
+	case string:
+		i, err := th.index(k, len(v))
+		if err != nil {
+			return nil, err
+		}
+		return v[i : i+1], nil
+	case *Dict:
+		got, ok, err := v.Get(k)
+		if err != nil {
+			return nil, th.wrap(err)
+		}
+		if !ok {
+			return nil, th.errorf("key %s not in dict", repr(k))
+		}
+		return got, nil
+	}
+	return nil, th.errorf("%s is not indexable", typeName(v))
+}
+
+func (th *thread) evalSlice(e *env, x *sliceExpr) (Value, error) {
+	v, err := th.eval(e, x.x)
+	if err != nil {
+		return nil, err
+	}
+	var bounds [3]Value
+	for i, b := range []expr{x.lo, x.hi, x.step} {
+		bounds[i] = None
+		if b != nil {
+			if bounds[i], err = th.eval(e, b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	th.line = x.line
+	var n int
+	switch v := v.(type) {
+	case Tuple:
+		n = len(v)
+	case *List:
+		n = len(v.elems)
+	case string:
+		n = len(v)
+	default:
+		return nil, th.errorf("%s cannot be sliced", typeName(v))
+	}
+	step := int64(1)
+	if bounds[2] != None {
+		s, ok := bounds[2].(int64)
+		if !ok || s == 0 {
+			return nil, th.errorf("slice step must be a non-zero int")
+		}
+		step = s
+	}
+	lo, hi := int64(0), int64(n)
+	if step < 0 {
+		lo, hi = int64(n-1), -1
+	}
+	clamp := func(b Value, def int64) (int64, error) {
+		if b == None {
+			return def, nil
+		}
+		i, ok := b.(int64)
+		if !ok {
+			return 0, th.errorf("slice bounds must be ints, not %s", typeName(b))
+		}
+		if i < 0 {
+			i += int64(n)
+		}
+		min, max := int64(0), int64(n)
+		if step < 0 {
+			min, max = -1, int64(n-1)
+		}
+		return int64(math.Max(float64(min), math.Min(float64(max), float64(i)))), nil
+	}
+	if lo, err = clamp(bounds[0], lo); err != nil {
+		return nil, err
+	}
+	if hi, err = clamp(bounds[1], hi); err != nil {
+		return nil, err
+	}
+	var idx []int
+	for i := lo; (step > 0 && i < hi) || (step < 0 && i > hi); i += step {
+		idx = append(idx, int(i))
+	}
+	switch v := v.(type) {
+	case Tuple:
+		out := make(Tuple, len(idx))
+		for j, i := range idx {
+			out[j] = v[i]
+		}
+		return th.charged(out)
+	case *List:
+		out := make([]Value, len(idx))
+		for j, i := range idx {
+			out[j] = v.elems[i]
+		}
+		return th.charged(NewList(out))
+	}
+	s := v.(string)
+	var b strings.Builder
+	for _, i := range idx {
+		b.WriteByte(s[i])
+	}
+	return th.charged(b.String())
+}
+
+func (th *thread) evalComp(e *env, x *compExpr) (Value, error) {
+	scope := &env{vars: map[string]Value{}, parent: e}
+	var list []Value
+	var dict *Dict
+	if x.key != nil {
+		dict = NewDict()
+	}
+	var loop func(i int) error
+	loop = func(i int) error {
+		if i == len(x.clauses) {
+			if dict != nil {
+				k, err := th.eval(scope, x.key)
+				if err != nil {
+					return err
+				}
+				v, err := th.eval(scope, x.value)
+				if err != nil {
+					return err
+				}
+				th.line = x.line
+				if err := th.alloc(2 * valueSize); err != nil {
+					return err
+				}
+				return th.wrap(dict.Set(k, v))
+			}
+			v, err := th.eval(scope, x.value)
+			if err != nil {
+				return err
+			}
+			if len(list) >= maxLen {
+				return th.errorf("list too long")
+			}
+			if err := th.alloc(valueSize); err != nil {
+				return err
+			}
+			list = append(list, v)
+			return nil
+		}
+		c := x.clauses[i]
+		if c.vars == nil {
+			cond, err := th.eval(scope, c.cond)
+			if err != nil || !truth(cond) {
+				return err
+			}
+			return loop(i + 1)
+		}
+		iter, err := th.eval(scope, c.iter)
+		if err != nil {
+			return err
+		}
+		th.line = x.line
+		return th.iterate(iter, func(v Value) (bool, error) {
+			if err := th.step(); err != nil {
+				return false, err
+			}
+			if err := th.assign(scope, c.vars, v); err != nil {
+				return false, err
+			}
+			return true, loop(i + 1)
+		})
+	}
+	if err := loop(0); err != nil {
+		return nil, err
+	}
+	if dict != nil {
+		return dict, nil
+	}
+	return NewList(list), nil
+}
+
+func (th *thread) evalCall(e *env, x *callExpr) (Value, error) {
+	fn, err := th.eval(e, x.fn)
+	if err != nil {
+		return nil, err
+	}
+	var args []Value
+	var kwargs []kwarg
+	for _, a := range x.args {
+		v, err := th.eval(e, a.value)
+		if err != nil {
+			return nil, err
+		}
+		if a.name != "" {
+			kwargs = append(kwargs, kwarg{name: a.name, value: v})
+		} else {
+			args = append(args, v)
+		}
+	}
+	th.line = x.line
+	v, err := th.call(fn, args, kwargs)
+	th.line = x.line
+	return v, err
+}
+
+// call calls a function value.
+func (th *thread) call(fn Value, args []Value, kwargs []kwarg) (Value, error) {
+	if err := th.step(); err != nil {
+		return nil, err
+	}
+	switch fn := fn.(type) {
+	case *builtin:
+		v, err := fn.fn(th, fn, args, kwargs)
+		return v, th.wrap(err)
+	case *function:
+		if th.active[fn] {
+			return nil, th.errorf("%s: recursion is not allowed", fn.name)
+		}
+		locals := &env{vars: make(map[string]Value, len(fn.params)), parent: fn.env}
+		if len(args) > len(fn.params) {
+			return nil, th.errorf("%s() takes %d arguments, got %d", fn.name, len(fn.params), len(args))
+		}
+		for i, a := range args {
+			locals.vars[fn.params[i].name] = a
+		}
+		for _, kw := range kwargs {
+			found := false
+			for i, p := range fn.params {
+				if p.name != kw.name {
+					continue
+				}
+				if i < len(args) {
+					return nil, th.errorf("%s() got multiple values for %s", fn.name, kw.name)
+				}
+				locals.vars[kw.name] = kw.value
+				found = true
+			}
+			if !found {
+				return nil, th.errorf("%s() got an unexpected keyword argument %s", fn.name, kw.name)
+			}
+		}
+		for i, p := range fn.params {
+			if _, ok := locals.vars[p.name]; ok {
+				continue
+			}
+			if fn.defaults[i] == nil {
+				return nil, th.errorf("%s() missing argument %s", fn.name, p.name)
+			}
+			locals.vars[p.name] = fn.defaults[i]
+		}
+		if th.active == nil {
+			th.active = map[*function]bool{}
+		}
+		th.active[fn] = true
+		defer delete(th.active, fn)
+		f, v, err := th.execBlock(locals, fn.body)
+		switch {
+		case err != nil:
+			return nil, err
+		case f == flowReturn:
+			return v, nil
+		case f == flowBreak || f == flowContinue:
+			return nil, th.errorf("break or continue outside a loop")
+		}
+		return None, nil
+	}
+	return nil, th.errorf("%s is not callable", typeName(fn))
+}
+
+func (th *thread) binary(op string, x, y Value) (Value, error) {
+	switch op {
+	case "==":
+		return equal(x, y), nil
+	case "!=":
+		return !equal(x, y), nil
+	case "<", "<=", ">", ">=":
+		c, err := compare(x, y)
+		if err != nil {
+			return nil, th.wrap(err)
+		}
+		switch op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		}
+		return c >= 0, nil
+	case "in", "not in":
+		in, err := th.contains(y, x)
+		if err != nil {
+			return nil, err
+		}
+		return in == (op == "in"), nil
+	}
+
+	if xi, ok := x.(int64); ok {
+		if yi, ok := y.(int64); ok {
+			switch op {
+			case "+":
+				return xi + yi, nil
+			case "-":
+				return xi - yi, nil
+			case "*":
+				return xi * yi, nil
+			case "/":
+				if yi == 0 {
+					return nil, th.errorf("division by zero")
+				}
+				return float64(xi) / float64(yi), nil
+			case "//", "%":
+				if yi == 0 {
+					return nil, th.errorf("division by zero")
+				}
+				q, r := xi/yi, xi%yi
+				if r != 0 && (r < 0) != (yi < 0) {
+					q--
+					r += yi
+				}
+				if op == "//" {
+					return q, nil
+				}
+				return r, nil
+			}
+		}
+	}
+	if xf, ok := number(x); ok {
+		if yf, ok := number(y); ok {
+			switch op {
+			case "+":
+				return xf + yf, nil
+			case "-":
+				return xf - yf, nil
+			case "*":
+				return xf * yf, nil
+			case "/", "//", "%":
+				if yf == 0 {
+					return nil, th.errorf("division by zero")
+				}
+				switch op {
+				case "/":
+					return xf / yf, nil
+				case "//":
+					return math.Floor(xf / yf), nil
+				}
+				r := math.Mod(xf, yf)
+				if r != 0 && (r < 0) != (yf < 0) {
+					r += yf
+				}
+				return r, nil
+			}
+		}
+	}
+
+	switch op {
+	case "+":
+		switch x := x.(type) {
+		case string:
+			if y, ok := y.(string); ok {
+				if len(x)+len(y) > maxLen {
+					return nil, th.errorf("string too long")
+				}
+				return th.charged(x + y)
+			}
+		case Tuple:
+			if y, ok := y.(Tuple); ok {
+				return th.charged(append(append(Tuple{}, x...), y...))
+			}
+		case *List:
+			if y, ok := y.(*List); ok {
+				return th.charged(NewList(append(append([]Value{}, x.elems...), y.elems...)))
+			}
+		}
+	case "*":
+		n, seq := y, x
+		if _, ok := x.(int64); ok {
+			n, seq = x, y
+		}
+		if count, ok := n.(int64); ok {
+			return th.repeat(seq, count)
+		}
+	case "%":
+		if format, ok := x.(string); ok {
+			return th.format(format, y)
+		}
+	}
+	return nil, th.errorf("unsupported operand types for %s: %s and %s", op, typeName(x), typeName(y))
+}
+
+// repeat implements sequence * int.
+func (th *thread) repeat(seq Value, n int64) (Value, error) {
+	if n < 0 {
+		n = 0
+	}
+	size := func(l, each int) error {
+		if int64(l)*n > maxLen {
+			return th.errorf("result too long")
+		}
+		return th.alloc(l * int(n) * each)
+	}
+	switch seq := seq.(type) {
+	case string:
+		if err := size(len(seq), 1); err != nil {
+			return nil, err
+		}
+		return strings.Repeat(seq, int(n)), nil
+	case Tuple:
+		if err := size(len(seq), valueSize); err != nil {
+			return nil, err
+		}
+		out := Tuple{}
+		for i := int64(0); i < n; i++ {
+			out = append(out, seq...)
+		}
+		return out, nil
+	case *List:
+		if err := size(len(seq.elems), valueSize); err != nil {
+			return nil, err
+		}
+		out := []Value{}
+		for i := int64(0); i < n; i++ {
+			out = append(out, seq.elems...)
+		}
+		return NewList(out), nil
+	}
+	return nil, th.errorf("unsupported operand types for *: %s and int", typeName(seq))
+}
+
+// format implements "%s" % args with the verbs %s, %r, %d and %%.
+func (th *thread) format(format string, args Value) (Value, error) {
+	vals, ok := args.(Tuple)
+	if !ok {
+		vals = Tuple{args}
+	}
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c != '%' {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		if i == len(format) {
+			return nil, th.errorf("incomplete format")
+		}
+		verb := format[i]
+		if verb == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		if n >= len(vals) {
+			return nil, th.errorf("not enough arguments for format string")
+		}
+		v := vals[n]
+		n++
+		switch verb {
+		case 's':
+			b.WriteString(str(v))
+		case 'r':
+			b.WriteString(repr(v))
+		case 'd':
+			switch v := v.(type) {
+			case int64:
+				fmt.Fprintf(&b, "%d", v)
+			case float64:
+				fmt.Fprintf(&b, "%d", int64(v))
+			default:
+				return nil, th.errorf("%%d format requires a number, not %s", typeName(v))
+			}
+		default:
+			return nil, th.errorf("unsupported format verb %%%c", verb)
+		}
+	}
+	if n < len(vals) {
+		return nil, th.errorf("too many arguments for format string")
+	}
+	if b.Len() > maxLen {
+		return nil, th.errorf("string too long")
+	}
+	return th.charged(b.String())
+}
+
+// contains implements "x in container".
+func (th *thread) contains(container, x Value) (bool, error) {
+	switch c := container.(type) {
+	case string:
+		s, ok := x.(string)
+		if !ok {
+			return false, th.errorf("'in <string>' requires a string, not %s", typeName(x))
+		}
+		return strings.Contains(c, s), nil
+	case Tuple:
+		for _, e := range c {
+			if equal(e, x) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *List:
+		for _, e := range c.elems {
+			if equal(e, x) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case *Dict:
+		_, ok, err := c.Get(x)
+		return ok, th.wrap(err)
+	}
+	return false, th.errorf("'in' is not supported by %s", typeName(container))
+}
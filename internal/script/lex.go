@@ -0,0 +1,314 @@
+package script
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNewline
+	tokIndent
+	tokDedent
+	tokName
+	tokInt
+	tokFloat
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string // name, operator or keyword
+	val  any    // int64, float64 or string literal value
+	line int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of file"
+	case tokNewline:
+		return "newline"
+	case tokIndent:
+		return "indent"
+	case tokDedent:
+		return "dedent"
+	case tokString:
+		return "string"
+	case tokInt, tokFloat:
+		return "number"
+	}
+	return strconv.Quote(t.text)
+}
+
+// operators are matched longest first.
+var operators = []string{
+	"//=", "==", "!=", "<=", ">=", "+=", "-=", "*=", "/=", "%=", "//",
+	"+", "-", "*", "/", "%", "<", ">", "=", "(", ")", "[", "]", "{", "}", ",", ":", ".",
+}
+
+// lex splits src into tokens, turning indentation into indent and dedent
+// tokens and dropping newlines inside brackets, as Python does.
+func lex(src string) ([]token, error) {
+	var (
+		toks    []token
+		indents = []int{0}
+		depth   int
+		line    = 1
+		i       int
+		bol     = true // at the beginning of a logical line
+	)
+	errorf := func(format string, args ...any) error {
+		return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+	}
+	for i < len(src) {
+		if bol && depth == 0 {
+			// Measure the indentation of a line that holds code.
+			col := 0
+			j := i
+			for j < len(src) && (src[j] == ' ' || src[j] == '\t') {
+				if src[j] == '\t' {
+					return nil, errorf("use spaces, not tabs, for indentation")
+				}
+				col++
+				j++
+			}
+			if j < len(src) && (src[j] == '\n' || src[j] == '#' || src[j] == '\r') {
+				// Blank or comment-only lines do not count.
+				for j < len(src) && src[j] != '\n' {
+					j++
+				}
+				if j < len(src) {
+					j++
+				}
+				line++
+				i = j
+				continue
+			}
+			i = j
+			if i == len(src) {
+				break
+			}
+			switch top := indents[len(indents)-1]; {
+			case col > top:
+				indents = append(indents, col)
+				toks = append(toks, token{kind: tokIndent, line: line})
+			case col < top:
+				for col < indents[len(indents)-1] {
+					indents = indents[:len(indents)-1]
+					toks = append(toks, token{kind: tokDedent, line: line})
+				}
+				if col != indents[len(indents)-1] {
+					return nil, errorf("unindent does not match any outer indentation level")
+				}
+			}
+			bol = false
+		}
+		c := src[i]
+		switch {
+		case c == '\n':
+			if depth == 0 {
+				toks = append(toks, token{kind: tokNewline, line: line})
+				bol = true
+			}
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '\\' && i+1 < len(src) && src[i+1] == '\n':
+			line++
+			i += 2
+		case isLetter(c):
+			j := i
+			for j < len(src) && (isLetter(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			word := src[i:j]
+			if (word == "r" || word == "R") && j < len(src) && (src[j] == '"' || src[j] == '\'') {
+				s, n, lines, err := lexString(src[j:], true)
+				if err != nil {
+					return nil, errorf("%v", err)
+				}
+				toks = append(toks, token{kind: tokString, val: s, line: line})
+				line += lines
+				i = j + n
+				continue
+			}
+			toks = append(toks, token{kind: tokName, text: word, line: line})
+			i = j
+		case isDigit(c) || (c == '.' && i+1 < len(src) && isDigit(src[i+1])):
+			tok, n, err := lexNumber(src[i:])
+			if err != nil {
+				return nil, errorf("%v", err)
+			}
+			tok.line = line
+			toks = append(toks, tok)
+			i += n
+		case c == '"' || c == '\'':
+			s, n, lines, err := lexString(src[i:], false)
+			if err != nil {
+				return nil, errorf("%v", err)
+			}
+			toks = append(toks, token{kind: tokString, val: s, line: line})
+			line += lines
+			i += n
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, errorf("unexpected character %q", c)
+			}
+			switch op {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				if depth > 0 {
+					depth--
+				}
+			}
+			toks = append(toks, token{kind: tokOp, text: op, line: line})
+			i += len(op)
+		}
+	}
+	if depth > 0 {
+		return nil, errorf("unclosed bracket at end of file")
+	}
+	if len(toks) > 0 && toks[len(toks)-1].kind != tokNewline && toks[len(toks)-1].kind != tokDedent {
+		toks = append(toks, token{kind: tokNewline, line: line})
+	}
+	for len(indents) > 1 {
+		indents = indents[:len(indents)-1]
+		toks = append(toks, token{kind: tokDedent, line: line})
+	}
+	return append(toks, token{kind: tokEOF, line: line}), nil
+}
+
+func isLetter(c byte) bool { return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// lexNumber reads an int or float literal at the start of s.
+func lexNumber(s string) (token, int, error) {
+	if len(s) > 1 && s[0] == '0' && (s[1] == 'x' || s[1] == 'X') {
+		j := 2
+		for j < len(s) && strings.IndexByte("0123456789abcdefABCDEF", s[j]) >= 0 {
+			j++
+		}
+		n, err := strconv.ParseInt(s[2:j], 16, 64)
+		if err != nil {
+			return token{}, 0, fmt.Errorf("invalid number %q", s[:j])
+		}
+		return token{kind: tokInt, val: n}, j, nil
+	}
+	j := 0
+	float := false
+	for j < len(s) && isDigit(s[j]) {
+		j++
+	}
+	if j < len(s) && s[j] == '.' {
+		float = true
+		j++
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+	}
+	if j < len(s) && (s[j] == 'e' || s[j] == 'E') {
+		k := j + 1
+		if k < len(s) && (s[k] == '+' || s[k] == '-') {
+			k++
+		}
+		if k < len(s) && isDigit(s[k]) {
+			float = true
+			for k < len(s) && isDigit(s[k]) {
+				k++
+			}
+			j = k
+		}
+	}
+	if j < len(s) && (isLetter(s[j]) || isDigit(s[j])) {
+		return token{}, 0, fmt.Errorf("invalid number %q", s[:j+1])
+	}
+	if float {
+		f, err := strconv.ParseFloat(s[:j], 64)
+		if err != nil {
+			return token{}, 0, fmt.Errorf("invalid number %q", s[:j])
+		}
+		return token{kind: tokFloat, val: f}, j, nil
+	}
+	if len(s[:j]) > 1 && s[0] == '0' {
+		return token{}, 0, fmt.Errorf("invalid number %q: leading zeros are not allowed", s[:j])
+	}
+	n, err := strconv.ParseInt(s[:j], 10, 64)
+	if err != nil {
+		return token{}, 0, fmt.Errorf("number %s is out of range", s[:j])
+	}
+	return token{kind: tokInt, val: n}, j, nil
+}
+
+// lexString reads a quoted string at the start of s, single or triple
+// quoted, and returns its value, its length in s and the newlines it spans.
+// Raw strings keep backslashes; otherwise unknown escapes are kept as
+// written, so regular expressions like "\d" read naturally.
+func lexString(s string, raw bool) (string, int, int, error) {
+	quote := s[:1]
+	if strings.HasPrefix(s, quote+quote+quote) {
+		quote = s[:3]
+	}
+	var b strings.Builder
+	lines := 0
+	for i := len(quote); i < len(s); {
+		if strings.HasPrefix(s[i:], quote) {
+			return b.String(), i + len(quote), lines, nil
+		}
+		c := s[i]
+		switch {
+		case c == '\n' && len(quote) == 1:
+			return "", 0, 0, fmt.Errorf("unterminated string")
+		case c == '\\' && i+1 < len(s):
+			next := s[i+1]
+			if next == '\n' {
+				lines++
+			}
+			if raw {
+				b.WriteByte(c)
+				b.WriteByte(next)
+				i += 2
+				continue
+			}
+			switch next {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '\'', '"':
+				b.WriteByte(next)
+			case '\n':
+				// A backslash joins the lines.
+			default:
+				b.WriteByte(c)
+				b.WriteByte(next)
+			}
+			i += 2
+			continue
+		case c == '\n':
+			lines++
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return "", 0, 0, fmt.Errorf("unterminated string")
+}
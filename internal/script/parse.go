@@ -0,0 +1,880 @@
+package script
+
+import (
+	"fmt"
+)
+
+// Expressions.
+type (
+	expr interface{ exprLine() int }
+
+	identExpr struct {
+		line int
+		name string
+	}
+	literalExpr struct {
+		line  int
+		value Value
+	}
+	listExpr struct {
+		line  int
+		elems []expr
+	}
+	tupleExpr struct {
+		line  int
+		elems []expr
+	}
+	dictExpr struct {
+		line         int
+		keys, values []expr
+	}
+	unaryExpr struct {
+		line int
+		op   string
+		x    expr
+	}
+	binaryExpr struct {
+		line int
+		op   string
+		x, y expr
+	}
+	condExpr struct {
+		line              int
+		cond, then, else_ expr
+	}
+	callExpr struct {
+		line int
+		fn   expr
+		args []argExpr
+	}
+	attrExpr struct {
+		line int
+		x    expr
+		name string
+	}
+	indexExpr struct {
+		line     int
+		x, index expr
+	}
+	sliceExpr struct {
+		line            int
+		x, lo, hi, step expr
+	}
+	// compExpr is a list or dict comprehension; key is nil for a list.
+	compExpr struct {
+		line       int
+		key, value expr
+		clauses    []compClause
+	}
+)
+
+// argExpr is one call argument; name is set for keyword arguments.
+type argExpr struct {
+	name  string
+	value expr
+}
+
+// compClause is a "for vars in iter" clause, or an "if cond" one when vars
+// is nil.
+type compClause struct {
+	vars expr
+	iter expr
+	cond expr
+}
+
+func (e *identExpr) exprLine() int   { return e.line }
+func (e *literalExpr) exprLine() int { return e.line }
+func (e *listExpr) exprLine() int    { return e.line }
+func (e *tupleExpr) exprLine() int   { return e.line }
+func (e *dictExpr) exprLine() int    { return e.line }
+func (e *unaryExpr) exprLine() int   { return e.line }
+func (e *binaryExpr) exprLine() int  { return e.line }
+func (e *condExpr) exprLine() int    { return e.line }
+func (e *callExpr) exprLine() int    { return e.line }
+func (e *attrExpr) exprLine() int    { return e.line }
+func (e *indexExpr) exprLine() int   { return e.line }
+func (e *sliceExpr) exprLine() int   { return e.line }
+func (e *compExpr) exprLine() int    { return e.line }
+
+// Statements.
+type (
+	stmt interface{ stmtLine() int }
+
+	exprStmt struct {
+		line int
+		x    expr
+	}
+	// assignStmt is "target = value", or "target op= value" when op is set.
+	assignStmt struct {
+		line   int
+		op     string
+		target expr
+		value  expr
+	}
+	ifStmt struct {
+		line        int
+		cond        expr
+		then, else_ []stmt
+	}
+	forStmt struct {
+		line int
+		vars expr
+		iter expr
+		body []stmt
+	}
+	defStmt struct {
+		line   int
+		name   string
+		params []param
+		body   []stmt
+	}
+	returnStmt struct {
+		line  int
+		value expr
+	}
+	// branchStmt is pass, break or continue.
+	branchStmt struct {
+		line int
+		kind string
+	}
+)
+
+// param is a function parameter; def is its default value, if any.
+type param struct {
+	name string
+	def  expr
+}
+
+func (s *exprStmt) stmtLine() int   { return s.line }
+func (s *assignStmt) stmtLine() int { return s.line }
+func (s *ifStmt) stmtLine() int     { return s.line }
+func (s *forStmt) stmtLine() int    { return s.line }
+func (s *defStmt) stmtLine() int    { return s.line }
+func (s *returnStmt) stmtLine() int { return s.line }
+func (s *branchStmt) stmtLine() int { return s.line }
+
+// keywords cannot be used as names.
+var keywords = map[string]bool{
+	"and": true, "break": true, "continue": true, "def": true, "elif": true, "else": true,
+	"for": true, "if": true, "in": true, "not": true, "or": true, "pass": true, "return": true,
+	"None": true, "True": true, "False": true,
+	// Reserved: the language has no unbounded loops, modules or lambdas.
+	"while": true, "load": true, "lambda": true, "import": true, "class": true,
+	"try": true, "except": true, "with": true, "yield": true, "global": true, "nonlocal": true,
+}
+
+type parser struct {
+	toks []token
+	pos  int
+}
+
+// parse turns src into the statements of a module.
+func parse(src string) ([]stmt, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	var stmts []stmt
+	for p.peek().kind != tokEOF {
+		s, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, s...)
+	}
+	return stmts, nil
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is reports whether the next token is the operator or keyword text.
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokOp || t.kind == tokName) && t.text == text
+}
+
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", t.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(text string) (token, error) {
+	t := p.peek()
+	if !p.accept(text) {
+		return t, p.errorf(t, "expected %q, got %s", text, t)
+	}
+	return t, nil
+}
+
+func (p *parser) expectNewline() error {
+	t := p.next()
+	if t.kind != tokNewline {
+		return p.errorf(t, "expected newline, got %s", t)
+	}
+	return nil
+}
+
+// statement parses one compound statement, or a line of simple ones.
+func (p *parser) statement() ([]stmt, error) {
+	t := p.peek()
+	if t.kind == tokIndent {
+		return nil, p.errorf(t, "unexpected indent")
+	}
+	if t.kind == tokName {
+		switch t.text {
+		case "def":
+			s, err := p.def()
+			return []stmt{s}, err
+		case "if":
+			p.next()
+			s, err := p.ifRest(t.line)
+			return []stmt{s}, err
+		case "for":
+			s, err := p.forStmt()
+			return []stmt{s}, err
+		case "while", "load", "lambda", "import", "class", "try", "with", "yield", "global", "nonlocal":
+			return nil, p.errorf(t, "%q is not supported", t.text)
+		}
+	}
+	s, err := p.simple()
+	if err != nil {
+		return nil, err
+	}
+	return []stmt{s}, p.expectNewline()
+}
+
+func (p *parser) simple() (stmt, error) {
+	t := p.peek()
+	if t.kind == tokName {
+		switch t.text {
+		case "pass", "break", "continue":
+			p.next()
+			return &branchStmt{line: t.line, kind: t.text}, nil
+		case "return":
+			p.next()
+			s := &returnStmt{line: t.line}
+			if p.peek().kind != tokNewline {
+				v, err := p.exprList()
+				if err != nil {
+					return nil, err
+				}
+				s.value = v
+			}
+			return s, nil
+		}
+	}
+	x, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "+=", "-=", "*=", "/=", "//=", "%="} {
+		if !p.is(op) {
+			continue
+		}
+		opTok := p.next()
+		if err := checkTarget(x, op == "="); err != nil {
+			return nil, p.errorf(opTok, "%v", err)
+		}
+		v, err := p.exprList()
+		if err != nil {
+			return nil, err
+		}
+		s := &assignStmt{line: t.line, target: x, value: v}
+		if op != "=" {
+			s.op = op[:len(op)-1]
+		}
+		return s, nil
+	}
+	return &exprStmt{line: t.line, x: x}, nil
+}
+
+// checkTarget reports whether x can be assigned to; tuples only when
+// unpacking is allowed.
+func checkTarget(x expr, unpack bool) error {
+	switch x := x.(type) {
+	case *identExpr, *indexExpr:
+		return nil
+	case *tupleExpr:
+		if unpack {
+			for _, e := range x.elems {
+				if err := checkTarget(e, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	case *listExpr:
+		if unpack {
+			for _, e := range x.elems {
+				if err := checkTarget(e, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot assign to this expression")
+}
+
+// block parses ":" and an indented suite, or simple statements on the
+// same line.
+func (p *parser) block() ([]stmt, error) {
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokNewline {
+		s, err := p.simple()
+		if err != nil {
+			return nil, err
+		}
+		return []stmt{s}, p.expectNewline()
+	}
+	p.next()
+	if t := p.next(); t.kind != tokIndent {
+		return nil, p.errorf(t, "expected an indented block")
+	}
+	var body []stmt
+	for p.peek().kind != tokDedent && p.peek().kind != tokEOF {
+		s, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		body = append(body, s...)
+	}
+	p.next()
+	return body, nil
+}
+
+func (p *parser) def() (stmt, error) {
+	t := p.next()
+	name := p.next()
+	if name.kind != tokName || keywords[name.text] {
+		return nil, p.errorf(name, "expected a function name, got %s", name)
+	}
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+	s := &defStmt{line: t.line, name: name.text}
+	seen := map[string]bool{}
+	for !p.is(")") {
+		pt := p.next()
+		if pt.kind != tokName || keywords[pt.text] {
+			return nil, p.errorf(pt, "expected a parameter name, got %s", pt)
+		}
+		if seen[pt.text] {
+			return nil, p.errorf(pt, "duplicate parameter %q", pt.text)
+		}
+		seen[pt.text] = true
+		prm := param{name: pt.text}
+		if p.accept("=") {
+			d, err := p.test()
+			if err != nil {
+				return nil, err
+			}
+			prm.def = d
+		} else if len(s.params) > 0 && s.params[len(s.params)-1].def != nil {
+			return nil, p.errorf(pt, "parameter %q without a default follows one with a default", pt.text)
+		}
+		s.params = append(s.params, prm)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	s.body = body
+	return s, nil
+}
+
+// ifRest parses an if statement after its keyword; elif chains nest.
+func (p *parser) ifRest(line int) (stmt, error) {
+	cond, err := p.test()
+	if err != nil {
+		return nil, err
+	}
+	then, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	s := &ifStmt{line: line, cond: cond, then: then}
+	if t := p.peek(); p.accept("elif") {
+		elif, err := p.ifRest(t.line)
+		if err != nil {
+			return nil, err
+		}
+		s.else_ = []stmt{elif}
+	} else if p.accept("else") {
+		if s.else_, err = p.block(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) forStmt() (stmt, error) {
+	t := p.next()
+	vars, err := p.targets()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("in"); err != nil {
+		return nil, err
+	}
+	iter, err := p.exprList()
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return &forStmt{line: t.line, vars: vars, iter: iter, body: body}, nil
+}
+
+// targets parses the loop variables of a for clause.
+func (p *parser) targets() (expr, error) {
+	t := p.peek()
+	var elems []expr
+	for {
+		x, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, x)
+		if !p.accept(",") || p.is("in") {
+			break
+		}
+	}
+	var vars expr = &tupleExpr{line: t.line, elems: elems}
+	if len(elems) == 1 {
+		vars = elems[0]
+	}
+	if err := checkTarget(vars, true); err != nil {
+		return nil, p.errorf(t, "%v", err)
+	}
+	return vars, nil
+}
+
+// exprList parses one expression, or a tuple of them without parentheses.
+func (p *parser) exprList() (expr, error) {
+	t := p.peek()
+	x, err := p.test()
+	if err != nil {
+		return nil, err
+	}
+	if !p.is(",") {
+		return x, nil
+	}
+	elems := []expr{x}
+	for p.accept(",") {
+		if p.endsList() {
+			break
+		}
+		y, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, y)
+	}
+	return &tupleExpr{line: t.line, elems: elems}, nil
+}
+
+// endsList reports whether the next token ends an expression list, after a
+// trailing comma.
+func (p *parser) endsList() bool {
+	t := p.peek()
+	switch t.kind {
+	case tokNewline, tokEOF:
+		return true
+	case tokOp:
+		switch t.text {
+		case ")", "]", "}", "=", ":":
+			return true
+		}
+	}
+	return false
+}
+
+// test parses a conditional expression: "a if cond else b".
+func (p *parser) test() (expr, error) {
+	t := p.peek()
+	x, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("if") {
+		return x, nil
+	}
+	cond, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("else"); err != nil {
+		return nil, err
+	}
+	y, err := p.test()
+	if err != nil {
+		return nil, err
+	}
+	return &condExpr{line: t.line, cond: cond, then: x, else_: y}, nil
+}
+
+func (p *parser) or() (expr, error) {
+	x, err := p.and()
+	for err == nil && p.is("or") {
+		t := p.next()
+		var y expr
+		if y, err = p.and(); err == nil {
+			x = &binaryExpr{line: t.line, op: "or", x: x, y: y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) and() (expr, error) {
+	x, err := p.not()
+	for err == nil && p.is("and") {
+		t := p.next()
+		var y expr
+		if y, err = p.not(); err == nil {
+			x = &binaryExpr{line: t.line, op: "and", x: x, y: y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) not() (expr, error) {
+	if t := p.peek(); p.accept("not") {
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{line: t.line, op: "not", x: x}, nil
+	}
+	return p.comparison()
+}
+
+// comparison parses one comparison; chains like a < b < c are rejected, as
+// in Starlark.
+func (p *parser) comparison() (expr, error) {
+	x, err := p.arith()
+	if err != nil {
+		return nil, err
+	}
+	op := ""
+	t := p.peek()
+	switch {
+	case p.is("==") || p.is("!=") || p.is("<") || p.is("<=") || p.is(">") || p.is(">="):
+		op = p.next().text
+	case p.is("in"):
+		p.next()
+		op = "in"
+	case p.is("not") && p.toks[p.pos+1].kind == tokName && p.toks[p.pos+1].text == "in":
+		p.pos += 2
+		op = "not in"
+	default:
+		return x, nil
+	}
+	y, err := p.arith()
+	if err != nil {
+		return nil, err
+	}
+	if n := p.peek(); n.kind == tokOp && (n.text == "==" || n.text == "!=" || n.text == "<" || n.text == "<=" || n.text == ">" || n.text == ">=") {
+		return nil, p.errorf(n, "chained comparisons are not supported; use and")
+	}
+	return &binaryExpr{line: t.line, op: op, x: x, y: y}, nil
+}
+
+func (p *parser) arith() (expr, error) {
+	x, err := p.term()
+	for err == nil && (p.is("+") || p.is("-")) {
+		t := p.next()
+		var y expr
+		if y, err = p.term(); err == nil {
+			x = &binaryExpr{line: t.line, op: t.text, x: x, y: y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) term() (expr, error) {
+	x, err := p.unary()
+	for err == nil && (p.is("*") || p.is("/") || p.is("//") || p.is("%")) {
+		t := p.next()
+		var y expr
+		if y, err = p.unary(); err == nil {
+			x = &binaryExpr{line: t.line, op: t.text, x: x, y: y}
+		}
+	}
+	return x, err
+}
+
+func (p *parser) unary() (expr, error) {
+	if t := p.peek(); p.is("-") || p.is("+") {
+		p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{line: t.line, op: t.text, x: x}, nil
+	}
+	return p.primary()
+}
+
+// primary parses an operand followed by calls, attributes and indexes.
+func (p *parser) primary() (expr, error) {
+	x, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case p.accept("."):
+			name := p.next()
+			if name.kind != tokName {
+				return nil, p.errorf(name, "expected an attribute name, got %s", name)
+			}
+			x = &attrExpr{line: t.line, x: x, name: name.text}
+		case p.accept("("):
+			args, err := p.args()
+			if err != nil {
+				return nil, err
+			}
+			x = &callExpr{line: t.line, fn: x, args: args}
+		case p.accept("["):
+			if x, err = p.subscript(x, t.line); err != nil {
+				return nil, err
+			}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) args() ([]argExpr, error) {
+	var args []argExpr
+	seen := map[string]bool{}
+	for !p.is(")") {
+		t := p.peek()
+		var a argExpr
+		if t.kind == tokName && !keywords[t.text] && p.toks[p.pos+1].kind == tokOp && p.toks[p.pos+1].text == "=" {
+			if seen[t.text] {
+				return nil, p.errorf(t, "keyword argument %q repeated", t.text)
+			}
+			seen[t.text] = true
+			a.name = t.text
+			p.pos += 2
+		} else if len(seen) > 0 {
+			return nil, p.errorf(t, "positional argument follows keyword argument")
+		}
+		v, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		a.value = v
+		args = append(args, a)
+		if !p.accept(",") {
+			break
+		}
+	}
+	_, err := p.expect(")")
+	return args, err
+}
+
+// subscript parses an index or slice after "[".
+func (p *parser) subscript(x expr, line int) (expr, error) {
+	var parts [3]expr
+	n := 0
+	for {
+		if !p.is(":") && !p.is("]") {
+			e, err := p.test()
+			if err != nil {
+				return nil, err
+			}
+			parts[n] = e
+		}
+		if n == 2 || !p.accept(":") {
+			break
+		}
+		n++
+	}
+	if _, err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		if parts[0] == nil {
+			return nil, fmt.Errorf("line %d: empty index", line)
+		}
+		return &indexExpr{line: line, x: x, index: parts[0]}, nil
+	}
+	return &sliceExpr{line: line, x: x, lo: parts[0], hi: parts[1], step: parts[2]}, nil
+}
+
+func (p *parser) operand() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokInt, tokFloat, tokString:
+		v := t.val
+		// Adjacent string literals are joined.
+		for t.kind == tokString && p.peek().kind == tokString {
+			v = v.(string) + p.next().val.(string)
+		}
+		return &literalExpr{line: t.line, value: v}, nil
+	case tokName:
+		switch t.text {
+		case "None":
+			return &literalExpr{line: t.line, value: None}, nil
+		case "True":
+			return &literalExpr{line: t.line, value: true}, nil
+		case "False":
+			return &literalExpr{line: t.line, value: false}, nil
+		}
+		if keywords[t.text] {
+			return nil, p.errorf(t, "unexpected %q", t.text)
+		}
+		return &identExpr{line: t.line, name: t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			if p.accept(")") {
+				return &tupleExpr{line: t.line}, nil
+			}
+			x, err := p.exprList()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		case "[":
+			return p.listOrComp(t.line)
+		case "{":
+			return p.dictOrComp(t.line)
+		}
+	}
+	return nil, p.errorf(t, "unexpected %s", t)
+}
+
+func (p *parser) listOrComp(line int) (expr, error) {
+	if p.accept("]") {
+		return &listExpr{line: line}, nil
+	}
+	first, err := p.test()
+	if err != nil {
+		return nil, err
+	}
+	if p.is("for") {
+		clauses, err := p.compClauses()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.expect("]")
+		return &compExpr{line: line, value: first, clauses: clauses}, err
+	}
+	elems := []expr{first}
+	for p.accept(",") && !p.is("]") {
+		e, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, e)
+	}
+	_, err = p.expect("]")
+	return &listExpr{line: line, elems: elems}, err
+}
+
+func (p *parser) dictOrComp(line int) (expr, error) {
+	d := &dictExpr{line: line}
+	if p.accept("}") {
+		return d, nil
+	}
+	k, err := p.test()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	v, err := p.test()
+	if err != nil {
+		return nil, err
+	}
+	if p.is("for") {
+		clauses, err := p.compClauses()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.expect("}")
+		return &compExpr{line: line, key: k, value: v, clauses: clauses}, err
+	}
+	d.keys, d.values = append(d.keys, k), append(d.values, v)
+	for p.accept(",") && !p.is("}") {
+		k, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.test()
+		if err != nil {
+			return nil, err
+		}
+		d.keys, d.values = append(d.keys, k), append(d.values, v)
+	}
+	_, err = p.expect("}")
+	return d, err
+}
+
+// compClauses parses the for and if clauses of a comprehension.
+func (p *parser) compClauses() ([]compClause, error) {
+	var clauses []compClause
+	for {
+		switch {
+		case p.accept("for"):
+			vars, err := p.targets()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect("in"); err != nil {
+				return nil, err
+			}
+			iter, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, compClause{vars: vars, iter: iter})
+		case p.accept("if"):
+			cond, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			clauses = append(clauses, compClause{cond: cond})
+		default:
+			return clauses, nil
+		}
+	}
+}
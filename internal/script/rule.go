@@ -0,0 +1,383 @@
+// Package script runs offender rules written in a small, sandboxed dialect
+// of Starlark: Python-like expressions, def, if and for over finite values,
+// and no while, recursion, I/O, clock or randomness. A script is loaded
+// once; its globals are then frozen, so every commit is judged by the same
+// pure function and reports are deterministic.
+//
+// A rule script defines check(commit), which returns None or False to pass
+// the commit, True to flag it with the script's description, a reason
+// string, a (reason, score) tuple, or a list of reasons and tuples. The
+// optional globals description, category and weight describe the rule;
+// the file name, without .star, is its ID.
+package script
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"roastgit/internal/analyze"
+)
+
+// Ext is the file extension of rule scripts.
+const Ext = ".star"
+
+// DefaultWeight is the score of a finding when the script sets no weight.
+const DefaultWeight = 5
+
+// Rule is an analyze.Rule backed by a script.
+type Rule struct {
+	id, path    string
+	description string
+	category    string
+	weight      int
+	check       *function
+	files       bool
+
+	mu  sync.Mutex
+	err error
+}
+
+// Load compiles the rule script src read from path.
+func Load(path string, src []byte) (*Rule, error) {
+	r := &Rule{
+		id:       strings.TrimSuffix(filepath.Base(path), Ext),
+		path:     path,
+		category: analyze.CategoryCustom,
+		weight:   DefaultWeight,
+	}
+	r.description = r.id
+	if err := r.load(string(src)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// LoadDir loads every *.star file in dir, in name order. A missing dir
+// holds no rules.
+func LoadDir(dir string) ([]*Rule, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == Ext {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	rules := make([]*Rule, 0, len(names))
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		r, err := Load(path, src)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (r *Rule) load(src string) error {
+	stmts, err := parse(src)
+	if err != nil {
+		return err
+	}
+	module := &env{vars: map[string]Value{}}
+	th := &thread{}
+	f, _, err := th.execBlock(module, stmts)
+	if err != nil {
+		return err
+	}
+	if f != flowNormal {
+		return th.errorf("return, break or continue outside a function or loop")
+	}
+	for _, v := range module.vars {
+		freeze(v)
+	}
+
+	check, ok := module.vars["check"].(*function)
+	if !ok {
+		return fmt.Errorf("needs a check(commit) function")
+	}
+	if len(check.params) != 1 {
+		return fmt.Errorf("check must take one parameter, the commit")
+	}
+	r.check = check
+	r.files = usesFiles(stmts)
+	if v, ok := module.vars["description"]; ok {
+		if r.description, ok = v.(string); !ok || r.description == "" {
+			return fmt.Errorf("description must be a non-empty string")
+		}
+	}
+	if v, ok := module.vars["category"]; ok {
+		if r.category, ok = v.(string); !ok || r.category == "" {
+			return fmt.Errorf("category must be a non-empty string")
+		}
+	}
+	if v, ok := module.vars["weight"]; ok {
+		w, ok := v.(int64)
+		if !ok || w < 1 || w > 100 {
+			return fmt.Errorf("weight must be an int between 1 and 100")
+		}
+		r.weight = int(w)
+	}
+	return nil
+}
+
+func (r *Rule) ID() string          { return r.id }
+func (r *Rule) Description() string { return r.description }
+func (r *Rule) Category() string    { return r.category }
+func (r *Rule) Weight() int         { return r.weight }
+
+// Path is the file the rule was loaded from.
+func (r *Rule) Path() string { return r.path }
+
+// NeedsFiles reports whether the script reads commit.files.
+func (r *Rule) NeedsFiles() bool { return r.files }
+
+// Err returns the first error check raised. A failing rule flags nothing
+// after it.
+func (r *Rule) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Evaluate calls check on one commit, with a fresh step budget.
+func (r *Rule) Evaluate(c *analyze.CommitFacts) []analyze.Finding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return nil
+	}
+	th := &thread{}
+	v, err := th.call(r.check, []Value{commitValue(c)}, nil)
+	var findings []analyze.Finding
+	if err == nil {
+		findings, err = r.findings(v)
+	}
+	if err != nil {
+		r.err = fmt.Errorf("%s: commit %.7s: %w", r.path, c.Commit.SHA, err)
+		return nil
+	}
+	return findings
+}
+
+// findings converts what check returned.
+func (r *Rule) findings(v Value) ([]analyze.Finding, error) {
+	switch v := v.(type) {
+	case NoneType:
+		return nil, nil
+	case bool:
+		if !v {
+			return nil, nil
+		}
+		return []analyze.Finding{{Reason: r.description, Weight: r.weight}}, nil
+	case *List:
+		var out []analyze.Finding
+		for _, e := range v.elems {
+			f, err := r.finding(e)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, f)
+		}
+		return out, nil
+	}
+	f, err := r.finding(v)
+	if err != nil {
+		return nil, err
+	}
+	return []analyze.Finding{f}, nil
+}
+
+func (r *Rule) finding(v Value) (analyze.Finding, error) {
+	f := analyze.Finding{Weight: r.weight}
+	switch v := v.(type) {
+	case string:
+		f.Reason = v
+	case Tuple:
+		if len(v) != 2 {
+			return f, fmt.Errorf("check returned a tuple of %d values, want (reason, score)", len(v))
+		}
+		reason, ok := v[0].(string)
+		if !ok {
+			return f, fmt.Errorf("check returned a %s reason, want a string", typeName(v[0]))
+		}
+		score, ok := v[1].(int64)
+		if !ok || score < 1 || score > 100 {
+			return f, fmt.Errorf("check returned score %s, want an int between 1 and 100", repr(v[1]))
+		}
+		f.Reason, f.Weight = reason, int(score)
+	default:
+		return f, fmt.Errorf("check returned %s, want None, a bool, a reason, a (reason, score) tuple or a list of them", typeName(v))
+	}
+	if strings.TrimSpace(f.Reason) == "" {
+		return f, fmt.Errorf("check returned an empty reason")
+	}
+	return f, nil
+}
+
+// commitValue is the commit struct scripts receive.
+func commitValue(c *analyze.CommitFacts) *Struct {
+	parents := make(Tuple, len(c.Commit.Parents))
+	for i, p := range c.Commit.Parents {
+		parents[i] = p
+	}
+	var size Value = None
+	if s := c.Commit.Size; s != nil {
+		size = NewStruct("size",
+			Field{"files", int64(s.Files)},
+			Field{"added", int64(s.Added)},
+			Field{"deleted", int64(s.Deleted)},
+			Field{"lines", int64(s.Added + s.Deleted)},
+			Field{"binary_files", int64(s.BinaryFiles)},
+			Field{"submodules", int64(s.Submodules)},
+		)
+	}
+	var files Value = None
+	if c.Files != nil {
+		t := make(Tuple, len(c.Files))
+		for i, f := range c.Files {
+			t[i] = NewStruct("file",
+				Field{"path", f.Path},
+				Field{"added", int64(f.Added)},
+				Field{"deleted", int64(f.Deleted)},
+				Field{"binary", f.Binary},
+			)
+		}
+		files = t
+	}
+	return NewStruct("commit",
+		Field{"sha", c.Commit.SHA},
+		Field{"author", c.Commit.AuthorName},
+		Field{"email", c.Commit.AuthorEmail},
+		Field{"date", c.Local.Format(time.RFC3339)},
+		Field{"hour", int64(c.Local.Hour())},
+		Field{"weekday", c.Local.Weekday().String()},
+		Field{"subject", c.Commit.Subject},
+		Field{"body", c.Commit.Body},
+		Field{"parents", parents},
+		Field{"merge", len(c.Commit.Parents) > 1},
+		Field{"bot", c.Bot},
+		Field{"size", size},
+		Field{"files", files},
+	)
+}
+
+// usesFiles reports whether a script mentions the files field, as an
+// attribute or a getattr name, so per-file stats are only loaded for
+// scripts that read them.
+func usesFiles(stmts []stmt) bool {
+	found := false
+	walk(stmts, func(x expr) {
+		switch x := x.(type) {
+		case *attrExpr:
+			found = found || x.name == "files"
+		case *literalExpr:
+			found = found || x.value == "files"
+		}
+	})
+	return found
+}
+
+// walk calls fn for every expression in stmts.
+func walk(stmts []stmt, fn func(expr)) {
+	var visit func(x expr)
+	visit = func(x expr) {
+		if x == nil {
+			return
+		}
+		fn(x)
+		switch x := x.(type) {
+		case *listExpr:
+			for _, e := range x.elems {
+				visit(e)
+			}
+		case *tupleExpr:
+			for _, e := range x.elems {
+				visit(e)
+			}
+		case *dictExpr:
+			for i := range x.keys {
+				visit(x.keys[i])
+				visit(x.values[i])
+			}
+		case *unaryExpr:
+			visit(x.x)
+		case *binaryExpr:
+			visit(x.x)
+			visit(x.y)
+		case *condExpr:
+			visit(x.cond)
+			visit(x.then)
+			visit(x.else_)
+		case *callExpr:
+			visit(x.fn)
+			for _, a := range x.args {
+				visit(a.value)
+			}
+		case *attrExpr:
+			visit(x.x)
+		case *indexExpr:
+			visit(x.x)
+			visit(x.index)
+		case *sliceExpr:
+			visit(x.x)
+			visit(x.lo)
+			visit(x.hi)
+			visit(x.step)
+		case *compExpr:
+			visit(x.key)
+			visit(x.value)
+			for _, c := range x.clauses {
+				visit(c.vars)
+				visit(c.iter)
+				visit(c.cond)
+			}
+		}
+	}
+	var block func(stmts []stmt)
+	block = func(stmts []stmt) {
+		for _, s := range stmts {
+			switch s := s.(type) {
+			case *exprStmt:
+				visit(s.x)
+			case *assignStmt:
+				visit(s.target)
+				visit(s.value)
+			case *ifStmt:
+				visit(s.cond)
+				block(s.then)
+				block(s.else_)
+			case *forStmt:
+				visit(s.vars)
+				visit(s.iter)
+				block(s.body)
+			case *defStmt:
+				for _, p := range s.params {
+					visit(p.def)
+				}
+				block(s.body)
+			case *returnStmt:
+				visit(s.value)
+			}
+		}
+	}
+	block(stmts)
+}
@@ -0,0 +1,179 @@
+package script
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/model"
+)
+
+func facts(subject string) *analyze.CommitFacts {
+	return &analyze.CommitFacts{
+		Commit: model.Commit{SHA: "0123456789abcdef", AuthorName: "Jane", Subject: subject, Size: &model.CommitSize{Files: 2, Added: 30, Deleted: 5}},
+		Files:  []model.FileStat{{Path: "vendor/lib.go", Added: 20}, {Path: "main.go", Added: 10, Deleted: 5}},
+		Local:  time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC),
+	}
+}
+
+func TestRuleFindings(t *testing.T) {
+	src := `
+description = "touches vendor/"
+weight = 4
+VENDOR = ["vendor/", "third_party/"]
+
+def vendored(files):
+    return [f.path for f in files if f.path.startswith(tuple(VENDOR))]
+
+def check(commit):
+    if commit.files == None:
+        return None
+    paths = vendored(commit.files)
+    if not paths:
+        return False
+    found = [("edits %s" % p, 6) for p in paths]
+    if commit.hour >= 23 and commit.weekday == "Friday":
+        found.append("late on a Friday")
+    return found
+`
+	r, err := Load(filepath.Join("rules", "vendored.star"), []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID() != "vendored" || r.Description() != "touches vendor/" || r.Category() != analyze.CategoryCustom || r.Weight() != 4 || !r.NeedsFiles() {
+		t.Fatalf("unexpected rule: %s %q %s %d files=%v", r.ID(), r.Description(), r.Category(), r.Weight(), r.NeedsFiles())
+	}
+	got := r.Evaluate(facts("Bump lib"))
+	want := []analyze.Finding{{Reason: "edits vendor/lib.go", Weight: 6}, {Reason: "late on a Friday", Weight: 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("findings = %+v, want %+v", got, want)
+	}
+	// The same commit is judged the same way every time.
+	if again := r.Evaluate(facts("Bump lib")); !reflect.DeepEqual(again, want) {
+		t.Fatalf("second run = %+v, want %+v", again, want)
+	}
+	c := facts("Bump lib")
+	c.Files = nil
+	if got := r.Evaluate(c); got != nil || r.Err() != nil {
+		t.Fatalf("expected no findings without files, got %+v, %v", got, r.Err())
+	}
+}
+
+func TestRuleDefaults(t *testing.T) {
+	r, err := Load("rules/shouting.star", []byte("def check(commit):\n    return commit.subject.isupper()\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Description() != "shouting" || r.Weight() != DefaultWeight || r.NeedsFiles() {
+		t.Fatalf("unexpected defaults: %q %d files=%v", r.Description(), r.Weight(), r.NeedsFiles())
+	}
+	got := r.Evaluate(facts("FIX EVERYTHING"))
+	if len(got) != 1 || got[0] != (analyze.Finding{Reason: "shouting", Weight: DefaultWeight}) {
+		t.Fatalf("findings = %+v", got)
+	}
+	if got := r.Evaluate(facts("Fix the parser")); got != nil {
+		t.Fatalf("expected a pass, got %+v", got)
+	}
+}
+
+func TestRuleRuntimeError(t *testing.T) {
+	src := "SEEN = []\n\ndef check(commit):\n    SEEN.append(commit.sha)\n"
+	r, err := Load("rules/stateful.star", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Evaluate(facts("Add a cache")); got != nil {
+		t.Fatalf("expected no findings from a failing rule, got %+v", got)
+	}
+	want := "rules/stateful.star: commit 0123456: line 4: cannot modify a frozen list"
+	if r.Err() == nil || r.Err().Error() != want {
+		t.Fatalf("Err() = %v, want %q", r.Err(), want)
+	}
+
+	r, err = Load("rules/score.star", []byte("def check(commit):\n    return (\"too much\", 101)\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Evaluate(facts("Add a cache"))
+	if r.Err() == nil || !strings.Contains(r.Err().Error(), "score 101, want an int between 1 and 100") {
+		t.Fatalf("Err() = %v, want a score error", r.Err())
+	}
+}
+
+func TestRuleAllocationBudget(t *testing.T) {
+	src := "def check(commit):\n    big = \"x\" * 1000000\n    l = [big + str(i) for i in range(1500)]\n    return len(l) > 0\n"
+	r, err := Load("rules/hog.star", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Evaluate(facts("Add a cache")); got != nil {
+		t.Fatalf("expected no findings from a rule over budget, got %+v", got)
+	}
+	want := "rules/hog.star: commit 0123456: line 3: script allocated more than 64 MiB"
+	if r.Err() == nil || r.Err().Error() != want {
+		t.Fatalf("Err() = %v, want %q", r.Err(), want)
+	}
+
+	// The budget is per call: a rule well under it runs on every commit.
+	r, err = Load("rules/ok.star", []byte("def check(commit):\n    return len(\"x\" * 1000000 + commit.subject) < 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		r.Evaluate(facts("Add a cache"))
+	}
+	if r.Err() != nil {
+		t.Fatal(r.Err())
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"x = 1\n", "rules/bad.star: needs a check(commit) function"},
+		{"def check():\n    pass\n", "check must take one parameter"},
+		{"weight = 0\ndef check(c):\n    pass\n", "weight must be an int between 1 and 100"},
+		{"description = \"\"\ndef check(c):\n    pass\n", "description must be a non-empty string"},
+		{"def check(c)\n    pass\n", "rules/bad.star: line 1: expected \":\""},
+		{"x = nope\n", "rules/bad.star: line 1: undefined: nope"},
+	} {
+		_, err := Load("rules/bad.star", []byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: got error %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, src := range map[string]string{
+		"wip.star":   "def check(c):\n    return \"wip\" in c.subject.lower()\n",
+		"big.star":   "def check(c):\n    return c.size != None and c.size.lines > 1000\n",
+		"README.md":  "not a rule",
+		"notes.star": "", // no check function
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := LoadDir(dir); err == nil || !strings.Contains(err.Error(), "notes.star: needs a check(commit) function") {
+		t.Fatalf("expected notes.star to fail, got %v", err)
+	}
+	os.Remove(filepath.Join(dir, "notes.star"))
+	rules, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].ID() != "big" || rules[1].ID() != "wip" {
+		t.Fatalf("expected big and wip in name order, got %d rules", len(rules))
+	}
+	if rules, err := LoadDir(filepath.Join(dir, "missing")); rules != nil || err != nil {
+		t.Fatalf("expected no rules from a missing dir, got %v, %v", rules, err)
+	}
+}
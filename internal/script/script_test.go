@@ -0,0 +1,82 @@
+package script
+
+import (
+	"strings"
+	"testing"
+)
+
+// run executes src as a module and returns the value of its result global.
+func run(src string) (Value, error) {
+	stmts, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	module := &env{vars: map[string]Value{}}
+	if _, _, err := (&thread{}).execBlock(module, stmts); err != nil {
+		return nil, err
+	}
+	return module.vars["result"], nil
+}
+
+func TestEval(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{`result = 1 + 2 * 3 - 4 // 3`, `6`},
+		{`result = (-7 // 2, -7 % 3, 7 / 2, 3 if False else 1.5 * 2)`, `(-4, 2, 3.5, 3.0)`},
+		{`result = "fix: %s in %r (%d%%)" % ("typo", "README", 3)`, `"fix: typo in \"README\" (3%)"`},
+		{`result = [w.upper() for w in "a bb ccc".split() if len(w) > 1]`, `["BB", "CCC"]`},
+		{`result = {k: v for k, v in zip(["a", "b"], range(2))}`, `{"a": 0, "b": 1}`},
+		{`result = "Refactor"[1:4] + "abc"[::-1] + "xyz"[-1]`, `"efacbaz"`},
+		{`result = sorted(["bb", "a", "ccc"], reverse=True)`, `["ccc", "bb", "a"]`},
+		{"def size(s):\n    return len(s)\nresult = sorted([\"bb\", \"a\", \"ccc\"], key=size)", `["a", "bb", "ccc"]`},
+		{`result = (1 in [1, 2], "x" not in "abc", 1 == 1.0, (1, 2) < (1, 3), not [])`, `(True, True, True, True, True)`},
+		{`result = matches(r"^[A-Z]+-\d+", "OPS-12 fix") and not matches("\d", "none")`, `True`},
+		{"x = []\nfor i in range(10):\n    if i % 2:\n        continue\n    if i > 6:\n        break\n    x += [i]\nresult = x", `[0, 2, 4, 6]`},
+		{"def f(a, b=2, c=3):\n    return a * 100 + b * 10 + c\nresult = f(1, c=5)", `125`},
+		{"d = {}\nd[\"n\"] = d.get(\"n\", 0) + 1\nresult = d.items()", `[("n", 1)]`},
+		{`result = min([3, 1, 2]), max(4, 9), any([0, ""]), all([]), abs(-2)`, `(1, 9, False, True, 2)`},
+		{`result = "  Fix it  ".strip().lower().replace("it", "that").startswith(("fix", "add"))`, `True`},
+		{"result = \"\"\"first\nsecond\n\"\"\".splitlines()", `["first", "second"]`},
+		{`result = [str(1.0), repr("q"), int("42"), type(None), bool("x")]`, `["1.0", "\"q\"", 42, "NoneType", True]`},
+	} {
+		got, err := run(tc.src)
+		if err != nil {
+			t.Errorf("%s: %v", tc.src, err)
+			continue
+		}
+		if repr(got) != tc.want {
+			t.Errorf("%s = %s, want %s", tc.src, repr(got), tc.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src  string
+		want string
+	}{
+		{"while True:\n    pass", `line 1: "while" is not supported`},
+		{`load("x.star", "y")`, `line 1: "load" is not supported`},
+		{"def f(n):\n    return f(n)\nresult = f(1)", `line 2: f: recursion is not allowed`},
+		{"x = 0\nfor i in range(100000):\n    for j in range(100):\n        x += 1", `exceeded 1000000 steps`},
+		{`result = range(1000000)`, `more than 100000 elements`},
+		{`result = "ab" * 10000000`, `result too long`},
+		{"x = [1]\nfor v in x:\n    x.append(v)", `cannot modify a list while iterating over it`},
+		{`result = undefined_name`, `line 1: undefined: undefined_name`},
+		{"\n\nresult = 1 / 0", `line 3: division by zero`},
+		{`for c in "abc": pass`, `a string is not iterable`},
+		{`result = {[]: 1}`, `unhashable type: list`},
+		{`result = 1 < "a"`, `cannot compare int with string`},
+		{`result = fail("no", 1)`, `fail: no 1`},
+		{"if True:\n\tpass", `use spaces, not tabs`},
+		{`result = 1 < 2 < 3`, `line 1:`},
+		{`result = "unterminated`, `unterminated string`},
+	} {
+		_, err := run(tc.src)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%q: got error %v, want %q", tc.src, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,533 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value is a script value: NoneType, bool, int64, float64, string, Tuple,
+// *List, *Dict, *Struct, *function or *builtin.
+type Value = any
+
+// NoneType is the type of None.
+type NoneType struct{}
+
+// None is the script's None.
+var None = NoneType{}
+
+// Tuple is an immutable sequence.
+type Tuple []Value
+
+// List is a mutable sequence. A frozen list, like every global once the
+// module has loaded, cannot change.
+type List struct {
+	elems     []Value
+	frozen    bool
+	iterating int
+}
+
+// NewList returns a list holding elems.
+func NewList(elems []Value) *List { return &List{elems: elems} }
+
+func (l *List) checkMutable() error {
+	switch {
+	case l.frozen:
+		return fmt.Errorf("cannot modify a frozen list")
+	case l.iterating > 0:
+		return fmt.Errorf("cannot modify a list while iterating over it")
+	}
+	return nil
+}
+
+// Dict is a mapping that keeps insertion order.
+type Dict struct {
+	keys      []Value
+	vals      []Value
+	index     map[any]int
+	frozen    bool
+	iterating int
+}
+
+// NewDict returns an empty dict.
+func NewDict() *Dict { return &Dict{index: map[any]int{}} }
+
+func (d *Dict) checkMutable() error {
+	switch {
+	case d.frozen:
+		return fmt.Errorf("cannot modify a frozen dict")
+	case d.iterating > 0:
+		return fmt.Errorf("cannot modify a dict while iterating over it")
+	}
+	return nil
+}
+
+// Get returns the value stored under k.
+func (d *Dict) Get(k Value) (Value, bool, error) {
+	h, err := hashKey(k)
+	if err != nil {
+		return nil, false, err
+	}
+	i, ok := d.index[h]
+	if !ok {
+		return nil, false, nil
+	}
+	return d.vals[i], true, nil
+}
+
+// Set stores v under k.
+func (d *Dict) Set(k, v Value) error {
+	if err := d.checkMutable(); err != nil {
+		return err
+	}
+	h, err := hashKey(k)
+	if err != nil {
+		return err
+	}
+	if i, ok := d.index[h]; ok {
+		d.vals[i] = v
+		return nil
+	}
+	d.index[h] = len(d.keys)
+	d.keys = append(d.keys, k)
+	d.vals = append(d.vals, v)
+	return nil
+}
+
+func (d *Dict) delete(k Value) (Value, bool, error) {
+	if err := d.checkMutable(); err != nil {
+		return nil, false, err
+	}
+	h, err := hashKey(k)
+	if err != nil {
+		return nil, false, err
+	}
+	i, ok := d.index[h]
+	if !ok {
+		return nil, false, nil
+	}
+	v := d.vals[i]
+	d.keys = append(d.keys[:i], d.keys[i+1:]...)
+	d.vals = append(d.vals[:i], d.vals[i+1:]...)
+	delete(d.index, h)
+	for key, j := range d.index {
+		if j > i {
+			d.index[key] = j - 1
+		}
+	}
+	return v, true, nil
+}
+
+// hashKey maps a hashable value to a comparable Go key. Ints and floats
+// with the same value are the same key.
+func hashKey(v Value) (any, error) {
+	switch v := v.(type) {
+	case NoneType, bool, string:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case Tuple:
+		var b strings.Builder
+		for _, e := range v {
+			k, err := hashKey(e)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&b, "%T:%v\x00", k, k)
+		}
+		return "tuple\x00" + b.String(), nil
+	}
+	return nil, fmt.Errorf("unhashable type: %s", typeName(v))
+}
+
+// Struct is a read-only record with named fields, like the commit a rule
+// receives.
+type Struct struct {
+	name   string
+	names  []string
+	fields map[string]Value
+}
+
+// NewStruct returns a struct of the given type name; fields are shown in
+// the order given.
+func NewStruct(name string, fields ...Field) *Struct {
+	s := &Struct{name: name, fields: make(map[string]Value, len(fields))}
+	for _, f := range fields {
+		s.names = append(s.names, f.Name)
+		s.fields[f.Name] = f.Value
+	}
+	return s
+}
+
+// Field is one named struct field.
+type Field struct {
+	Name  string
+	Value Value
+}
+
+// function is a function defined with def.
+type function struct {
+	name     string
+	params   []param
+	defaults []Value
+	body     []stmt
+	env      *env
+}
+
+// builtin is a function implemented in Go, possibly bound to a receiver.
+type builtin struct {
+	name string
+	recv Value
+	fn   func(th *thread, b *builtin, args []Value, kwargs []kwarg) (Value, error)
+}
+
+type kwarg struct {
+	name  string
+	value Value
+}
+
+func typeName(v Value) string {
+	switch v := v.(type) {
+	case NoneType:
+		return "NoneType"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case Tuple:
+		return "tuple"
+	case *List:
+		return "list"
+	case *Dict:
+		return "dict"
+	case *Struct:
+		return v.name
+	case *function, *builtin:
+		return "function"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func truth(v Value) bool {
+	switch v := v.(type) {
+	case NoneType:
+		return false
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case Tuple:
+		return len(v) > 0
+	case *List:
+		return len(v.elems) > 0
+	case *Dict:
+		return len(v.keys) > 0
+	}
+	return true
+}
+
+// str renders v as str() does: strings as they are, the rest as repr.
+func str(v Value) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return repr(v)
+}
+
+func repr(v Value) string {
+	var b strings.Builder
+	writeRepr(&b, v, 0)
+	return b.String()
+}
+
+func writeRepr(b *strings.Builder, v Value, depth int) {
+	if depth > 32 {
+		b.WriteString("...")
+		return
+	}
+	switch v := v.(type) {
+	case NoneType:
+		b.WriteString("None")
+	case bool:
+		if v {
+			b.WriteString("True")
+		} else {
+			b.WriteString("False")
+		}
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		b.WriteString(formatFloat(v))
+	case string:
+		b.WriteString(strconv.Quote(v))
+	case Tuple:
+		b.WriteByte('(')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeRepr(b, e, depth+1)
+		}
+		if len(v) == 1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte(')')
+	case *List:
+		b.WriteByte('[')
+		for i, e := range v.elems {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeRepr(b, e, depth+1)
+		}
+		b.WriteByte(']')
+	case *Dict:
+		b.WriteByte('{')
+		for i, k := range v.keys {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			writeRepr(b, k, depth+1)
+			b.WriteString(": ")
+			writeRepr(b, v.vals[i], depth+1)
+		}
+		b.WriteByte('}')
+	case *Struct:
+		b.WriteString(v.name)
+		b.WriteByte('(')
+		for i, name := range v.names {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(name)
+			b.WriteByte('=')
+			writeRepr(b, v.fields[name], depth+1)
+		}
+		b.WriteByte(')')
+	case *function:
+		fmt.Fprintf(b, "<function %s>", v.name)
+	case *builtin:
+		fmt.Fprintf(b, "<built-in function %s>", v.name)
+	default:
+		fmt.Fprintf(b, "%v", v)
+	}
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+inf"
+	case math.IsInf(f, -1):
+		return "-inf"
+	case math.IsNaN(f):
+		return "nan"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// equal reports deep equality; ints and floats compare by value.
+func equal(x, y Value) bool {
+	switch x := x.(type) {
+	case int64:
+		switch y := y.(type) {
+		case int64:
+			return x == y
+		case float64:
+			return float64(x) == y
+		}
+		return false
+	case float64:
+		switch y := y.(type) {
+		case int64:
+			return x == float64(y)
+		case float64:
+			return x == y
+		}
+		return false
+	case Tuple:
+		y, ok := y.(Tuple)
+		return ok && equalSeq(x, y)
+	case *List:
+		y, ok := y.(*List)
+		return ok && equalSeq(x.elems, y.elems)
+	case *Dict:
+		y, ok := y.(*Dict)
+		if !ok || len(x.keys) != len(y.keys) {
+			return false
+		}
+		for i, k := range x.keys {
+			v, found, err := y.Get(k)
+			if err != nil || !found || !equal(x.vals[i], v) {
+				return false
+			}
+		}
+		return true
+	case *Struct:
+		return x == y
+	case *function:
+		return x == y
+	case *builtin:
+		yb, ok := y.(*builtin)
+		return ok && x.name == yb.name && x.recv == yb.recv
+	}
+	return x == y
+}
+
+func equalSeq(x, y []Value) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !equal(x[i], y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// compare orders two numbers, strings or sequences of them.
+func compare(x, y Value) (int, error) {
+	if xf, ok := number(x); ok {
+		if yf, ok := number(y); ok {
+			if xi, ok := x.(int64); ok {
+				if yi, ok := y.(int64); ok {
+					return cmpInt(xi, yi), nil
+				}
+			}
+			switch {
+			case xf < yf:
+				return -1, nil
+			case xf > yf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	switch x := x.(type) {
+	case string:
+		if y, ok := y.(string); ok {
+			return strings.Compare(x, y), nil
+		}
+	case bool:
+		if y, ok := y.(bool); ok {
+			return cmpInt(b2i(x), b2i(y)), nil
+		}
+	case Tuple:
+		if y, ok := y.(Tuple); ok {
+			return compareSeq(x, y)
+		}
+	case *List:
+		if y, ok := y.(*List); ok {
+			return compareSeq(x.elems, y.elems)
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", typeName(x), typeName(y))
+}
+
+func compareSeq(x, y []Value) (int, error) {
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if equal(x[i], y[i]) {
+			continue
+		}
+		return compare(x[i], y[i])
+	}
+	return cmpInt(int64(len(x)), int64(len(y))), nil
+}
+
+func cmpInt(x, y int64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+func b2i(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// number returns the value of an int or float.
+func number(v Value) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// freeze makes v and everything it holds immutable.
+func freeze(v Value) {
+	switch v := v.(type) {
+	case *List:
+		if v.frozen {
+			return
+		}
+		v.frozen = true
+		for _, e := range v.elems {
+			freeze(e)
+		}
+	case *Dict:
+		if v.frozen {
+			return
+		}
+		v.frozen = true
+		for _, e := range v.vals {
+			freeze(e)
+		}
+	case Tuple:
+		for _, e := range v {
+			freeze(e)
+		}
+	}
+}
+
+// sortValues sorts vs in place, reporting values that cannot be ordered.
+func sortValues(vs []Value, keys []Value, reverse bool) error {
+	var err error
+	idx := make([]int, len(vs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		c, e := compare(keys[idx[i]], keys[idx[j]])
+		if e != nil && err == nil {
+			err = e
+		}
+		if reverse {
+			return c > 0
+		}
+		return c < 0
+	})
+	if err != nil {
+		return err
+	}
+	sorted := make([]Value, len(vs))
+	for i, j := range idx {
+		sorted[i] = vs[j]
+	}
+	copy(vs, sorted)
+	return nil
+}
@@ -0,0 +1,372 @@
+// Package server serves reports over HTTP: a JSON API with the same shape
+// as --json output and a small dashboard page that reads it.
+package server
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"roastgit/internal/aggregate"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/render"
+)
+
+//go:embed dashboard.html
+var dashboard []byte
+
+// maxCached bounds the rendered reports kept in memory.
+const maxCached = 128
+
+// datedTTL bounds how long the report of a query with since or until is
+// kept. Git reads those dates relative to now ("2 weeks ago"; a bare date
+// keeps the current time of day), so the answer drifts while HEAD stays put.
+const datedTTL = time.Minute
+
+// Repo is a repository the server answers for. Name is what the repo query
+// parameter selects.
+type Repo struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// Options configures a server.
+type Options struct {
+	Repos []Repo
+	// Base holds the defaults each query starts from.
+	Base model.Config
+	// Build runs the analysis of one repository.
+	Build func(ctx context.Context, repo string, cfg model.Config) (model.Report, error)
+	// Head returns the current HEAD of a repository. Rendered reports are
+	// kept until it moves; when it fails the report is not kept.
+	Head func(ctx context.Context, repo string) (string, error)
+	// Validate rejects a query's config before it is built.
+	Validate func(cfg model.Config) error
+	// Timeout bounds each analysis; the report is partial when it expires.
+	// 0 means no limit.
+	Timeout time.Duration
+}
+
+// Server answers /api/repos, /api/report, /api/org and the dashboard.
+// Analyses of the same repository run one at a time, so concurrent queries
+// share the commit cache instead of racing to rewrite it.
+type Server struct {
+	opts  Options
+	mux   *http.ServeMux
+	locks map[string]*sync.Mutex
+
+	mu     sync.Mutex
+	cached map[string]cachedReport
+	order  []string
+	now    func() time.Time
+}
+
+// cachedReport is a rendered report; a zero expires keeps it until evicted.
+type cachedReport struct {
+	body    []byte
+	expires time.Time
+}
+
+// New returns a server for opts.Repos, which must not be empty. Repository
+// names are made unique by numbering repeats.
+func New(opts Options) *Server {
+	seen := map[string]int{}
+	repos := make([]Repo, len(opts.Repos))
+	for i, r := range opts.Repos {
+		seen[r.Name]++
+		if n := seen[r.Name]; n > 1 {
+			r.Name = fmt.Sprintf("%s-%d", r.Name, n)
+		}
+		repos[i] = r
+	}
+	opts.Repos = repos
+	s := &Server{opts: opts, mux: http.NewServeMux(), locks: map[string]*sync.Mutex{},
+		cached: map[string]cachedReport{}, now: time.Now}
+	for _, r := range repos {
+		s.locks[r.Path] = &sync.Mutex{}
+	}
+	s.mux.HandleFunc("GET /{$}", s.handleDashboard)
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /api/repos", s.handleRepos)
+	s.mux.HandleFunc("GET /api/report", s.handleReport)
+	s.mux.HandleFunc("GET /api/org", s.handleOrg)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(dashboard)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleRepos(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.opts.Repos)
+}
+
+// handleReport answers with the report of one repository, selected with
+// ?repo=name unless the server has only one.
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	repo, ok := s.repo(r.URL.Query().Get("repo"))
+	if !ok {
+		if len(s.opts.Repos) > 1 && r.URL.Query().Get("repo") == "" {
+			writeError(w, http.StatusBadRequest, "repo is required; see /api/repos")
+			return
+		}
+		writeError(w, http.StatusNotFound, fmt.Sprintf("unknown repo %q", r.URL.Query().Get("repo")))
+		return
+	}
+	cfg, err := s.queryConfig(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx, cancel := s.context(r.Context())
+	defer cancel()
+	body, err := s.report(ctx, repo, cfg, r.URL.Query())
+	if err != nil {
+		writeError(w, errorStatus(err), err.Error())
+		return
+	}
+	writeBody(w, http.StatusOK, body)
+}
+
+// handleOrg answers with the combined report of every repository.
+func (s *Server) handleOrg(w http.ResponseWriter, r *http.Request) {
+	cfg, err := s.queryConfig(r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx, cancel := s.context(r.Context())
+	defer cancel()
+	reports := []model.Report{}
+	failures := []model.RepoFailure{}
+	for _, repo := range s.opts.Repos {
+		body, err := s.report(ctx, repo, cfg, r.URL.Query())
+		if err != nil {
+			failures = append(failures, model.RepoFailure{Path: repo.Path, Error: err.Error()})
+			continue
+		}
+		var report model.Report
+		if err := json.Unmarshal(body, &report); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		reports = append(reports, report)
+	}
+	out, err := render.MultiJSON(aggregate.Reports(reports, failures))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeBody(w, http.StatusOK, []byte(out))
+}
+
+func (s *Server) context(parent context.Context) (context.Context, context.CancelFunc) {
+	if s.opts.Timeout > 0 {
+		return context.WithTimeout(parent, s.opts.Timeout)
+	}
+	return context.WithCancel(parent)
+}
+
+func (s *Server) repo(name string) (Repo, bool) {
+	if name == "" && len(s.opts.Repos) == 1 {
+		return s.opts.Repos[0], true
+	}
+	for _, r := range s.opts.Repos {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Repo{}, false
+}
+
+// report returns the rendered JSON report of repo, from memory when HEAD
+// has not moved since the same query was last answered, and for queries with
+// dates, within datedTTL. Partial reports and range queries are never kept.
+func (s *Server) report(ctx context.Context, repo Repo, cfg model.Config, query url.Values) ([]byte, error) {
+	lock := s.locks[repo.Path]
+	lock.Lock()
+	defer lock.Unlock()
+
+	// A range may name refs other than HEAD, which move on their own.
+	key := ""
+	if s.opts.Head != nil && !query.Has("range") {
+		if head, err := s.opts.Head(ctx, repo.Path); err == nil {
+			key = cacheKey(repo.Path, head, query)
+		}
+	}
+	if body, ok := s.lookup(key); ok {
+		return body, nil
+	}
+	report, err := s.opts.Build(ctx, repo.Path, cfg)
+	if err != nil {
+		return nil, err
+	}
+	out, err := render.JSON(report)
+	if err != nil {
+		return nil, err
+	}
+	if report.Partial == nil {
+		var ttl time.Duration
+		if query.Has("since") || query.Has("until") {
+			ttl = datedTTL
+		}
+		s.store(key, []byte(out), ttl)
+	}
+	return []byte(out), nil
+}
+
+func (s *Server) lookup(key string) ([]byte, bool) {
+	if key == "" {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.cached[key]
+	if !ok || !c.expires.IsZero() && !s.now().Before(c.expires) {
+		return nil, false
+	}
+	return c.body, true
+}
+
+// store keeps body under key for ttl, or until evicted when ttl is 0,
+// dropping the oldest entry when full.
+func (s *Server) store(key string, body []byte, ttl time.Duration) {
+	if key == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.cached[key]; !ok {
+		if len(s.order) >= maxCached {
+			delete(s.cached, s.order[0])
+			s.order = s.order[1:]
+		}
+		s.order = append(s.order, key)
+	}
+	c := cachedReport{body: body}
+	if ttl > 0 {
+		c.expires = s.now().Add(ttl)
+	}
+	s.cached[key] = c
+}
+
+// cacheKey identifies a query's answer: the repository, its HEAD and the
+// query parameters in a fixed order.
+func cacheKey(repo, head string, query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		if k != "repo" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	b := &strings.Builder{}
+	b.WriteString(repo + "\x00" + head)
+	for _, k := range keys {
+		b.WriteString("\x00" + k + "=" + strings.Join(query[k], ","))
+	}
+	return b.String()
+}
+
+// queryConfig applies the query parameters on top of the server defaults.
+func (s *Server) queryConfig(query url.Values) (model.Config, error) {
+	cfg := s.opts.Base
+	cfg.JSON = true
+	cfg.Quiet = true
+	for key, values := range query {
+		value := values[len(values)-1]
+		var err error
+		switch key {
+		case "repo":
+		case "since":
+			cfg.Since = value
+		case "until":
+			cfg.Until = value
+		case "author":
+			cfg.Author = value
+		case "range":
+			cfg.Range = value
+		case "trend":
+			cfg.Trend = value
+		case "tz":
+			cfg.TZ = value
+		case "deep":
+			cfg.Deep, err = strconv.ParseBool(value)
+		case "exclude_bots":
+			cfg.ExcludeBots, err = strconv.ParseBool(value)
+		case "wholesome":
+			cfg.Wholesome, err = strconv.ParseBool(value)
+		case "censor":
+			cfg.Censor, err = strconv.ParseBool(value)
+		case "intensity":
+			cfg.Intensity, err = strconv.Atoi(value)
+		case "top":
+			cfg.Top, err = strconv.Atoi(value)
+		case "max_commits":
+			cfg.MaxCommits, err = strconv.Atoi(value)
+		default:
+			return cfg, fmt.Errorf("unknown parameter %q", key)
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("invalid %s %q", key, value)
+		}
+	}
+	if s.opts.Validate != nil {
+		if err := s.opts.Validate(cfg); err != nil {
+			return cfg, err
+		}
+	}
+	return cfg, nil
+}
+
+// errorStatus maps an analysis error to an HTTP status: revisions the
+// query named that do not exist are the client's fault.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, git.ErrBadRevision), errors.Is(err, git.ErrShallow):
+		return http.StatusBadRequest
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	body, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeBody(w, status, body)
+}
+
+func writeBody(w http.ResponseWriter, status int, body []byte) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+	w.Write([]byte("\n"))
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	body, _ := json.Marshal(map[string]string{"error": msg})
+	writeBody(w, status, body)
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+)
+
+// fakeRepos builds reports from the query alone and counts the builds.
+type fakeRepos struct {
+	head   string
+	builds int
+}
+
+func (f *fakeRepos) options() Options {
+	return Options{
+		Repos: []Repo{{Name: "api", Path: "/src/api"}, {Name: "web", Path: "/src/web"}},
+		Base:  model.Config{TZ: "local", Top: 5},
+		Build: func(ctx context.Context, repo string, cfg model.Config) (model.Report, error) {
+			f.builds++
+			if cfg.Range == "nope" {
+				return model.Report{}, fmt.Errorf("log: %w", git.ErrBadRevision)
+			}
+			return model.Report{
+				Repo:    model.RepoInfo{Path: repo, Name: repo[strings.LastIndex(repo, "/")+1:], Head: f.head, CommitCount: 10},
+				Filters: model.Filters{Since: cfg.Since, Author: cfg.Author, TZ: cfg.TZ, Deep: cfg.Deep},
+				Score:   model.Score{Overall: 80},
+			}, nil
+		},
+		Head: func(ctx context.Context, repo string) (string, error) { return f.head, nil },
+	}
+}
+
+func get(t *testing.T, h http.Handler, url string) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	var body map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("GET %s: %v\n%s", url, err, rec.Body.String())
+	}
+	return rec.Code, body
+}
+
+func TestReport(t *testing.T) {
+	f := &fakeRepos{head: "aaa"}
+	srv := New(f.options())
+
+	code, body := get(t, srv, "/api/report?repo=web&since=2024-01-01&deep=true")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, body)
+	}
+	filters := body["filters"].(map[string]any)
+	if body["schema_version"] != float64(model.SchemaVersion) || filters["since"] != "2024-01-01" || filters["deep"] != true {
+		t.Fatalf("unexpected report: %v", body)
+	}
+	if _, ok := body["offenders"].([]any); !ok {
+		t.Fatalf("offenders should be an empty list like --json output: %v", body["offenders"])
+	}
+
+	get(t, srv, "/api/report?deep=true&since=2024-01-01&repo=web")
+	if f.builds != 1 {
+		t.Fatalf("same query at the same HEAD built %d times, want 1", f.builds)
+	}
+	f.head = "bbb"
+	get(t, srv, "/api/report?repo=web&since=2024-01-01&deep=true")
+	if f.builds != 2 {
+		t.Fatalf("query after HEAD moved built %d times in total, want 2", f.builds)
+	}
+}
+
+func TestReportDatedQueryExpires(t *testing.T) {
+	f := &fakeRepos{head: "aaa"}
+	srv := New(f.options())
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	srv.now = func() time.Time { return now }
+
+	get(t, srv, "/api/report?repo=web&since=2+weeks+ago")
+	get(t, srv, "/api/report?repo=web")
+	now = now.Add(datedTTL)
+	get(t, srv, "/api/report?repo=web&since=2+weeks+ago")
+	get(t, srv, "/api/report?repo=web")
+	if f.builds != 3 {
+		t.Fatalf("built %d times, want 3: a dated query is rebuilt after %s, an undated one is not", f.builds, datedTTL)
+	}
+}
+
+func TestReportErrors(t *testing.T) {
+	f := &fakeRepos{head: "aaa"}
+	srv := New(f.options())
+	for url, want := range map[string]int{
+		"/api/report":                     http.StatusBadRequest,
+		"/api/report?repo=nope":           http.StatusNotFound,
+		"/api/report?repo=api&deep=yes!":  http.StatusBadRequest,
+		"/api/report?repo=api&color=red":  http.StatusBadRequest,
+		"/api/report?repo=api&range=nope": http.StatusBadRequest,
+	} {
+		code, body := get(t, srv, url)
+		if code != want || body["error"] == "" {
+			t.Errorf("GET %s = %d %v, want %d with an error", url, code, body, want)
+		}
+	}
+}
+
+func TestOrg(t *testing.T) {
+	f := &fakeRepos{head: "aaa"}
+	srv := New(f.options())
+	code, body := get(t, srv, "/api/org?author=jane")
+	if code != http.StatusOK {
+		t.Fatalf("status = %d: %v", code, body)
+	}
+	repos := body["repos"].([]any)
+	if len(repos) != 2 || body["commit_count"] != float64(20) {
+		t.Fatalf("unexpected org report: %v", body)
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	srv := New((&fakeRepos{}).options())
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/api/report") {
+		t.Fatalf("dashboard = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown path = %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,60 @@
+//go:build !windows
+
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// Supported reports whether Run can show the TUI on this platform.
+const Supported = true
+
+// Run shows the TUI on tty until the user quits. tty must be a terminal.
+func Run(tty *os.File, out io.Writer, opts Options) error {
+	restore, err := makeRaw(tty)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	// Alternate screen, hidden cursor; both undone on the way out.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	a := newApp(opts)
+	keys := make(chan []byte)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := tty.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- append([]byte(nil), buf[:n]...)
+		}
+	}()
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer signal.Stop(winch)
+
+	for !a.quit {
+		a.width, a.height = termSize(tty)
+		fmt.Fprint(out, "\x1b[H\x1b[2J"+strings.Join(a.view(), "\r\n"))
+		select {
+		case chunk, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, k := range decodeKeys(chunk) {
+				a.handle(k)
+			}
+		case <-winch:
+		}
+	}
+	return nil
+}
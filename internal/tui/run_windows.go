@@ -0,0 +1,15 @@
+package tui
+
+import (
+	"io"
+	"os"
+)
+
+// Supported reports whether Run can show the TUI on this platform.
+const Supported = false
+
+// Run reports ErrUnsupported: the TUI drives the terminal through stty(1)
+// and SIGWINCH, which Windows consoles lack.
+func Run(tty *os.File, out io.Writer, opts Options) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// stty runs stty(1) on tty and returns its output. Going through stty keeps
+// the TUI free of terminal libraries; it is present on every Unix.
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// makeRaw switches tty to raw mode without echo and returns a function that
+// restores the previous settings.
+func makeRaw(tty *os.File) (func(), error) {
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() { stty(tty, saved) }, nil
+}
+
+// termSize returns the width and height of tty, or 80x24 when unknown.
+func termSize(tty *os.File) (int, int) {
+	out, err := stty(tty, "size")
+	if err != nil {
+		return 80, 24
+	}
+	rows, cols, _ := strings.Cut(out, " ")
+	h, errH := strconv.Atoi(rows)
+	w, errW := strconv.Atoi(cols)
+	if errH != nil || errW != nil || w <= 0 || h <= 0 {
+		return 80, 24
+	}
+	return w, h
+}
@@ -0,0 +1,15 @@
+package tui
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrUnsupported is returned by Run on platforms without a TUI.
+var ErrUnsupported = errors.New("tui is not supported on Windows")
+
+// IsTerminal reports whether f is a character device such as a terminal.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,594 @@
+// Package tui is a full-screen terminal browser for a report: a tab per
+// category, every flagged commit with filters, and a detail pane with the
+// commit's numstat.
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/model"
+	"roastgit/internal/render"
+	"roastgit/internal/util"
+)
+
+// Options configures the TUI.
+type Options struct {
+	// Report should list every offender (no --top cut).
+	Report model.Report
+	// Intensity is the roast intensity the report was generated at.
+	Intensity int
+	// Files returns the per-file numstat of a commit for the detail pane.
+	Files func(sha string) ([]model.FileStat, error)
+	// Reroast regenerates the roast text at another intensity.
+	Reroast func(intensity int) model.RoastOutput
+}
+
+// Tabs in display order.
+const (
+	tabOverview = iota
+	tabMessages
+	tabCadence
+	tabHygiene
+	tabSize
+	tabOffenders
+	tabAuthors
+)
+
+var tabNames = []string{"Overview", "Messages", "Cadence", "Hygiene", "Size", "Offenders", "Authors"}
+
+// tabChecks lists the offender checks shown under each category tab.
+var tabChecks = map[int][]string{
+	tabMessages: {analyze.CheckGeneric, analyze.CheckEmojiOnly, analyze.CheckTooLong, analyze.CheckTooShort, analyze.CheckFixup, analyze.CheckLying},
+	tabCadence:  {analyze.CheckPanic, analyze.CheckMidnight, analyze.CheckDeadline},
+	tabSize:     {analyze.CheckHuge, analyze.CheckBinary},
+}
+
+// app is the TUI state. Keys update it through handle and view draws it;
+// neither touches the terminal, so both are tested directly.
+type app struct {
+	opts      Options
+	report    model.Report
+	intensity int
+	tab       int
+	// scroll is the first body line shown on text tabs.
+	scroll int
+	// cursor and offset select and scroll the filtered offender list.
+	cursor, offset int
+	reason, author string
+	detail         bool
+	files          map[string][]model.FileStat
+	fileErrs       map[string]error
+	width, height  int
+	quit           bool
+}
+
+func newApp(opts Options) *app {
+	return &app{
+		opts:      opts,
+		report:    opts.Report,
+		intensity: opts.Intensity,
+		files:     map[string][]model.FileStat{},
+		fileErrs:  map[string]error{},
+		width:     80,
+		height:    24,
+	}
+}
+
+// decodeKeys splits raw terminal input into key names: "up", "down",
+// "left", "right", "pgup", "pgdn", "home", "end", "tab", "backtab",
+// "enter", "esc", "backspace", "ctrl-c", or the typed character.
+func decodeKeys(b []byte) []string {
+	sequences := []struct{ seq, key string }{
+		{"\x1b[A", "up"}, {"\x1b[B", "down"}, {"\x1b[C", "right"}, {"\x1b[D", "left"},
+		{"\x1bOA", "up"}, {"\x1bOB", "down"}, {"\x1bOC", "right"}, {"\x1bOD", "left"},
+		{"\x1b[5~", "pgup"}, {"\x1b[6~", "pgdn"}, {"\x1b[H", "home"}, {"\x1b[F", "end"},
+		{"\x1b[1~", "home"}, {"\x1b[4~", "end"}, {"\x1b[Z", "backtab"},
+	}
+	keys := []string{}
+	s := string(b)
+	for len(s) > 0 {
+		matched := false
+		for _, seq := range sequences {
+			if strings.HasPrefix(s, seq.seq) {
+				keys = append(keys, seq.key)
+				s = s[len(seq.seq):]
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		r := []rune(s)[0]
+		s = s[len(string(r)):]
+		switch r {
+		case '\x1b':
+			keys = append(keys, "esc")
+		case '\r', '\n':
+			keys = append(keys, "enter")
+		case '\t':
+			keys = append(keys, "tab")
+		case 3:
+			keys = append(keys, "ctrl-c")
+		case 127, 8:
+			keys = append(keys, "backspace")
+		default:
+			keys = append(keys, string(r))
+		}
+	}
+	return keys
+}
+
+// handle applies one key.
+func (a *app) handle(key string) {
+	switch key {
+	case "q", "ctrl-c":
+		a.quit = true
+	case "tab", "right", "l":
+		a.setTab((a.tab + 1) % len(tabNames))
+	case "backtab", "left", "h":
+		a.setTab((a.tab + len(tabNames) - 1) % len(tabNames))
+	case "1", "2", "3", "4", "5", "6", "7":
+		a.setTab(int(key[0] - '1'))
+	case "up", "k":
+		a.move(-1)
+	case "down", "j":
+		a.move(1)
+	case "pgup":
+		a.move(-a.pageSize())
+	case "pgdn", " ":
+		a.move(a.pageSize())
+	case "home", "g":
+		a.move(-1 << 30)
+	case "end", "G":
+		a.move(1 << 30)
+	case "enter":
+		if a.tab == tabOffenders {
+			a.detail = !a.detail
+			a.loadFiles()
+		}
+	case "esc":
+		a.detail = false
+	case "r":
+		a.reason = cycle(a.reasons(), a.reason)
+		a.cursor, a.offset = 0, 0
+		a.loadFiles()
+	case "a":
+		a.author = cycle(a.authors(), a.author)
+		a.cursor, a.offset = 0, 0
+		a.loadFiles()
+	case "c":
+		a.reason, a.author = "", ""
+		a.cursor, a.offset = 0, 0
+		a.loadFiles()
+	case "+", "=":
+		a.reroast(a.intensity + 1)
+	case "-", "_":
+		a.reroast(a.intensity - 1)
+	}
+}
+
+func (a *app) setTab(tab int) {
+	if tab == a.tab {
+		return
+	}
+	a.tab = tab
+	a.scroll = 0
+}
+
+func (a *app) reroast(intensity int) {
+	intensity = util.ClampInt(intensity, 0, 5)
+	if intensity == a.intensity || a.opts.Reroast == nil {
+		return
+	}
+	a.intensity = intensity
+	a.report.Roasts = a.opts.Reroast(intensity)
+}
+
+// move scrolls text tabs or moves the offender cursor by delta lines.
+func (a *app) move(delta int) {
+	if a.tab != tabOffenders {
+		a.scroll = util.ClampInt(a.scroll+delta, 0, max(0, len(a.body())-a.bodyHeight()))
+		return
+	}
+	n := len(a.filtered())
+	if n == 0 {
+		return
+	}
+	a.cursor = util.ClampInt(a.cursor+delta, 0, n-1)
+	a.loadFiles()
+}
+
+// loadFiles fetches the numstat of the selected offender for the detail
+// pane, once per commit.
+func (a *app) loadFiles() {
+	if !a.detail || a.opts.Files == nil {
+		return
+	}
+	off, ok := a.selected()
+	if !ok {
+		return
+	}
+	if _, done := a.files[off.SHA]; done {
+		return
+	}
+	if _, failed := a.fileErrs[off.SHA]; failed {
+		return
+	}
+	files, err := a.opts.Files(off.SHA)
+	if err != nil {
+		a.fileErrs[off.SHA] = err
+		return
+	}
+	a.files[off.SHA] = files
+}
+
+// filtered returns the offenders matching the reason and author filters.
+func (a *app) filtered() []model.Offender {
+	out := []model.Offender{}
+	for _, off := range a.report.Offenders {
+		if a.author != "" && off.Author != a.author {
+			continue
+		}
+		if a.reason != "" && !contains(off.Reasons, a.reason) {
+			continue
+		}
+		out = append(out, off)
+	}
+	return out
+}
+
+func (a *app) selected() (model.Offender, bool) {
+	list := a.filtered()
+	if a.cursor >= len(list) {
+		return model.Offender{}, false
+	}
+	return list[a.cursor], true
+}
+
+// reasons lists the reasons present among the offenders, built-in ones in
+// check order.
+func (a *app) reasons() []string {
+	present := map[string]bool{}
+	for _, off := range a.report.Offenders {
+		for _, r := range off.Reasons {
+			present[r] = true
+		}
+	}
+	out := []string{}
+	for _, c := range analyze.Checks {
+		if present[c.Description()] {
+			out = append(out, c.Description())
+			delete(present, c.Description())
+		}
+	}
+	// Custom rules follow, by name.
+	custom := make([]string, 0, len(present))
+	for r := range present {
+		custom = append(custom, r)
+	}
+	sort.Strings(custom)
+	return append(out, custom...)
+}
+
+// authors lists the offenders' authors by name.
+func (a *app) authors() []string {
+	seen := map[string]bool{}
+	out := []string{}
+	for _, off := range a.report.Offenders {
+		if !seen[off.Author] {
+			seen[off.Author] = true
+			out = append(out, off.Author)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// cycle returns the value after current in values, wrapping to "" (no
+// filter) after the last.
+func cycle(values []string, current string) string {
+	if current == "" {
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+	for i, v := range values {
+		if v == current && i+1 < len(values) {
+			return values[i+1]
+		}
+	}
+	return ""
+}
+
+func (a *app) pageSize() int {
+	return max(1, a.bodyHeight()-1)
+}
+
+// bodyHeight is the number of lines between the tab bar and the footer.
+func (a *app) bodyHeight() int {
+	return max(1, a.height-4)
+}
+
+// view draws the whole screen as lines no wider than the terminal.
+func (a *app) view() []string {
+	// Below a few rows there is no room for the header, tabs and footer.
+	a.height = max(a.height, 5)
+	r := a.report
+	head := "no commits"
+	if r.Repo.Head != "" {
+		head = util.ShortSHA(r.Repo.Head)
+	}
+	lines := []string{
+		fmt.Sprintf("roastgit  %s @ %s  %d commits  score %d/100  intensity %d", r.Repo.Name, head, r.Repo.CommitCount, r.Score.Overall, a.intensity),
+		a.tabBar(),
+	}
+	var body []string
+	if a.tab == tabOffenders {
+		body = a.offendersView()
+	} else {
+		body = a.body()
+		end := min(len(body), a.scroll+a.bodyHeight())
+		body = body[min(a.scroll, end):end]
+	}
+	lines = append(lines, "")
+	lines = append(lines, body...)
+	for len(lines) < a.height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:a.height-1], a.footer())
+	for i, line := range lines {
+		lines[i] = clip(line, a.width)
+	}
+	// Highlighting is added after clipping so escapes never count as width.
+	lines[1] = a.highlightTab(lines[1])
+	return lines
+}
+
+func (a *app) tabBar() string {
+	parts := make([]string, len(tabNames))
+	for i, name := range tabNames {
+		parts[i] = fmt.Sprintf(" %d %s ", i+1, name)
+	}
+	return strings.Join(parts, " ")
+}
+
+// highlightTab shows the active tab in reverse video.
+func (a *app) highlightTab(bar string) string {
+	label := fmt.Sprintf(" %d %s ", a.tab+1, tabNames[a.tab])
+	return strings.Replace(bar, label, "\x1b[7m"+label+"\x1b[0m", 1)
+}
+
+func (a *app) footer() string {
+	keys := "q quit  tab/1-7 switch  up/down scroll  +/- intensity"
+	if a.tab == tabOffenders {
+		keys = "q quit  up/down select  enter details  r reason  a author  c clear  +/- intensity"
+	}
+	return keys
+}
+
+// body returns every line of a text tab.
+func (a *app) body() []string {
+	switch a.tab {
+	case tabOverview:
+		return a.overview()
+	case tabAuthors:
+		return a.authorsView()
+	}
+	sec := render.Sections(a.report, 0)[a.tab-tabMessages]
+	lines := []string{sec.Title, ""}
+	for _, b := range sec.Bullets {
+		lines = append(lines, "- "+b)
+	}
+	if sec.Roast != "" {
+		lines = append(lines, "")
+		lines = append(lines, wrap(sec.Roast, a.width)...)
+	}
+	if a.tab == tabSize && (a.report.Metrics.Submodules.Commits > 0 || len(a.report.Submodules) > 0) {
+		lines = append(lines, "", "Submodules", "")
+		for _, b := range render.SubmoduleBullets(a.report) {
+			lines = append(lines, "- "+b)
+		}
+	}
+	if checks := tabChecks[a.tab]; len(checks) > 0 {
+		counts := map[string]int{}
+		for _, off := range a.report.Offenders {
+			for _, reason := range off.Reasons {
+				counts[reason]++
+			}
+		}
+		lines = append(lines, "", "Flagged commits (see Offenders, r to filter)", "")
+		for _, id := range checks {
+			c, _ := analyze.LookupCheck(id)
+			lines = append(lines, fmt.Sprintf("- %-20s %d", c.Description(), counts[c.Description()]))
+		}
+	}
+	return lines
+}
+
+func (a *app) overview() []string {
+	r := a.report
+	b := r.Score.Breakdown
+	lines := wrap(r.Roasts.Headline, a.width)
+	lines = append(lines, "",
+		fmt.Sprintf("Score %d/100: message %d/30, hygiene %d/30, cadence %d/20, size %d/20",
+			r.Score.Overall, b.MessageQuality, b.Hygiene, b.Cadence, b.SizeDiscipline))
+	if r.Filters.Since != "" || r.Filters.Until != "" || r.Filters.Author != "" || r.Filters.Range != "" {
+		lines = append(lines, fmt.Sprintf("Filters: since %s, until %s, author %s, range %s",
+			orAll(r.Filters.Since), orAll(r.Filters.Until), orAll(r.Filters.Author), orAll(r.Filters.Range)))
+	}
+	if r.Partial != nil {
+		lines = append(lines, fmt.Sprintf("Partial: %s during %s; metrics cover only what was read", r.Partial.Reason, r.Partial.Stage))
+	}
+	if r.Shallow != nil {
+		lines = append(lines, fmt.Sprintf("Shallow history: cut off at %d boundary commit(s)", len(r.Shallow.Boundary)))
+	}
+	if r.Baseline != nil && r.Baseline.Suppressed > 0 {
+		lines = append(lines, fmt.Sprintf("Baseline: %d known offenders hidden", r.Baseline.Suppressed))
+	}
+	if ex := r.Metrics.Exemptions; ex.Checks > 0 {
+		lines = append(lines, fmt.Sprintf("Exemptions: %d checks skipped on %d commits", ex.Checks, ex.Commits))
+	}
+	lines = append(lines, fmt.Sprintf("Flagged commits: %d", len(r.Offenders)))
+	if len(r.Roasts.Tips) > 0 {
+		lines = append(lines, "", "Tips", "")
+		for _, tip := range r.Roasts.Tips {
+			lines = append(lines, wrap("- "+tip, a.width)...)
+		}
+	}
+	if r.Trend != nil && len(r.Trend.Points) > 0 {
+		lines = append(lines, "", fmt.Sprintf("Trend (per %s)", r.Trend.Period), "")
+		values := make([]float64, len(r.Trend.Points))
+		for i, p := range r.Trend.Points {
+			values[i] = float64(p.Overall)
+		}
+		lines = append(lines, "Overall "+util.Sparkline(values, 0, 100))
+	}
+	return lines
+}
+
+func (a *app) authorsView() []string {
+	lines := []string{fmt.Sprintf("%-28s %7s %11s %8s", "Author", "Commits", "Low quality", "Avg msg"), ""}
+	for _, au := range a.report.Authors {
+		lines = append(lines, fmt.Sprintf("%-28s %7d %11d %8.0f  %s", clip(au.Name, 28), au.Commits, au.LowQuality, au.AverageQuality, au.Email))
+	}
+	if a.report.Automation.Commits > 0 {
+		lines = append(lines, "", "Automation", "")
+		for _, b := range render.AutomationBullets(a.report.Automation, 0) {
+			lines = append(lines, "- "+b)
+		}
+	}
+	return lines
+}
+
+// offendersView draws the filter line, the visible part of the list, and
+// the detail pane below it when open.
+func (a *app) offendersView() []string {
+	list := a.filtered()
+	lines := []string{fmt.Sprintf("Reason: %s   Author: %s   %d of %d commits",
+		orAll(a.reason), orAll(a.author), len(list), len(a.report.Offenders))}
+	listHeight := a.bodyHeight() - 1
+	var detail []string
+	if a.detail {
+		detail = a.detailView()
+		listHeight = max(3, listHeight/2)
+	}
+	if a.cursor < a.offset {
+		a.offset = a.cursor
+	}
+	if a.cursor >= a.offset+listHeight {
+		a.offset = a.cursor - listHeight + 1
+	}
+	if len(list) == 0 {
+		lines = append(lines, "No flagged commits match.")
+	}
+	for i := a.offset; i < len(list) && i < a.offset+listHeight; i++ {
+		off := list[i]
+		marker := "  "
+		if i == a.cursor {
+			marker = "> "
+		}
+		lines = append(lines, fmt.Sprintf("%s%s %s %-16s %s -- %s", marker, util.ShortSHA(off.SHA), dateOf(off.Date),
+			clip(off.Author, 16), off.Subject, strings.Join(off.Reasons, ", ")))
+	}
+	if a.detail {
+		for len(lines) < listHeight+1 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, strings.Repeat("-", a.width))
+		lines = append(lines, detail...)
+	}
+	return lines
+}
+
+func (a *app) detailView() []string {
+	off, ok := a.selected()
+	if !ok {
+		return nil
+	}
+	lines := []string{
+		fmt.Sprintf("%s  %s  %s", off.SHA, off.Author, off.Date),
+		off.Subject,
+		"Flagged for: " + strings.Join(off.Reasons, ", "),
+	}
+	if err, failed := a.fileErrs[off.SHA]; failed {
+		return append(lines, "Numstat unavailable: "+err.Error())
+	}
+	files, ok := a.files[off.SHA]
+	if !ok {
+		return lines
+	}
+	added, deleted := 0, 0
+	for _, f := range files {
+		added += f.Added
+		deleted += f.Deleted
+	}
+	lines = append(lines, fmt.Sprintf("%d files, +%d/-%d lines", len(files), added, deleted))
+	for _, f := range files {
+		switch {
+		case f.Submodule:
+			lines = append(lines, fmt.Sprintf("  %8s  %s", "submod", f.Path))
+		case f.Binary:
+			lines = append(lines, fmt.Sprintf("  %8s  %s", "binary", f.Path))
+		default:
+			lines = append(lines, fmt.Sprintf("  %8s  %s", fmt.Sprintf("+%d/-%d", f.Added, f.Deleted), f.Path))
+		}
+	}
+	return lines
+}
+
+func dateOf(rfc3339 string) string {
+	if len(rfc3339) >= 10 {
+		return rfc3339[:10]
+	}
+	return rfc3339
+}
+
+func orAll(s string) string {
+	if s == "" {
+		return "all"
+	}
+	return s
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// clip cuts s to width runes.
+func clip(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	return string(r[:max(0, width)])
+}
+
+// wrap breaks text into lines of at most width runes at spaces.
+func wrap(text string, width int) []string {
+	lines := []string{}
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len([]rune(line))+1+len([]rune(word)) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
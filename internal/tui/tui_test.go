@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"roastgit/internal/model"
+)
+
+func sampleOptions() Options {
+	return Options{
+		Report: model.Report{
+			Repo:  model.RepoInfo{Name: "demo", Head: "abcdef1234567890", CommitCount: 3},
+			Score: model.Score{Overall: 42},
+			Offenders: []model.Offender{
+				{SHA: "1111111111", Subject: "wip", Date: "2024-01-02T03:04:05Z", Author: "Jane", Reasons: []string{"generic message"}},
+				{SHA: "2222222222", Subject: "huge dump", Date: "2024-01-03T03:04:05Z", Author: "Bob", Reasons: []string{"huge commit", "binary blobs"}},
+				{SHA: "3333333333", Subject: "fix", Date: "2024-01-04T03:04:05Z", Author: "Jane", Reasons: []string{"generic message", "too short"}},
+			},
+			Roasts: model.RoastOutput{Headline: "intensity 3", Sections: map[string]string{}},
+		},
+		Intensity: 3,
+		Files: func(sha string) ([]model.FileStat, error) {
+			if sha == "3333333333" {
+				return nil, errors.New("boom")
+			}
+			return []model.FileStat{{Path: "main.go", Added: 10, Deleted: 2}, {Path: "logo.png", Binary: true}}, nil
+		},
+		Reroast: func(intensity int) model.RoastOutput {
+			return model.RoastOutput{Headline: "intensity " + string(rune('0'+intensity)), Sections: map[string]string{}}
+		},
+	}
+}
+
+func screen(a *app) string {
+	return strings.Join(a.view(), "\n")
+}
+
+func TestDecodeKeys(t *testing.T) {
+	got := decodeKeys([]byte("j\x1b[A\x1b[6~\r\t\x1b[Z\x03q\x1b"))
+	want := []string{"j", "up", "pgdn", "enter", "tab", "backtab", "ctrl-c", "q", "esc"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("decodeKeys = %q, want %q", got, want)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	a := newApp(sampleOptions())
+	a.handle("6")
+	if a.tab != tabOffenders || len(a.filtered()) != 3 {
+		t.Fatalf("tab %d, %d offenders", a.tab, len(a.filtered()))
+	}
+	a.handle("r")
+	if a.reason != "generic message" || len(a.filtered()) != 2 {
+		t.Fatalf("reason %q kept %d offenders", a.reason, len(a.filtered()))
+	}
+	a.handle("a")
+	a.handle("a")
+	if a.author != "Jane" || len(a.filtered()) != 2 {
+		t.Fatalf("author %q kept %d offenders", a.author, len(a.filtered()))
+	}
+	a.handle("a")
+	if a.author != "" {
+		t.Fatalf("author filter should wrap to none, got %q", a.author)
+	}
+	a.handle("c")
+	if a.reason != "" || len(a.filtered()) != 3 {
+		t.Fatalf("clear left reason %q", a.reason)
+	}
+}
+
+func TestDetailPane(t *testing.T) {
+	a := newApp(sampleOptions())
+	a.handle("6")
+	a.handle("j")
+	a.handle("enter")
+	out := screen(a)
+	for _, want := range []string{"2222222222", "huge commit, binary blobs", "2 files, +10/-2 lines", "binary  logo.png"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("detail pane missing %q:\n%s", want, out)
+		}
+	}
+	a.handle("j")
+	if out := screen(a); !strings.Contains(out, "Numstat unavailable: boom") {
+		t.Errorf("detail pane should show the numstat error:\n%s", out)
+	}
+	a.handle("j")
+	if a.cursor != 2 {
+		t.Fatalf("cursor moved past the end: %d", a.cursor)
+	}
+}
+
+func TestReroastAndView(t *testing.T) {
+	a := newApp(sampleOptions())
+	a.width, a.height = 40, 10
+	a.handle("+")
+	a.handle("+")
+	a.handle("+")
+	if a.intensity != 5 || a.report.Roasts.Headline != "intensity 5" {
+		t.Fatalf("intensity %d, headline %q", a.intensity, a.report.Roasts.Headline)
+	}
+	lines := a.view()
+	if len(lines) != 10 {
+		t.Fatalf("view has %d lines, want the terminal height", len(lines))
+	}
+	for i, line := range lines {
+		if i != 1 && len([]rune(line)) > 40 {
+			t.Errorf("line %d wider than the terminal: %q", i, line)
+		}
+	}
+	a.width, a.height = 80, 24
+	a.handle("2")
+	if out := screen(a); !strings.Contains(out, "generic message") || !strings.Contains(out, "Flagged commits") {
+		t.Errorf("messages tab should count flagged commits:\n%s", out)
+	}
+	a.handle("q")
+	if !a.quit {
+		t.Fatal("q should quit")
+	}
+}
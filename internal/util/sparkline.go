@@ -0,0 +1,21 @@
+package util
+
+import "math"
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders values as block characters scaled between lo and hi.
+func Sparkline(values []float64, lo, hi float64) string {
+	out := make([]rune, 0, len(values))
+	span := hi - lo
+	for _, v := range values {
+		idx := 0
+		if span > 0 {
+			frac := (v - lo) / span
+			idx = int(math.Round(frac * float64(len(sparkBlocks)-1)))
+		}
+		idx = ClampInt(idx, 0, len(sparkBlocks)-1)
+		out = append(out, sparkBlocks[idx])
+	}
+	return string(out)
+}
@@ -0,0 +1,13 @@
+package util
+
+import "testing"
+
+func TestSparklineScales(t *testing.T) {
+	out := Sparkline([]float64{0, 50, 100, 150}, 0, 100)
+	if out != "▁▅██" {
+		t.Fatalf("unexpected sparkline %q", out)
+	}
+	if Sparkline([]float64{3, 3}, 3, 3) != "▁▁" {
+		t.Fatalf("expected flat sparkline for empty range")
+	}
+}
@@ -0,0 +1,18 @@
+// Package roastgit analyzes a git history and roasts it: it scores commit
+// messages, cadence, hygiene and commit sizes, lists the worst commits, and
+// writes the result as the same text and JSON reports the roastgit command
+// prints.
+//
+// Analyze reads a repository on disk, or any other history through a
+// Source:
+//
+//	report, err := roastgit.Analyze(ctx, roastgit.Options{Path: "/src/site", Intensity: 3})
+//	if err != nil {
+//		return err
+//	}
+//	return roastgit.TextRenderer(roastgit.TextOptions{NoColor: true}).Render(w, report)
+//
+// The Report type is the --json document, described by the JSON Schema that
+// "roastgit schema" prints; its version is SchemaVersion. Fields are only
+// added between releases, and each addition bumps SchemaVersion.
+package roastgit
@@ -0,0 +1,100 @@
+package roastgit_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"roastgit/pkg/roastgit"
+)
+
+// history is a small history held in memory, newest first.
+func history() ([]roastgit.Commit, map[string]roastgit.CommitSize) {
+	zone := time.FixedZone("CET", 3600)
+	commits := []roastgit.Commit{
+		{SHA: "c3", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: time.Date(2024, 3, 2, 1, 30, 0, 0, zone), Subject: "fix", Parents: []string{"c2"}},
+		{SHA: "c2", AuthorName: "Bob", AuthorEmail: "bob@example.com", Date: time.Date(2024, 2, 28, 14, 0, 0, 0, zone), Subject: "Add retry to the upload client", Parents: []string{"c1"}},
+		{SHA: "c1", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: time.Date(2024, 2, 27, 10, 0, 0, 0, zone), Subject: "Initial import of the upload service"},
+	}
+	sizes := map[string]roastgit.CommitSize{
+		"c3": {Files: 1, Added: 2, Deleted: 1},
+		"c2": {Files: 3, Added: 80, Deleted: 12},
+		"c1": {Files: 40, Added: 3000},
+	}
+	return commits, sizes
+}
+
+// Analyze reads a history from any Source; NewMemorySource serves commits
+// that are already in memory.
+func Example() {
+	commits, sizes := history()
+	report, err := roastgit.Analyze(context.Background(), roastgit.Options{
+		Source: roastgit.NewMemorySource(commits, sizes),
+		Name:   "uploads",
+		TZ:     "commit",
+		Deep:   true,
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Printf("%s: %d commits, score %d/100\n", report.Repo.Name, report.Repo.CommitCount, report.Score.Overall)
+	for _, off := range report.Offenders {
+		fmt.Printf("%s %q: %s\n", off.SHA, off.Subject, strings.Join(off.Reasons, ", "))
+	}
+	// Output:
+	// uploads: 3 commits, score 82/100
+	// c3 "fix": generic message, too short, midnight gremlin
+	// c1 "Initial import of the upload service": huge commit
+}
+
+// Analyze reads the repository at Path like the roastgit command does; a
+// JSONRenderer writes the same document as --json.
+func ExampleAnalyze() {
+	report, err := roastgit.Analyze(context.Background(), roastgit.Options{
+		Path:      ".",
+		Since:     "2024-01-01",
+		Intensity: 3,
+		Top:       5,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	roastgit.JSONRenderer(0).Render(os.Stdout, report)
+}
+
+// A RendererFunc plugs in an output format of your own.
+func ExampleRendererFunc() {
+	commits, sizes := history()
+	report, _ := roastgit.Analyze(context.Background(), roastgit.Options{
+		Source: roastgit.NewMemorySource(commits, sizes),
+		Name:   "uploads",
+		TZ:     "commit",
+		Since:  "2024-02-28",
+	})
+	badge := roastgit.RendererFunc(func(w io.Writer, r roastgit.Report) error {
+		_, err := fmt.Fprintf(w, "[roastgit %s | %d/100 | %d flagged]\n", r.Repo.Name, r.Score.Overall, len(r.Offenders))
+		return err
+	})
+	badge.Render(os.Stdout, report)
+	// Output:
+	// [roastgit uploads | 86/100 | 1 flagged]
+}
+
+// Roast rewrites a report's roast at another intensity without analyzing
+// the history again.
+func ExampleRoast() {
+	commits, sizes := history()
+	report, _ := roastgit.Analyze(context.Background(), roastgit.Options{
+		Source: roastgit.NewMemorySource(commits, sizes),
+		TZ:     "commit",
+	})
+	gentle := roastgit.Roast(report, 0, true, false)
+	fmt.Println(gentle.Headline != report.Roasts.Headline)
+	// Output:
+	// true
+}
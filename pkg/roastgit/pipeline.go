@@ -0,0 +1,507 @@
+package roastgit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/baseline"
+	"roastgit/internal/cache"
+	"roastgit/internal/config"
+	"roastgit/internal/gate"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/util"
+)
+
+// analyzeRepo runs the full analysis pipeline over repo.
+func analyzeRepo(ctx context.Context, repo git.Backend, opts Options) (Report, error) {
+	fileCfg, err := loadConfig(opts)
+	if err != nil {
+		return Report{}, ConfigError{err}
+	}
+	allow, err := fileCfg.AllowRules()
+	if err != nil {
+		return Report{}, ConfigError{err}
+	}
+	custom, err := fileCfg.CustomRules()
+	if err != nil {
+		return Report{}, ConfigError{err}
+	}
+	// Names in FailOn may refer to rules the config file declares.
+	if err := gate.Validate(opts.FailOn, custom...); err != nil {
+		return Report{}, ConfigError{fmt.Errorf("fail on: %w", err)}
+	}
+	bots := fileCfg.BotMatcher()
+	analyzeCfg := analyze.AnalyzeConfig{TZ: opts.TZ, Allow: allow, Bots: bots, Rules: custom}
+	var key string
+	if opts.Incremental {
+		// Sampled sizes cannot be added up across runs.
+		opts.Deep = true
+		if key, err = stateKey(opts, fileCfg, custom); err != nil {
+			return Report{}, ConfigError{err}
+		}
+	}
+
+	// An interrupt or timeout keeps what was read so far: the report is
+	// built from the commits and sizes loaded before the context ended.
+	var partial *model.PartialInfo
+	commits, head, saved, err := loadCommits(ctx, repo, opts, key)
+	if err != nil {
+		if partial = partialInfo(ctx, "log"); partial == nil || len(commits) == 0 {
+			return Report{}, err
+		}
+	}
+	if saved != nil && saved.since != "" {
+		// A single numstat stream must cover the same commits as the log.
+		opts.Revisions = []string{head, "^" + saved.since}
+	}
+	shallow, err := repo.ShallowCommits(ctx)
+	if err != nil && partial == nil {
+		return Report{}, err
+	}
+	boundary := boundaryCommits(commits, shallow)
+	authors := analyze.AuthorTotals{}
+	totals := &analyze.Totals{}
+	if saved != nil {
+		authors, totals = saved.state.Authors, &saved.state.Totals
+	}
+	authors.Add(commits, analyzeCfg)
+	automation := authors.Automation(opts.ExcludeBots)
+	if opts.ExcludeBots {
+		commits = analyze.WithoutBots(commits, bots)
+	}
+	humans, _ := authors.Split()
+
+	branches := []string{}
+	if bs, err := repo.Branches(ctx); err == nil {
+		branches = bs
+	}
+
+	var spinner *util.Spinner
+	if opts.Progress != nil && len(commits) > 2000 {
+		spinner = util.NewSpinner(opts.Progress, "Analyzing commits")
+		spinner.Start()
+	}
+	store := cache.OpenRepo(ctx, repo.GitDir, opts.Backend, opts.NoCache)
+	sizes, sampled, err := loadSizes(ctx, repo, store, commits, boundary, opts)
+	if spinner != nil {
+		spinner.Stop("Analysis complete")
+	}
+	if err != nil {
+		p := partialInfo(ctx, "numstat")
+		if p == nil {
+			return Report{}, err
+		}
+		if partial == nil {
+			partial = p
+		}
+	}
+	if analyze.NeedsFiles(analyze.Rules(custom...)) && partial == nil {
+		analyzeCfg.Files, err = loadFiles(ctx, repo, store, commits, sizes, opts.Jobs)
+		if err != nil {
+			if partial = partialInfo(ctx, "numstat"); partial == nil {
+				return Report{}, err
+			}
+		}
+	}
+	analyzeCfg.Messages = store.Messages(commits)
+	// The cache only saves work; failing to write it must not fail the run.
+	_ = store.Save()
+
+	totals.Add(commits, sizes, analyzeCfg)
+	metrics, offenders := totals.Metrics(branches), totals.Offenders
+	metrics.Size.Sampled = sampled
+	// A rule script that failed on some commit would leave its findings out.
+	if err := analyze.RuleErr(analyze.Rules(custom...)); err != nil {
+		return Report{}, ConfigError{err}
+	}
+	if saved != nil && partial == nil {
+		// Like the cache, the state only saves work.
+		_ = saved.save(head)
+	}
+
+	score := analyze.Score(metrics)
+	report := Report{
+		Repo: model.RepoInfo{
+			Path:        opts.Path,
+			Name:        opts.Name,
+			Head:        head,
+			CommitCount: totals.Commits,
+		},
+		Filters: model.Filters{
+			Since:       opts.Since,
+			Until:       opts.Until,
+			Author:      opts.Author,
+			MaxCommits:  opts.MaxCommits,
+			Range:       opts.Range,
+			ExcludeBots: opts.ExcludeBots,
+			TZ:          opts.TZ,
+			Deep:        opts.Deep,
+		},
+		Score:      score,
+		Metrics:    metrics,
+		Authors:    humans,
+		Automation: automation,
+		Partial:    partial,
+	}
+	if report.Repo.Name == "" && opts.Path != "" {
+		report.Repo.Name = git.RepoName(opts.Path)
+	}
+	report.Roasts = Roast(report, opts.Intensity, opts.Wholesome, opts.Censor)
+	if len(boundary) > 0 {
+		report.Shallow = &model.ShallowInfo{Boundary: boundary}
+	}
+
+	if path := baselinePath(opts); path != "" && !opts.NoBaseline {
+		known, err := baseline.Load(path)
+		switch {
+		case err == nil:
+			var suppressed int
+			offenders, suppressed = analyze.SuppressOffenders(offenders, known.Known(), custom...)
+			report.Baseline = &model.BaselineInfo{Path: path, Entries: len(known.Entries), Suppressed: suppressed}
+		case errors.Is(err, os.ErrNotExist) && opts.Baseline == "":
+		default:
+			return Report{}, err
+		}
+	}
+	if len(opts.FailOn) > 0 && !(opts.RefuseShallow && report.Shallow != nil) {
+		result := gate.Evaluate(offenders, opts.FailOn, custom...)
+		report.Gate = &result
+	}
+	report.Offenders = offenders
+	if opts.Top > 0 {
+		report.Offenders = analyze.TopOffenders(offenders, opts.Top)
+	}
+
+	if opts.Trend != "" {
+		trend, err := analyze.BuildTrend(commits, sizes, branches, analyzeCfg, opts.Trend)
+		if err != nil {
+			return Report{}, err
+		}
+		if err := analyze.RuleErr(analyze.Rules(custom...)); err != nil {
+			return Report{}, ConfigError{err}
+		}
+		report.Trend = &trend
+	}
+	if opts.RecurseSubmodules {
+		if report.Submodules, err = submoduleReports(ctx, opts); err != nil {
+			return Report{}, err
+		}
+	}
+	return report, nil
+}
+
+// loadConfig reads the config file: opts.ConfigFile, or the default file at
+// the repository root when there is one.
+func loadConfig(opts Options) (config.File, error) {
+	if opts.Path == "" && opts.ConfigFile == "" {
+		return config.File{}, nil
+	}
+	return config.LoadRepo(opts.Path, opts.ConfigFile)
+}
+
+// baselinePath is opts.Baseline, or the default file at the repository root
+// when there is one.
+func baselinePath(opts Options) string {
+	if opts.Path == "" {
+		return opts.Baseline
+	}
+	return baseline.Path(opts.Path, opts.Baseline)
+}
+
+// partialInfo describes why ctx ended during stage, or returns nil while it
+// is still live.
+func partialInfo(ctx context.Context, stage string) *model.PartialInfo {
+	switch {
+	case ctx.Err() == nil:
+		return nil
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return &model.PartialInfo{Reason: "timeout", Stage: stage}
+	default:
+		return &model.PartialInfo{Reason: "interrupted", Stage: stage}
+	}
+}
+
+// boundaryCommits returns the sorted commits whose parents a shallow clone
+// did not fetch. git lists them without parents, so their numstat counts
+// the whole tree.
+func boundaryCommits(commits []model.Commit, shallow []string) []string {
+	if len(shallow) == 0 {
+		return nil
+	}
+	cut := make(map[string]bool, len(shallow))
+	for _, sha := range shallow {
+		cut[sha] = true
+	}
+	boundary := []string{}
+	for _, c := range commits {
+		if cut[c.SHA] {
+			boundary = append(boundary, c.SHA)
+		}
+	}
+	sort.Strings(boundary)
+	return boundary
+}
+
+// loadCommits returns the commits to analyze, newest first, and HEAD. A
+// repository without commits yields none and an empty HEAD. With
+// Incremental it returns the state to add the commits to as well.
+func loadCommits(ctx context.Context, repo git.Backend, opts Options, key string) ([]model.Commit, string, *savedState, error) {
+	if !repo.IsRepo(ctx) {
+		// Let git say why, so an ownership refusal is not reported as
+		// "not a git repository".
+		if _, err := repo.GitDir(ctx); err != nil {
+			return nil, "", nil, err
+		}
+		return nil, "", nil, git.ErrNotRepo
+	}
+	head, err := repo.HeadSHA(ctx)
+	if errors.Is(err, git.ErrUnbornHead) {
+		// A fresh repository: report "no commits yet" instead of failing.
+		return []model.Commit{}, "", nil, nil
+	}
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if opts.Incremental {
+		commits, saved, err := loadIncremental(ctx, repo, head, key)
+		return commits, head, saved, err
+	}
+	commits, err := repo.LogCommits(ctx, logOptions(opts))
+	// On error commits may still hold what was read before ctx ended.
+	return commits, head, nil, err
+}
+
+// savedState is the incremental state a run adds its commits to.
+type savedState struct {
+	dir   string
+	state cache.State
+	// since is the head the state was saved at, when the commits are the
+	// ones made since; it is empty when they are the whole history.
+	since string
+}
+
+// save records the state as the totals of head.
+func (s *savedState) save(head string) error {
+	s.state.Head = head
+	return cache.SaveState(s.dir, s.state)
+}
+
+// loadIncremental returns the commits made since the saved state and the
+// state to add them to. The state is reused only while its head is still
+// an ancestor of head, it was saved under the same key and a shallow clone
+// was not deepened; after a rebase, reset, branch switch, fetch
+// --unshallow or a change of settings the full history is read into empty
+// totals.
+func loadIncremental(ctx context.Context, repo git.Backend, head, key string) ([]model.Commit, *savedState, error) {
+	gitDir, err := repo.GitDir(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	shallow, err := repo.ShallowCommits(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	saved := &savedState{dir: cache.Dir(gitDir)}
+	state, ok, err := cache.LoadState(saved.dir)
+	if err != nil || state.Key != key || !slices.Equal(state.Shallow, shallow) {
+		ok = false
+	}
+	if ok {
+		if state.Head == head {
+			saved.state = state
+			return []model.Commit{}, saved, nil
+		}
+		if commits, ok := logSince(ctx, repo, state.Head, head); ok {
+			saved.state, saved.since = state, state.Head
+			return commits, saved, nil
+		}
+	}
+	saved.state = cache.State{Version: cache.StateVersion, Key: key, Shallow: shallow, Authors: analyze.AuthorTotals{}}
+	// On error commits may still hold what was read before ctx ended.
+	commits, err := repo.LogCommits(ctx, git.LogOptions{})
+	return commits, saved, err
+}
+
+// logSince returns the commits of since..head. It fails when since is not
+// an ancestor of head: then no new commit has it as a parent.
+func logSince(ctx context.Context, repo git.Backend, since, head string) ([]model.Commit, bool) {
+	commits, err := repo.LogCommits(ctx, git.LogOptions{Revisions: []string{head, "^" + since}})
+	if err != nil {
+		return nil, false
+	}
+	for _, c := range commits {
+		for _, p := range c.Parents {
+			if p == since {
+				return commits, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// stateKey fingerprints what the totals of an incremental state depend on
+// besides the commits: the options that change how commits are judged, the
+// local time zone, the config file and the rules.
+func stateKey(opts Options, fileCfg config.File, custom []analyze.Rule) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s\n%t\n%s\n", cache.StateVersion, opts.TZ, opts.ExcludeBots, opts.Backend)
+	for _, month := range []time.Month{time.January, time.July} {
+		name, offset := time.Date(2024, month, 1, 0, 0, 0, 0, time.Local).Zone()
+		fmt.Fprintf(h, "%s %d\n", name, offset)
+	}
+	data, err := json.Marshal(fileCfg)
+	if err != nil {
+		return "", err
+	}
+	h.Write(data)
+	for _, r := range fileCfg.Scripts() {
+		src, err := os.ReadFile(r.Path())
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "\n%s\n", r.Path())
+		h.Write(src)
+	}
+	for _, r := range analyze.Rules(custom...) {
+		fmt.Fprintf(h, "\n%s\t%s\t%s\t%d", r.ID(), r.Description(), r.Category(), r.Weight())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func logOptions(opts Options) git.LogOptions {
+	lo := git.LogOptions{
+		Since:      opts.Since,
+		Until:      opts.Until,
+		Author:     opts.Author,
+		MaxCommits: opts.MaxCommits,
+	}
+	switch {
+	case len(opts.Revisions) > 0:
+		lo.Revisions = opts.Revisions
+	case opts.Range != "":
+		lo.Revisions = []string{opts.Range}
+	}
+	return lo
+}
+
+// loadSizes returns numstat summaries for every commit with Deep, or for a
+// sample of up to 500 commits otherwise. Only commits missing from the
+// cache are read from git. Merges are never cached: git log --numstat
+// leaves them empty while git show diffs them against the first parent.
+func loadSizes(ctx context.Context, repo git.Backend, store *cache.Cache, commits []model.Commit, boundary []string, opts Options) (map[string]model.CommitSize, bool, error) {
+	if len(commits) == 0 {
+		return map[string]model.CommitSize{}, false, nil
+	}
+	targets := commits
+	sampled := false
+	if !opts.Deep {
+		count := len(commits)
+		sampleSize := count
+		if count > 500 {
+			sampleSize = 500
+		}
+		targets = make([]model.Commit, 0, sampleSize)
+		for _, idx := range util.SampleIndices(count, sampleSize) {
+			targets = append(targets, commits[idx])
+		}
+		sampled = sampleSize < count
+	}
+
+	// Boundary commits of a shallow clone have no size: their numstat
+	// counts the whole tree.
+	skip := make(map[string]bool, len(boundary))
+	for _, sha := range boundary {
+		skip[sha] = true
+	}
+	sizes := make(map[string]model.CommitSize, len(targets))
+	merges := map[string]bool{}
+	missing := []string{}
+	for _, c := range targets {
+		if skip[c.SHA] {
+			continue
+		}
+		if len(c.Parents) > 1 {
+			merges[c.SHA] = true
+			if opts.Deep {
+				sizes[c.SHA] = model.CommitSize{}
+			} else {
+				missing = append(missing, c.SHA)
+			}
+			continue
+		}
+		if size, ok := store.Size(c.SHA); ok {
+			sizes[c.SHA] = size
+			continue
+		}
+		missing = append(missing, c.SHA)
+	}
+	if len(missing) == 0 {
+		return sizes, sampled, nil
+	}
+
+	var fetched map[string]model.CommitSize
+	var err error
+	if opts.Deep && git.Jobs(opts.Jobs) == 1 && len(missing) == len(targets)-len(merges)-len(boundary) {
+		// Nothing cached: one git log stream beats batched git show.
+		fetched, err = repo.NumstatForLog(ctx, logOptions(opts))
+	} else {
+		fetched, err = repo.NumstatForCommits(ctx, missing, opts.Jobs)
+	}
+	if err != nil && ctx.Err() == nil {
+		return nil, false, err
+	}
+	// After an interrupt fetched holds the commits read so far; the rest
+	// of the sample is left out.
+	for _, sha := range missing {
+		size, ok := fetched[sha]
+		if !ok {
+			continue
+		}
+		sizes[sha] = size
+		if !merges[sha] {
+			store.PutSize(sha, size)
+		}
+	}
+	if err != nil {
+		return sizes, true, err
+	}
+	return sizes, sampled, nil
+}
+
+// loadFiles returns the per-file numstat of the commits with a size, for
+// rules that match on paths. Like sizes, they are read through the cache.
+// Merges are left out, as their changes belong to the merged commits.
+func loadFiles(ctx context.Context, repo git.Backend, store *cache.Cache, commits []model.Commit, sizes map[string]model.CommitSize, jobs int) (map[string][]model.FileStat, error) {
+	files := make(map[string][]model.FileStat, len(sizes))
+	missing := []string{}
+	for _, c := range commits {
+		if _, ok := sizes[c.SHA]; !ok || len(c.Parents) > 1 {
+			continue
+		}
+		if stats, ok := store.Files(c.SHA); ok {
+			files[c.SHA] = stats
+			continue
+		}
+		missing = append(missing, c.SHA)
+	}
+	fetched, err := git.FileStatsForCommits(ctx, repo, missing, jobs)
+	if errors.Is(err, ErrUnsupported) {
+		// A Source without FileStats: path conditions match nothing.
+		return files, nil
+	}
+	for sha, stats := range fetched {
+		files[sha] = stats
+		store.PutFiles(sha, stats)
+	}
+	return files, err
+}
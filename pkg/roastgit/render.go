@@ -0,0 +1,51 @@
+package roastgit
+
+import (
+	"fmt"
+	"io"
+
+	"roastgit/internal/render"
+)
+
+// Renderer writes a report in one output format.
+type Renderer interface {
+	Render(w io.Writer, report Report) error
+}
+
+// RendererFunc adapts a function to a Renderer.
+type RendererFunc func(w io.Writer, report Report) error
+
+func (f RendererFunc) Render(w io.Writer, report Report) error { return f(w, report) }
+
+// TextOptions configures TextRenderer.
+type TextOptions struct {
+	// NoColor leaves out ANSI colors.
+	NoColor bool
+	// Explain adds how each part of the score was computed.
+	Explain bool
+}
+
+// TextRenderer writes the terminal report the roastgit command prints.
+func TextRenderer(opts TextOptions) Renderer {
+	return RendererFunc(func(w io.Writer, report Report) error {
+		_, err := fmt.Fprintln(w, render.Text(report, render.TextConfig{NoColor: opts.NoColor, Explain: opts.Explain}))
+		return err
+	})
+}
+
+// JSONRenderer writes the --json report in schema version, from 1 to
+// SchemaVersion; 0 means SchemaVersion. Older versions leave out the fields
+// added since.
+func JSONRenderer(version int) Renderer {
+	if version == 0 {
+		version = SchemaVersion
+	}
+	return RendererFunc(func(w io.Writer, report Report) error {
+		out, err := render.JSONCompat(report, version)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, out)
+		return err
+	})
+}
@@ -0,0 +1,220 @@
+package roastgit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"roastgit/internal/analyze"
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+	"roastgit/internal/roast"
+)
+
+// SchemaVersion is the version of the JSON report JSONRenderer writes by
+// default.
+const SchemaVersion = model.SchemaVersion
+
+// Report types. They are the --json document, so their JSON field names are
+// part of the schema.
+type (
+	// Report is the result of one analysis.
+	Report = model.Report
+	// Score is the overall score out of 100 and its breakdown.
+	Score = model.Score
+	// Offender is a flagged commit and the reasons it was flagged.
+	Offender = model.Offender
+	// RoastOutput is the generated roast text.
+	RoastOutput = model.RoastOutput
+)
+
+// History types, which a Source supplies.
+type (
+	// Commit is one commit of the history. Size is ignored; sizes come
+	// from Source.Sizes.
+	Commit = model.Commit
+	// CommitSize summarizes the files and lines a commit changed.
+	CommitSize = model.CommitSize
+	// FileStat is the numstat of one file in a commit.
+	FileStat = model.FileStat
+)
+
+// Failures Analyze reports, matched with errors.Is.
+var (
+	// ErrNotRepo is a Path that is not inside a git repository.
+	ErrNotRepo = git.ErrNotRepo
+	// ErrUnsafeRepo is git refusing a repository owned by another user
+	// until it is listed in safe.directory.
+	ErrUnsafeRepo = git.ErrUnsafeRepo
+	// ErrBadRevision is a Range or Revisions naming something that does
+	// not exist.
+	ErrBadRevision = git.ErrBadRevision
+	// ErrShallow is a revision missing because the repository is a shallow
+	// clone.
+	ErrShallow = git.ErrShallow
+)
+
+// ConfigError is an invalid config file, or a FailOn check that neither
+// roastgit nor the config file defines. It is told apart from git failures
+// because the user, not the repository, has to fix it.
+type ConfigError struct{ Err error }
+
+func (e ConfigError) Error() string { return e.Err.Error() }
+func (e ConfigError) Unwrap() error { return e.Err }
+
+// Options configures Analyze. The zero value analyzes the repository at the
+// working directory with the mildest roasts and lists every offender.
+type Options struct {
+	// Path is the repository: its work tree root, a bare repository, or
+	// any directory inside one. The config and baseline files and the
+	// .roastgit/rules scripts are looked up at its root.
+	Path string
+	// Source supplies the history instead of reading the repository at
+	// Path. Path may still be set to locate the config, baseline and rule
+	// files.
+	Source Source
+	// Name labels the report; it defaults to the name of Path.
+	Name string
+	// Backend reads the repository at Path: "exec" (the default) runs git,
+	// "native" reads .git directly.
+	Backend string
+	// ConfigFile replaces the .roastgit.json at the repository root.
+	ConfigFile string
+
+	// Since and Until bound commit dates, as YYYY-MM-DD.
+	Since string
+	Until string
+	// Author keeps commits whose author matches, like git log --author.
+	Author string
+	// Range limits the history to a revision range such as "v1.0..HEAD".
+	Range string
+	// Revisions selects commits with raw git log revisions; it takes
+	// precedence over Range.
+	Revisions []string
+	// MaxCommits keeps only the newest commits; 0 means all.
+	MaxCommits int
+	// ExcludeBots leaves automation commits out of the analysis.
+	ExcludeBots bool
+	// Deep reads the size of every commit instead of a sample of 500.
+	Deep bool
+	// TZ is "local" (the default) or "commit": the time zone in which
+	// midnight and weekend commits are judged.
+	TZ string
+
+	// Intensity is the roast intensity, 0 (mildest) to 5.
+	Intensity int
+	Wholesome bool
+	Censor    bool
+	// Top limits the offenders listed; 0 lists all of them.
+	Top int
+	// Trend adds a score time series per "week", "month" or "quarter".
+	Trend string
+
+	// Baseline replaces the .roastgit-baseline.json at the repository
+	// root; offenders it lists are hidden. NoBaseline ignores it.
+	Baseline   string
+	NoBaseline bool
+	// FailOn evaluates a gate over these checks (or "any") and records the
+	// verdict in Report.Gate. Checks are named by rule ID or reason and may
+	// be rules the config file declares.
+	FailOn []string
+	// RefuseShallow leaves the gate unevaluated when a shallow clone cut
+	// off the history.
+	RefuseShallow bool
+
+	// Jobs bounds concurrent numstat workers; 0 means one per CPU.
+	Jobs int
+	// NoCache neither reads nor writes the on-disk commit cache.
+	NoCache bool
+	// Incremental adds the commits made since the previous incremental
+	// analysis to the totals it saved. It implies Deep.
+	Incremental bool
+	// RecurseSubmodules nests a report of each checked-out submodule.
+	RecurseSubmodules bool
+	// Progress, when set, shows a spinner while large histories are read.
+	Progress io.Writer
+}
+
+// Analyze analyzes one history and returns its report. When ctx ends after
+// some commits were read, the report covers those and Report.Partial says
+// why it stopped; the error is only returned when nothing was read.
+func Analyze(ctx context.Context, opts Options) (Report, error) {
+	if err := opts.validate(); err != nil {
+		return Report{}, err
+	}
+	if opts.TZ == "" {
+		opts.TZ = "local"
+	}
+	var repo git.Backend
+	if opts.Source != nil {
+		repo = sourceBackend{opts.Source}
+	} else {
+		path, err := git.FindRepoRoot(opts.Path)
+		if err != nil {
+			return Report{}, err
+		}
+		opts.Path = path
+		if repo, err = git.Open(opts.Backend, path); err != nil {
+			return Report{}, err
+		}
+	}
+	defer repo.Close()
+	return analyzeRepo(ctx, repo, opts)
+}
+
+// Roast regenerates the roast text of report at other settings, as if it
+// had been analyzed with them. The same report and settings always give the
+// same text.
+func Roast(report Report, intensity int, wholesome, censor bool) RoastOutput {
+	if report.Repo.Head == "" {
+		return emptyRoast()
+	}
+	seed := roastSeed(report.Repo.Head, report.Repo.CommitCount, wholesome)
+	return roast.GenerateRoasts(report.Metrics, report.Score, intensity, wholesome, censor, seed)
+}
+
+// roastSeed picks the roast lines of a report, so the same history always
+// gets the same roast.
+func roastSeed(head string, commits int, wholesome bool) string {
+	return head + fmt.Sprintf("-%d-%t", commits, wholesome)
+}
+
+func emptyRoast() RoastOutput {
+	return RoastOutput{
+		Headline: "No commits yet. Nothing to roast... for now.",
+		Sections: map[string]string{},
+		Tips:     []string{"Make a first commit, then run roastgit again."},
+	}
+}
+
+func (o Options) validate() error {
+	switch {
+	case o.Intensity < 0 || o.Intensity > 5:
+		return fmt.Errorf("intensity must be between 0 and 5")
+	case o.TZ != "" && o.TZ != "local" && o.TZ != "commit":
+		return fmt.Errorf("tz must be \"local\" or \"commit\"")
+	case o.Top < 0 || o.MaxCommits < 0 || o.Jobs < 0:
+		return fmt.Errorf("top, max commits and jobs must be 0 or more")
+	case strings.HasPrefix(o.Range, "-"):
+		return fmt.Errorf("invalid revision range %q", o.Range)
+	case o.Source != nil && (o.Incremental || o.RecurseSubmodules):
+		return fmt.Errorf("incremental analysis and submodules need a repository, not a Source")
+	case o.Incremental && (o.Since != "" || o.Until != "" || o.Author != "" || o.MaxCommits != 0 || o.Range != "" || len(o.Revisions) > 0):
+		return fmt.Errorf("incremental analysis covers the whole history of HEAD and cannot be filtered")
+	case o.Incremental && o.Trend != "":
+		return fmt.Errorf("incremental analysis keeps totals, not the commits a trend is built from")
+	}
+	switch o.Trend {
+	case "", analyze.PeriodWeek, analyze.PeriodMonth, analyze.PeriodQuarter:
+	default:
+		return fmt.Errorf("trend must be %q, %q or %q", analyze.PeriodWeek, analyze.PeriodMonth, analyze.PeriodQuarter)
+	}
+	for _, date := range []string{o.Since, o.Until} {
+		if _, err := time.Parse("2006-01-02", date); date != "" && err != nil {
+			return fmt.Errorf("invalid date %q: want YYYY-MM-DD", date)
+		}
+	}
+	return nil
+}
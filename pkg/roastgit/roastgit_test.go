@@ -0,0 +1,303 @@
+package roastgit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"roastgit/internal/git"
+)
+
+func TestMemorySourceQuery(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.Local) }
+	src := NewMemorySource([]Commit{
+		{SHA: "d", AuthorName: "Bob", AuthorEmail: "bob@example.com", Date: day(4)},
+		{SHA: "c", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: day(3)},
+		{SHA: "b", AuthorName: "Jane", AuthorEmail: "jane@example.com", Date: day(2)},
+		{SHA: "a", AuthorName: "Bob", AuthorEmail: "bob@example.com", Date: day(1)},
+	}, nil)
+	ctx := context.Background()
+	for _, tc := range []struct {
+		q    Query
+		want string
+	}{
+		{Query{}, "dcba"},
+		{Query{Since: "2024-01-02", Until: "2024-01-03"}, "cb"},
+		{Query{Author: "jane@"}, "cb"},
+		{Query{Author: "^Bob", MaxCommits: 1}, "d"},
+	} {
+		commits, err := src.Commits(ctx, tc.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := ""
+		for _, c := range commits {
+			got += c.SHA
+		}
+		if got != tc.want {
+			t.Errorf("Commits(%+v) = %q, want %q", tc.q, got, tc.want)
+		}
+	}
+	if _, err := src.Commits(ctx, Query{Revisions: []string{"v1..v2"}}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("revisions: err = %v, want ErrUnsupported", err)
+	}
+	if _, err := Analyze(ctx, Options{Source: src, Range: "v1..v2"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Analyze with a range: err = %v, want ErrUnsupported", err)
+	}
+}
+
+func TestAnalyzeOptions(t *testing.T) {
+	ctx := context.Background()
+	for _, opts := range []Options{
+		{Intensity: 6},
+		{TZ: "utc"},
+		{Top: -1},
+		{Since: "last week"},
+		{Trend: "day"},
+		{FailOn: []string{"nope"}},
+		{Source: NewMemorySource(nil, nil), Incremental: true},
+		{Incremental: true, Trend: "week"},
+	} {
+		if _, err := Analyze(ctx, opts); err == nil {
+			t.Errorf("Analyze(%+v) should fail", opts)
+		}
+	}
+	report, err := Analyze(ctx, Options{Source: NewMemorySource(nil, nil)})
+	if err != nil || report.Repo.CommitCount != 0 || report.Roasts.Headline == "" {
+		t.Fatalf("empty source: %+v, %v", report, err)
+	}
+}
+
+func TestConfigRules(t *testing.T) {
+	cfgPath := filepath.Join(t.TempDir(), "roastgit.json")
+	data := `{"rules": [
+		{"id": "no-ticket", "description": "no ticket", "weight": 2, "subject": "^[^A-Z]*$"},
+		{"id": "vendored", "description": "vendored edit", "weight": 3, "path": "^vendor/"}
+	]}`
+	if err := os.WriteFile(cfgPath, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	src := NewMemorySource([]Commit{
+		{SHA: "b", AuthorName: "Jane", Date: day(2), Subject: "fix login redirect"},
+		{SHA: "a", AuthorName: "Jane", Date: day(1), Subject: "PROJ-12 Add the login form"},
+	}, map[string]CommitSize{"b": {Files: 1, Added: 4}, "a": {Files: 2, Added: 90}})
+	ctx := context.Background()
+	report, err := Analyze(ctx, Options{Source: src, ConfigFile: cfgPath, TZ: "commit", FailOn: []string{"no-ticket"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off := report.Offenders; len(off) != 1 || off[0].SHA != "b" || off[0].Reasons[len(off[0].Reasons)-1] != "no ticket" {
+		t.Fatalf("unexpected offenders: %+v", report.Offenders)
+	}
+	if report.Gate == nil || report.Gate.Violations != 1 {
+		t.Fatalf("expected the gate to count the custom rule, got %+v", report.Gate)
+	}
+	_, err = Analyze(ctx, Options{Source: src, ConfigFile: cfgPath, FailOn: []string{"no-tickets"}})
+	if !errors.As(err, new(ConfigError)) {
+		t.Fatalf("unknown FailOn check: err = %v, want a ConfigError", err)
+	}
+}
+
+func TestScriptRules(t *testing.T) {
+	repo := t.TempDir()
+	dir := filepath.Join(repo, ".roastgit", "rules")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	script := "def check(commit):\n    if commit.size.lines > 50:\n        return (\"%d lines from %s\" % (commit.size.lines, commit.author), 3)\n"
+	if err := os.WriteFile(filepath.Join(dir, "chunky.star"), []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+	commits := []Commit{
+		{SHA: "b", AuthorName: "Jane", Date: day(2), Subject: "Add the signup form"},
+		{SHA: "a", AuthorName: "Jane", Date: day(1), Subject: "Add the login form"},
+	}
+	src := NewMemorySource(commits, map[string]CommitSize{"b": {Files: 1, Added: 4}, "a": {Files: 2, Added: 90}})
+	ctx := context.Background()
+	report, err := Analyze(ctx, Options{Source: src, Path: repo, TZ: "commit", Deep: true, FailOn: []string{"chunky"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off := report.Offenders; len(off) != 1 || off[0].SHA != "a" || off[0].Reasons[0] != "90 lines from Jane" || off[0].Score != 3 {
+		t.Fatalf("unexpected offenders: %+v", report.Offenders)
+	}
+	if report.Gate == nil || report.Gate.Violations != 1 {
+		t.Fatalf("expected the gate to count the script, got %+v", report.Gate)
+	}
+
+	// A commit without a size breaks the script, which fails the run.
+	src = NewMemorySource(commits, map[string]CommitSize{"a": {Files: 2, Added: 90}})
+	_, err = Analyze(ctx, Options{Source: src, Path: repo, TZ: "commit", Deep: true})
+	if !errors.As(err, new(ConfigError)) || !strings.Contains(err.Error(), "chunky.star: commit b: line 2: NoneType has no attribute lines") {
+		t.Fatalf("err = %v, want the script's ConfigError", err)
+	}
+}
+
+// TestSourceMatchesRepository analyzes a repository on disk and the same
+// history served by a Source, which must score the same.
+func TestSourceMatchesRepository(t *testing.T) {
+	if err := git.EnsureGit(); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	for i, subject := range []string{"Initial commit", "wip", "fix", "Add the parser for config files"} {
+		date := fmt.Sprintf("2024-02-%02dT%02d:00:00+01:00", 1+i, 9+i*4)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte(subject+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-q", "-m", subject}} {
+			if i > 0 && args[0] == "init" {
+				continue
+			}
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane", "GIT_AUTHOR_EMAIL=jane@example.com",
+				"GIT_COMMITTER_NAME=Jane", "GIT_COMMITTER_EMAIL=jane@example.com",
+				"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date, "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	fromRepo, err := Analyze(ctx, Options{Path: dir, TZ: "commit", Deep: true, NoCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := git.Open(git.BackendExec, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	commits, err := repo.LogCommits(ctx, git.LogOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shas := make([]string, len(commits))
+	for i, c := range commits {
+		shas[i] = c.SHA
+	}
+	sizes, err := repo.NumstatForCommits(ctx, shas, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromSource, err := Analyze(ctx, Options{Source: NewMemorySource(commits, sizes), Path: dir, TZ: "commit", Deep: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromSource.Score, fromRepo.Score) || len(fromSource.Offenders) != len(fromRepo.Offenders) ||
+		fromSource.Roasts.Headline != fromRepo.Roasts.Headline {
+		t.Fatalf("source report differs:\nrepo   %+v %v\nsource %+v %v", fromRepo.Score, fromRepo.Offenders, fromSource.Score, fromSource.Offenders)
+	}
+}
+
+// TestCacheSwitchBackends analyzes a repository with both backends on one
+// cache directory. They disagree on an edited rename, so each must get its
+// own sizes back rather than the other's.
+func TestCacheSwitchBackends(t *testing.T) {
+	if err := git.EnsureGit(); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane", "GIT_AUTHOR_EMAIL=jane@example.com",
+			"GIT_COMMITTER_NAME=Jane", "GIT_COMMITTER_EMAIL=jane@example.com", "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	lines := strings.Repeat("line\n", 40)
+	if err := os.WriteFile(filepath.Join(dir, "old.txt"), []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("init", "-q")
+	run("add", ".")
+	run("commit", "-q", "-m", "Add the notes")
+	run("mv", "old.txt", "new.txt")
+	if err := os.WriteFile(filepath.Join(dir, "new.txt"), []byte(lines+"more\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run("commit", "-q", "-am", "Rename the notes")
+
+	ctx := context.Background()
+	for _, backend := range []string{git.BackendExec, git.BackendNative, git.BackendExec} {
+		uncached, err := Analyze(ctx, Options{Path: dir, Backend: backend, TZ: "commit", NoCache: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cached, err := Analyze(ctx, Options{Path: dir, Backend: backend, TZ: "commit"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cached.Metrics.Size != uncached.Metrics.Size {
+			t.Fatalf("%s: cached sizes %+v, want %+v", backend, cached.Metrics.Size, uncached.Metrics.Size)
+		}
+	}
+}
+
+// TestIncrementalMatchesFullRun adds commits to a saved state across a
+// panic window that starts before the saved head.
+func TestIncrementalMatchesFullRun(t *testing.T) {
+	if err := git.EnsureGit(); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	commit := func(i int, subject, date string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("f%d.txt", i)), []byte(subject+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-q", "-m", subject}} {
+			if i > 0 && args[0] == "init" {
+				continue
+			}
+			cmd := exec.Command("git", args...)
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane", "GIT_AUTHOR_EMAIL=jane@example.com",
+				"GIT_COMMITTER_NAME=Jane", "GIT_COMMITTER_EMAIL=jane@example.com",
+				"GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date, "GIT_CONFIG_NOSYSTEM=1", "HOME="+dir)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("git %v: %v\n%s", args, err, out)
+			}
+		}
+	}
+	ctx := context.Background()
+	opts := Options{Path: dir, TZ: "commit", Incremental: true}
+	commit(0, "Initial commit", "2024-02-01T12:00:00+01:00")
+	commit(1, "wip", "2024-02-01T15:00:00+01:00")
+	commit(2, "fix", "2024-02-01T15:10:00+01:00")
+	if _, err := Analyze(ctx, opts); err != nil {
+		t.Fatal(err)
+	}
+	commit(3, "tmp", "2024-02-01T15:20:00+01:00")
+	commit(4, "oops", "2024-02-01T15:30:00+01:00")
+	commit(5, "Add the parser for config files", "2024-02-02T20:00:00+01:00")
+	got, err := Analyze(ctx, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := Analyze(ctx, Options{Path: dir, TZ: "commit", Deep: true, NoCache: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Metrics.Message.Panic != 4 || want.Metrics.Time.LongestStreakDays != 2 {
+		t.Fatalf("unexpected full run metrics: %+v", want.Metrics)
+	}
+	if !reflect.DeepEqual(got.Metrics, want.Metrics) || !reflect.DeepEqual(got.Offenders, want.Offenders) ||
+		!reflect.DeepEqual(got.Authors, want.Authors) || got.Repo != want.Repo || !reflect.DeepEqual(got.Score, want.Score) {
+		t.Fatalf("incremental report differs:\n got %+v\nwant %+v", got, want)
+	}
+}
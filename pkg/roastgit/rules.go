@@ -0,0 +1,52 @@
+package roastgit
+
+import "roastgit/internal/analyze"
+
+// Rule types. A Rule flags commits as offenders; the built-in checks are
+// rules too, and a report lists the reasons of every rule in the order
+// Rules returns them.
+type (
+	// Rule is one offender check: an ID, the reason it reports, a
+	// category, a weight and the function judging each commit.
+	Rule = analyze.Rule
+	// FileRule is a Rule that needs the per-file numstat of commits.
+	FileRule = analyze.FileRule
+	// Finding is one reason a rule flags a commit for, with its score.
+	Finding = analyze.Finding
+	// CommitFacts is what a rule sees of one commit.
+	CommitFacts = analyze.CommitFacts
+	// Pattern matches commits by subject, author, path and size.
+	Pattern = analyze.Pattern
+)
+
+// Rule categories of the built-in rules, and the default of declared ones.
+const (
+	CategoryMessage = analyze.CategoryMessage
+	CategorySize    = analyze.CategorySize
+	CategoryTime    = analyze.CategoryTime
+	CategoryCustom  = analyze.CategoryCustom
+)
+
+// NewRule returns a rule flagging the commits match accepts, reported with
+// description as the reason and scored with weight.
+func NewRule(id, description, category string, weight int, match func(*CommitFacts) bool) Rule {
+	return analyze.NewRule(id, description, category, weight, match)
+}
+
+// PatternRule returns a rule flagging the commits p matches, like a rule
+// declared in the config file.
+func PatternRule(id, description, category string, weight int, p Pattern) Rule {
+	return analyze.PatternRule(id, description, category, weight, p)
+}
+
+// RegisterRule adds rules to every analysis in the process, after the
+// built-in ones, so FailOn, allowlists and Roastgit-Ignore trailers can
+// name them. Call it from an init function; it panics when an ID is taken.
+func RegisterRule(rules ...Rule) {
+	analyze.Register(rules...)
+}
+
+// Rules lists the built-in and registered rules in report order.
+func Rules() []Rule {
+	return analyze.Rules()
+}
@@ -0,0 +1,179 @@
+package roastgit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+)
+
+// Source supplies a history to Analyze in place of a git repository, such
+// as commits already loaded by a service or fetched from a code host.
+//
+// A Source may also implement Branches(ctx) ([]string, error), to have
+// branch names judged, and FileStats(ctx, sha) ([]FileStat, error).
+type Source interface {
+	// Head names the newest commit, or returns "" when there are none.
+	Head(ctx context.Context) (string, error)
+	// Commits lists the commits q selects, newest first.
+	Commits(ctx context.Context, q Query) ([]Commit, error)
+	// Sizes returns the size of each commit in shas. Commits it cannot
+	// size are left out and count as unknown.
+	Sizes(ctx context.Context, shas []string) (map[string]CommitSize, error)
+}
+
+// Query selects the commits a Source lists. Its fields come from Options.
+type Query struct {
+	// Since and Until bound commit dates, as YYYY-MM-DD; both days are
+	// included.
+	Since string
+	Until string
+	// Author is a regular expression matched against "Name <email>".
+	Author string
+	// MaxCommits keeps only the newest commits; 0 means all.
+	MaxCommits int
+	// Revisions are git log revisions from Options.Range or
+	// Options.Revisions; sources without revisions should reject them.
+	Revisions []string
+}
+
+// ErrUnsupported is returned by a Source for a query it cannot answer.
+var ErrUnsupported = errors.New("not supported by this source")
+
+// NewMemorySource returns a Source over commits held in memory, newest
+// first, with the sizes known for them. It answers every query but
+// Revisions; dates are compared in the local time zone.
+func NewMemorySource(commits []Commit, sizes map[string]CommitSize) Source {
+	return memorySource{commits: commits, sizes: sizes}
+}
+
+type memorySource struct {
+	commits []Commit
+	sizes   map[string]CommitSize
+}
+
+func (m memorySource) Head(ctx context.Context) (string, error) {
+	if len(m.commits) == 0 {
+		return "", nil
+	}
+	return m.commits[0].SHA, nil
+}
+
+func (m memorySource) Commits(ctx context.Context, q Query) ([]Commit, error) {
+	if len(q.Revisions) > 0 {
+		return nil, fmt.Errorf("revisions: %w", ErrUnsupported)
+	}
+	var since, until time.Time
+	var err error
+	if q.Since != "" {
+		if since, err = time.ParseInLocation("2006-01-02", q.Since, time.Local); err != nil {
+			return nil, err
+		}
+	}
+	if q.Until != "" {
+		if until, err = time.ParseInLocation("2006-01-02", q.Until, time.Local); err != nil {
+			return nil, err
+		}
+		until = until.AddDate(0, 0, 1)
+	}
+	var author *regexp.Regexp
+	if q.Author != "" {
+		if author, err = regexp.Compile(q.Author); err != nil {
+			return nil, fmt.Errorf("author: %w", err)
+		}
+	}
+	out := []Commit{}
+	for _, c := range m.commits {
+		switch {
+		case !since.IsZero() && c.Date.Before(since):
+		case !until.IsZero() && !c.Date.Before(until):
+		case author != nil && !author.MatchString(c.AuthorName+" <"+c.AuthorEmail+">"):
+		default:
+			out = append(out, c)
+		}
+		if q.MaxCommits > 0 && len(out) == q.MaxCommits {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (m memorySource) Sizes(ctx context.Context, shas []string) (map[string]CommitSize, error) {
+	out := make(map[string]CommitSize, len(shas))
+	for _, sha := range shas {
+		if size, ok := m.sizes[sha]; ok {
+			out[sha] = size
+		}
+	}
+	return out, nil
+}
+
+// sourceBackend reads a Source through the interface the pipeline uses for
+// git repositories. It has no git directory, so nothing is cached.
+type sourceBackend struct {
+	src Source
+}
+
+var errNoGitDir = errors.New("source has no git directory")
+
+func (b sourceBackend) IsRepo(ctx context.Context) bool { return true }
+
+func (b sourceBackend) HeadSHA(ctx context.Context) (string, error) {
+	head, err := b.src.Head(ctx)
+	if err == nil && head == "" {
+		return "", git.ErrUnbornHead
+	}
+	return head, err
+}
+
+func (b sourceBackend) GitDir(ctx context.Context) (string, error) { return "", errNoGitDir }
+
+func (b sourceBackend) ShallowCommits(ctx context.Context) ([]string, error) { return nil, nil }
+
+func (b sourceBackend) Branches(ctx context.Context) ([]string, error) {
+	if lister, ok := b.src.(interface {
+		Branches(context.Context) ([]string, error)
+	}); ok {
+		return lister.Branches(ctx)
+	}
+	return nil, ErrUnsupported
+}
+
+func (b sourceBackend) ResolveCommit(ctx context.Context, rev string) (string, error) {
+	return "", ErrUnsupported
+}
+
+func (b sourceBackend) LogCommits(ctx context.Context, opts git.LogOptions) ([]model.Commit, error) {
+	return b.src.Commits(ctx, Query(opts))
+}
+
+func (b sourceBackend) NumstatForCommits(ctx context.Context, shas []string, jobs int) (map[string]model.CommitSize, error) {
+	return b.src.Sizes(ctx, shas)
+}
+
+func (b sourceBackend) NumstatForLog(ctx context.Context, opts git.LogOptions) (map[string]model.CommitSize, error) {
+	commits, err := b.LogCommits(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	shas := make([]string, len(commits))
+	for i, c := range commits {
+		shas[i] = c.SHA
+	}
+	return b.src.Sizes(ctx, shas)
+}
+
+func (b sourceBackend) FileStats(ctx context.Context, sha string) ([]model.FileStat, error) {
+	if statter, ok := b.src.(interface {
+		FileStats(context.Context, string) ([]model.FileStat, error)
+	}); ok {
+		return statter.FileStats(ctx, sha)
+	}
+	return nil, ErrUnsupported
+}
+
+func (b sourceBackend) Close() error { return nil }
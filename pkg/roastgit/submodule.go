@@ -0,0 +1,49 @@
+package roastgit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"roastgit/internal/git"
+	"roastgit/internal/model"
+)
+
+// submoduleReports analyzes each submodule of the work tree at opts.Path
+// over the same date and author filters, recursing into their own
+// submodules. Revision ranges name superproject commits and the gate,
+// baseline and config file belong to the superproject, so they are not
+// passed down.
+func submoduleReports(ctx context.Context, opts Options) ([]model.SubmoduleReport, error) {
+	paths, err := git.Submodules(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	subOpts := opts
+	subOpts.Name, subOpts.Range, subOpts.Revisions = "", "", nil
+	subOpts.ConfigFile, subOpts.Baseline, subOpts.FailOn = "", "", nil
+	subOpts.Progress = nil
+
+	reports := []model.SubmoduleReport{}
+	for _, path := range paths {
+		sub := model.SubmoduleReport{Path: path}
+		dir := filepath.Join(opts.Path, filepath.FromSlash(path))
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err != nil {
+			sub.Error = "not checked out; run git submodule update --init"
+			reports = append(reports, sub)
+			continue
+		}
+		subOpts.Path = dir
+		report, err := Analyze(ctx, subOpts)
+		switch {
+		case err == nil:
+			sub.Report = &report
+		case ctx.Err() != nil:
+			sub.Error = "interrupted before any commits were analyzed"
+		default:
+			sub.Error = git.Reason(err)
+		}
+		reports = append(reports, sub)
+	}
+	return reports, nil
+}